@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// webhookTimeout bounds one alert delivery
+const webhookTimeout = 10 * time.Second
+
+// checkAlerts counts a newly kept post toward the mention-rate rules and
+// reports any that fire. Delivery runs in the background so a slow webhook
+// never holds up the pipeline.
+func (s *scraper) checkAlerts(p domain.Post) {
+	for _, a := range s.alerts.Observe(p, time.Now()) {
+		s.logger.Warn("Mention rate alert", "kind", a.Kind, "name", a.Name, "count", a.Count, "window", a.Window)
+		if s.webhook == nil {
+			continue
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+			defer cancel()
+			if err := s.webhook.Send(ctx, a); err != nil {
+				s.logger.Error("Alert webhook failed", "kind", a.Kind, "name", a.Name, "err", err)
+			}
+		}()
+	}
+}
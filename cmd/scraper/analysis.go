@@ -0,0 +1,102 @@
+package main
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// defaultQueueSize is the capacity of each channel between pipeline stages
+const defaultQueueSize = 100
+
+// rawBatch is one target's fetched, not yet filtered posts
+type rawBatch struct {
+	target domain.Target
+	posts  []domain.Post
+}
+
+// publishFunc hands a target's fetched posts to the analysis stage
+type publishFunc func(t domain.Target, posts []domain.Post)
+
+// startAnalysis returns how fetch workers publish their posts. With no
+// analysis pool (ANALYSIS_WORKERS=0) they filter inline, as before; otherwise
+// batches go through a queue to the pool so slow matching never holds up
+// fetching. The returned stop waits for the pool to drain; call it once the
+// fetch workers are done.
+func (s *scraper) startAnalysis(matched chan<- domain.Post, errs *errorCounts) (publish publishFunc, stop func()) {
+	if s.analysisWorkers <= 0 {
+		return func(t domain.Target, posts []domain.Post) { s.analyze(t, posts, matched) }, func() {}
+	}
+
+	raw := make(chan rawBatch, s.queueSize())
+	var wg sync.WaitGroup
+	for i := 0; i < s.analysisWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range raw {
+				s.analyzeSafe(b, matched, errs)
+			}
+		}()
+	}
+	publish = func(t domain.Target, posts []domain.Post) { raw <- rawBatch{target: t, posts: posts} }
+	stop = func() {
+		close(raw)
+		wg.Wait()
+	}
+	return publish, stop
+}
+
+// analyzeSafe is analyze for the pool: a panic drops the batch, not the worker
+func (s *scraper) analyzeSafe(b rawBatch, matched chan<- domain.Post, errs *errorCounts) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			errs.inc("panic")
+			s.logger.Error("Analysis panic recovered", "sub", b.target.Name(), "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	s.analyze(b.target, b.posts, matched)
+}
+
+// analyze filters a target's posts and publishes the new survivors
+func (s *scraper) analyze(t domain.Target, posts []domain.Post, matched chan<- domain.Post) {
+	for _, p := range s.processPosts(t, posts) {
+		publish, first := s.claim(p.ID)
+		if !publish {
+			continue
+		}
+		if first {
+			s.checkAlerts(p)
+			s.progress.matched.Add(1)
+		}
+		matched <- p
+	}
+}
+
+// claim decides whether a kept post is published. Posts already written
+// (DEDUP_SCOPE) are skipped, but another target's copy of a post published
+// this cycle goes on so the cycle's buffer can merge it into the first.
+func (s *scraper) claim(id string) (publish, first bool) {
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+	if s.cycleIDs[id] {
+		return true, false
+	}
+	if s.seen != nil && !s.seen.Add(id) {
+		return false, false
+	}
+	if s.cycleIDs == nil {
+		s.cycleIDs = make(map[string]bool)
+	}
+	s.cycleIDs[id] = true
+	return true, true
+}
+
+func (s *scraper) queueSize() int {
+	if s.pipelineQueue > 0 {
+		return s.pipelineQueue
+	}
+	return defaultQueueSize
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// panickyMatcher is a stub analysis stage that blows up on one title
+type panickyMatcher struct{}
+
+func (panickyMatcher) Match(text string) []string {
+	if text == "boom" {
+		panic("matcher bug")
+	}
+	if strings.Contains(strings.ToLower(text), "splunk") {
+		return []string{"splunk"}
+	}
+	return nil
+}
+
+func TestAnalysisPoolFiltersEveryBatch(t *testing.T) {
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+	s.matcher = panickyMatcher{}
+	s.analysisWorkers = 3
+	s.pipelineQueue = 1
+
+	matched := make(chan domain.Post, 1000)
+	errs := &errorCounts{counts: map[string]int{}}
+	publish, stop := s.startAnalysis(matched, errs)
+
+	// Several fetch workers publish at once, as in a cycle
+	var fetchers sync.WaitGroup
+	for w := range 4 {
+		fetchers.Add(1)
+		go func() {
+			defer fetchers.Done()
+			for b := range 10 {
+				target := domain.Target{Subreddit: fmt.Sprintf("sub%d", w), MinScore: 100}
+				publish(target, []domain.Post{
+					{ID: fmt.Sprintf("hit-%d-%d", w, b), Title: "Splunk tips"},
+					{ID: fmt.Sprintf("miss-%d-%d", w, b), Title: "Weekly thread"},
+				})
+			}
+		}()
+	}
+	// One batch panics; the pool drops it and keeps going
+	publish(domain.Target{Subreddit: "netsec", MinScore: 100}, []domain.Post{{ID: "bad", Title: "boom"}})
+	fetchers.Wait()
+	stop()
+	close(matched)
+
+	var ids []string
+	for p := range matched {
+		ids = append(ids, p.ID)
+	}
+	slices.Sort(ids)
+	if len(ids) != 40 || slices.ContainsFunc(ids, func(id string) bool { return !strings.HasPrefix(id, "hit-") }) || len(slices.Compact(slices.Clone(ids))) != 40 {
+		t.Errorf("matched %d posts %v, want each of the 40 hits once", len(ids), ids)
+	}
+	if s.panics.Load() != 1 || errs.counts["panic"] != 1 {
+		t.Errorf("panics = %d, counted %d; want 1", s.panics.Load(), errs.counts["panic"])
+	}
+}
+
+func TestAnalysisPoolMatchesInlineResults(t *testing.T) {
+	stored := func(workers int) []string {
+		s := newPipelineScraper(t, &crosspostStub{}, manyTargets(6), "Splunk")
+		s.analysisWorkers = workers
+		if _, err := s.runCycle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		posts, err := storage.LoadPosts(s.dataFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	inline, pooled := stored(0), stored(4)
+	if len(inline) != 7 || !slices.Equal(inline, pooled) {
+		t.Errorf("inline stored %v, pooled %v; want the same 7 posts", inline, pooled)
+	}
+}
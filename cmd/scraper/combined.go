@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// maxListingLimit is the most posts Reddit returns for one listing request
+const maxListingLimit = 100
+
+// combinable reports whether t can share a /r/a+b+c/new request
+// (COMBINE_SUBREDDITS): a plain subreddit polled only on /new, without
+// INCREMENTAL's per-subreddit cursors
+func (s *scraper) combinable(t domain.Target) bool {
+	return s.combineSubs > 1 && !s.incremental && t.Kind != domain.KindMulti &&
+		slices.Equal(s.targetSorts(t), []string{"new"})
+}
+
+// fetchJobs turns the cycle's targets into jobs. With a collector that can
+// combine listings, combinable targets are grouped up to combineSubs per
+// job; everything else is one job per target, in order.
+func (s *scraper) fetchJobs(order []domain.Target) []fetchJob {
+	_, canCombine := s.client.(domain.CombinedFetcher)
+	var jobs []fetchJob
+	var group []domain.Target
+	for _, t := range order {
+		if !canCombine || !s.combinable(t) {
+			jobs = append(jobs, fetchJob{target: t})
+			continue
+		}
+		group = append(group, t)
+		if len(group) == s.combineSubs {
+			jobs = append(jobs, fetchJob{target: group[0], group: group})
+			group = nil
+		}
+	}
+	switch len(group) {
+	case 0:
+	case 1:
+		jobs = append(jobs, fetchJob{target: group[0]})
+	default:
+		jobs = append(jobs, fetchJob{target: group[0], group: group})
+	}
+	return jobs
+}
+
+// scrapeCombined fetches a group of targets' /new listings in one request and
+// publishes each target's share of it. The limit grows with the group (up to
+// what Reddit returns at once), but busy subreddits can still crowd out quiet
+// ones. If the combined request fails the targets are fetched one by one, so
+// a single private or banned subreddit doesn't sink the rest.
+func (s *scraper) scrapeCombined(ctx context.Context, group []domain.Target, publish publishFunc, errs *errorCounts, abort context.CancelFunc) {
+	if s.spacing != nil {
+		var wait time.Duration
+		for _, t := range group {
+			wait = max(wait, s.spacing.Reserve(t, time.Now()))
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	posts, err := s.fetchCombined(ctx, group, errs)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		if errors.Is(err, collector.ErrUnauthorized) {
+			s.logger.Error("Authentication failed, aborting cycle", "subs", len(group), "err", err)
+			abort()
+			return
+		}
+		s.logger.Warn("Combined fetch failed, fetching its targets one by one", "subs", len(group), "err", err)
+		for _, t := range group {
+			s.fetchTarget(ctx, t, publish, errs, abort)
+		}
+		return
+	}
+
+	bySub := make(map[string][]domain.Post, len(group))
+	for _, p := range posts {
+		key := combinedKey(p.Subreddit)
+		bySub[key] = append(bySub[key], p)
+	}
+	errs.succeed()
+	for _, t := range group {
+		s.recordHealth(t, nil)
+		share := bySub[combinedKey(t.Subreddit)]
+		s.logger.Debug("Fetched target", "sub", t.Name(), "posts", len(share), "combined", len(group))
+		publish(t, share)
+	}
+}
+
+// fetchCombined makes the combined request, containing a collector panic
+// the way fetchTarget does
+func (s *scraper) fetchCombined(ctx context.Context, group []domain.Target, errs *errorCounts) (posts []domain.Post, err error) {
+	subs := make([]string, len(group))
+	for i, t := range group {
+		subs[i] = t.Subreddit
+	}
+	defer s.active.start("combined " + strings.Join(subs, "+"))()
+	defer func() {
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			errs.inc("panic")
+			s.logger.Error("Worker panic recovered", "subs", subs, "panic", r, "stack", string(debug.Stack()))
+			err = errors.New("combined fetch panicked")
+		}
+	}()
+	limit := min(s.searchLimit*len(group), maxListingLimit)
+	return s.client.(domain.CombinedFetcher).FetchCombined(ctx, subs, limit)
+}
+
+// combinedKey matches a post's subreddit ("r/NetSec") to a target's ("netsec")
+func combinedKey(sub string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(sub), "r/"))
+}
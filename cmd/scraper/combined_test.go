@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// combinedStub serves a canned combined listing and counts the requests
+type combinedStub struct {
+	collector.MockClient
+	mu       sync.Mutex
+	combined [][]string
+	single   []string
+	posts    []domain.Post
+	err      error
+}
+
+func (c *combinedStub) FetchCombined(_ context.Context, subs []string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.combined = append(c.combined, subs)
+	return c.posts, c.err
+}
+
+func (c *combinedStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.single = append(c.single, sub)
+	return []domain.Post{{ID: "solo_" + sub, Subreddit: sub}}, nil
+}
+
+func newCombinedScraper(t *testing.T, client domain.Collector, combine int) *scraper {
+	return &scraper{
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:      client,
+		searchLimit: 25,
+		combineSubs: combine,
+		healthFile:  filepath.Join(t.TempDir(), "health.json"),
+	}
+}
+
+func TestFetchJobsGroupsNewOnlySubreddits(t *testing.T) {
+	s := newCombinedScraper(t, &combinedStub{}, 2)
+	order := []domain.Target{
+		{Subreddit: "a"},
+		{Subreddit: "b"},
+		{Subreddit: "hot", Sorts: []string{"hot"}},
+		{Kind: domain.KindMulti, Owner: "o", Multi: "m"},
+		{Subreddit: "c"},
+		{Subreddit: "d"},
+		{Subreddit: "e"},
+	}
+	var got []int
+	for _, j := range s.fetchJobs(order) {
+		got = append(got, len(j.group))
+	}
+	// a+b, hot, multi, c+d, then e alone
+	if want := []int{2, 0, 0, 2, 0}; !slices.Equal(got, want) {
+		t.Fatalf("group sizes = %v, want %v", got, want)
+	}
+
+	s.incremental = true
+	if jobs := s.fetchJobs(order); len(jobs) != len(order) {
+		t.Errorf("INCREMENTAL should disable combining, got %d jobs for %d targets", len(jobs), len(order))
+	}
+}
+
+func TestScrapeCombinedAttributesPostsToTheirSubreddit(t *testing.T) {
+	stub := &combinedStub{posts: []domain.Post{
+		{ID: "1", Subreddit: "r/NetSec"},
+		{ID: "2", Subreddit: "r/malware"},
+		{ID: "3", Subreddit: "r/netsec"},
+	}}
+	s := newCombinedScraper(t, stub, 3)
+	group := []domain.Target{{Subreddit: "netsec"}, {Subreddit: "Malware"}, {Subreddit: "blueteam"}}
+
+	got := map[string][]string{}
+	publish := func(t domain.Target, posts []domain.Post) {
+		for _, p := range posts {
+			got[t.Name()] = append(got[t.Name()], p.ID)
+		}
+	}
+	s.scrapeCombined(context.Background(), group, publish, &errorCounts{}, func() {})
+
+	if len(stub.combined) != 1 || len(stub.single) != 0 {
+		t.Fatalf("requests: combined %v, single %v; want one combined", stub.combined, stub.single)
+	}
+	if !slices.Equal(got["netsec"], []string{"1", "3"}) || !slices.Equal(got["Malware"], []string{"2"}) || len(got["blueteam"]) != 0 {
+		t.Errorf("attribution = %v", got)
+	}
+}
+
+func TestScrapeCombinedFallsBackPerTarget(t *testing.T) {
+	stub := &combinedStub{err: &collector.FetchError{Mode: "public", Target: "r/a+b", Err: collector.ErrForbidden}}
+	s := newCombinedScraper(t, stub, 2)
+	group := []domain.Target{{Subreddit: "a"}, {Subreddit: "b"}}
+
+	var published []string
+	var mu sync.Mutex
+	publish := func(t domain.Target, posts []domain.Post) {
+		mu.Lock()
+		defer mu.Unlock()
+		published = append(published, t.Name())
+	}
+	s.scrapeCombined(context.Background(), group, publish, &errorCounts{}, func() {})
+
+	if !slices.Equal(stub.single, []string{"a", "b"}) || !slices.Equal(published, []string{"a", "b"}) {
+		t.Errorf("fallback fetched %v and published %v, want a and b", stub.single, published)
+	}
+}
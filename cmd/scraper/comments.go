@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// commentsOutput is what -comments prints
+type commentsOutput struct {
+	URL      string           `json:"url"`
+	Count    int              `json:"count"`
+	Comments []domain.Comment `json:"comments"`
+	// Truncated counts replies Reddit left behind "load more" stubs
+	Truncated int `json:"truncated,omitempty"`
+}
+
+// dumpComments fetches a single thread's comments and writes them to w as
+// JSON, returning the process exit code
+func dumpComments(ctx context.Context, logger *slog.Logger, w io.Writer, permalink string) int {
+	client, err := collector.NewCollector()
+	if err != nil {
+		logger.Error("Failed to initialize collector", "error", err)
+		return exitConfig
+	}
+	cf, ok := client.(domain.CommentFetcher)
+	if !ok {
+		logger.Error("This collector can't fetch comments", "mode", os.Getenv("COLLECTOR_MODE"))
+		return exitConfig
+	}
+
+	comments, truncated, err := cf.FetchCommentsByURL(ctx, permalink)
+	if err != nil {
+		logger.Error("Failed to fetch comments", "url", permalink, "err", err)
+		return exitConfig
+	}
+	if truncated > 0 {
+		logger.Warn("Comment tree truncated by Reddit", "url", permalink, "fetched", len(comments), "not_loaded", truncated)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(commentsOutput{URL: permalink, Count: len(comments), Comments: comments, Truncated: truncated}); err != nil {
+		logger.Error("Failed to write comments", "err", err)
+		return exitConfig
+	}
+	return 0
+}
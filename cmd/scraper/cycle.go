@@ -0,0 +1,789 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/alert"
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
+	"github.com/qepting91/reddit-scraper/internal/ingest"
+	"github.com/qepting91/reddit-scraper/internal/schedule"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// scraper holds everything a scrape cycle needs so cycles can be re-run
+// (e.g. when triggered from the dashboard)
+type scraper struct {
+	logger      *slog.Logger
+	client      domain.Collector
+	targets     []domain.Target
+	keywords    []ingest.Keyword
+	searchLimit int
+	numWorkers  int
+	dataFile    string
+
+	// analysisWorkers filter fetched posts in their own pool (ANALYSIS_WORKERS,
+	// 0 = inline in the fetch workers); pipelineQueue sizes the channels
+	// between stages (PIPELINE_QUEUE_SIZE)
+	analysisWorkers int
+	pipelineQueue   int
+
+	// matcher finds keywords in text; rebuilt from keywords on reload (MATCH_MODE)
+	matcher   filter.Matcher
+	matchMode string
+	// collapseHits drops keyword hits nested in a longer hit (COLLAPSE_OVERLAPS)
+	collapseHits bool
+
+	// sorts are the listing sorts fetched for targets without their own (SORTS)
+	sorts []string
+	// kinds is the post kind allowlist for targets without their own (POST_KINDS, empty = all)
+	kinds []domain.PostKind
+
+	// inputs, when set, lets targets/keywords be reloaded between cycles
+	inputs *inputFiles
+	// exclude lists subreddits dropped from the loaded targets (EXCLUDE_SUBREDDITS)
+	exclude []string
+	// outputFields is the OUTPUT_FIELDS projection (empty = all fields)
+	outputFields []string
+	// outputEncoding is OUTPUT_ENCODING (utf8, ascii or ascii-strip)
+	outputEncoding string
+	// outputBuffer and flushInterval buffer the data file (OUTPUT_BUFFER_SIZE,
+	// OUTPUT_FLUSH_INTERVAL); a zero buffer writes each post straight through
+	outputBuffer  int
+	flushInterval time.Duration
+	// splitDir enables per-subreddit files (SPLIT_BY_SUBREDDIT); combinedOutput
+	// keeps writing dataFile alongside them (COMBINED_OUTPUT)
+	splitDir       string
+	combinedOutput bool
+	// dailyDir also writes each post to a file for the day (DAILY_OUTPUT),
+	// dated in dailyLoc
+	dailyDir string
+	dailyLoc *time.Location
+
+	// seen drops posts already written, in this cycle or ever depending on
+	// dedupScope (DEDUP_SCOPE, nil = off)
+	seen       *filter.SeenSet
+	dedupScope string
+	// cycleIDs holds the post IDs published this cycle, so another target's
+	// copy is merged into the first (MatchedTargets) rather than dropped
+	cycleMu  sync.Mutex
+	cycleIDs map[string]bool
+
+	// dedupTitles enables the near-duplicate title pass (DEDUP_TITLES)
+	dedupTitles    bool
+	titleThreshold float64
+
+	// dropRemoved skips deleted/removed posts instead of storing them flagged (DROP_REMOVED)
+	dropRemoved bool
+
+	// openSearch, when set, replaces the file writer (STORAGE_BACKEND=opensearch);
+	// each cycle indexes through a copy of it
+	openSearch *storage.OpenSearchWriter
+	// storageWriters is how many copies index concurrently (STORAGE_WRITERS)
+	storageWriters int
+
+	// minUpvoteRatio drops posts with a lower reported ratio (MIN_UPVOTE_RATIO, 0 = off)
+	minUpvoteRatio float64
+
+	// nsfwPolicy keeps, drops or isolates over_18 posts (FILTER_NSFW)
+	nsfwPolicy string
+	// modPolicy keeps, drops or isolates moderator/admin (distinguished) posts
+	// (FILTER_DISTINGUISHED)
+	modPolicy string
+
+	// detectLang records each title's language (LANG_DETECT); langs, when
+	// set, keeps only those languages plus titles too short to call (LANG_FILTER)
+	detectLang bool
+	langs      []string
+
+	// alerts counts kept posts toward the mention-rate rules (input/alerts.csv,
+	// nil = none); webhook, when set, is notified when one fires (ALERT_WEBHOOK_URL)
+	alerts  *alert.Tracker
+	webhook *alert.Webhook
+
+	// filterExpr is an extra keep rule every post must pass (FILTER_EXPR, nil = off)
+	filterExpr *filter.Expr
+
+	// globalMinScore drops posts below it before any other filtering
+	// (GLOBAL_MIN_SCORE, math.MinInt = off). Keyword hits don't bypass it.
+	globalMinScore int
+
+	// minKeywordsHit is how many keywords a post under MinScore must hit to be
+	// kept (MIN_KEYWORDS_HIT, 0 = any one)
+	minKeywordsHit int
+	// matchPolicy combines the score and keyword tests (MATCH_POLICY)
+	matchPolicy string
+	// noKeywords decides what is kept while no keywords are configured
+	// (NO_KEYWORDS): filter.NoKeywordsScore or filter.NoKeywordsAll
+	noKeywords string
+
+	// maxTitleLen caps stored titles in runes (MAX_TITLE_LEN, 0 = unlimited)
+	maxTitleLen int
+
+	// incremental polls /new with a per-subreddit "before" cursor (INCREMENTAL)
+	incremental bool
+	cursorMu    sync.Mutex
+	cursors     map[string]string
+
+	// comments enables the comment enrichment stage (COMMENT_ENRICH, nil = off)
+	// with commentWorkers fetchers reading up to commentLimit comments per post
+	comments       domain.CommentFetcher
+	commentWorkers int
+	commentLimit   int
+
+	// shuffle, when set, randomizes target order each cycle (SHUFFLE_TARGETS)
+	// so the same subreddits aren't always scraped last
+	shuffle *rand.Rand
+
+	// enqueueJitter is the max random delay between handing targets to the
+	// workers (ENQUEUE_JITTER, 0 = all at once)
+	enqueueJitter time.Duration
+
+	// workerStartJitter is the max random delay before each worker takes its
+	// first target (WORKER_START_JITTER), so workers don't all hit the
+	// limiter in the same instant at cycle start
+	workerStartJitter time.Duration
+
+	// stats fetches subreddit sizes (SUBREDDIT_STATS, nil = off), cached for
+	// statsTTL and saved to statsFile for the dashboard
+	stats      domain.StatsFetcher
+	statsTTL   time.Duration
+	statsFile  string
+	statsCache map[string]domain.SubredditStats
+
+	// health tracks each target's last attempt and success, saved to
+	// healthFile after every cycle for the dashboard's staleness table
+	healthMu   sync.Mutex
+	health     map[string]domain.TargetHealth
+	healthFile string
+
+	// retention prunes stored posts older than this each cycle (RETENTION_AGE,
+	// 0 = keep forever); keepUndated decides posts without created_utc
+	retention   time.Duration
+	keepUndated bool
+
+	// maxConsecFailures aborts a cycle after this many targets fail in a row
+	// (MAX_CONSECUTIVE_FAILURES, 0 = never)
+	maxConsecFailures int
+
+	// retryMax caps how many failed targets are retried once after the main
+	// pass (CYCLE_RETRY_MAX, 0 = off), retryWait after it finishes
+	retryMax  int
+	retryWait time.Duration
+
+	// intervals schedules targets on their own polling intervals in monitor
+	// mode (SCRAPE_INTERVAL, nil = only initial and on-demand cycles)
+	intervals *schedule.Intervals
+
+	// spacing delays a target's request until SUBREDDIT_MIN_INTERVAL has
+	// passed since its last one, whichever cycle that was in (nil = off)
+	spacing *schedule.Spacing
+
+	// interleave fetches targets' listings in rounds, one sort of every
+	// target per round (INTERLEAVE_FETCHES); requestBudget caps the listing
+	// requests per cycle and implies it (CYCLE_REQUEST_BUDGET, 0 = unlimited)
+	interleave    bool
+	requestBudget int
+
+	// combineSubs puts up to this many /new-only subreddit targets in one
+	// /r/a+b+c/new request when the collector supports it
+	// (COMBINE_SUBREDDITS, <2 = off)
+	combineSubs int
+
+	// rotation limits each cycle to a window of the targets, moving on to
+	// the next window every cycle (MAX_TARGETS_PER_CYCLE, nil = off)
+	rotation *targetRotation
+
+	// queue saves full cycles' progress so a restart resumes them
+	// (RESUME_CYCLES, nil = off)
+	queue *cycleQueue
+
+	// progress counts the running cycle's finished targets and new matches,
+	// logged every progressInterval (PROGRESS_INTERVAL, 0 = off)
+	progress         *cycleProgress
+	progressInterval time.Duration
+
+	// active tracks in-flight targets and the writer for shutdown logging
+	active *activity
+
+	// panics counts worker panics recovered over the process lifetime
+	panics atomic.Int64
+}
+
+// errTooManyFailures means a cycle was aborted by MAX_CONSECUTIVE_FAILURES
+var errTooManyFailures = errors.New("cycle aborted after too many consecutive target failures")
+
+// errQuietHours is reported for a cycle skipped because of QUIET_HOURS
+var errQuietHours = errors.New("scraping paused (quiet hours)")
+
+// runCycle scrapes every target once and returns how many posts were written.
+// The error is errTooManyFailures when the failure threshold cut it short.
+func (s *scraper) runCycle(ctx context.Context) (int, error) {
+	return s.cycle(ctx, false)
+}
+
+// runDue is a monitor-mode cycle over just the targets whose interval has
+// elapsed; it returns straight away when none are due
+func (s *scraper) runDue(ctx context.Context) (int, error) {
+	return s.cycle(ctx, true)
+}
+
+func (s *scraper) cycle(ctx context.Context, dueOnly bool) (int, error) {
+	s.reloadInputs()
+	targets := s.targets
+	if s.intervals != nil {
+		if dueOnly {
+			targets = s.intervals.Due(targets, time.Now())
+		} else {
+			s.intervals.Mark(targets, time.Now())
+		}
+	}
+	if s.rotation != nil {
+		targets = s.rotation.window(targets)
+	}
+	// Only full cycles are resumable; scheduled ones are subsets anyway
+	resumable := s.queue != nil && !dueOnly
+	if resumable {
+		targets = s.queue.begin(targets, time.Now())
+	}
+	if len(targets) == 0 {
+		if resumable {
+			s.queue.finish()
+		}
+		return 0, nil
+	}
+	s.pruneData()
+	s.refreshStats(ctx)
+	if s.seen != nil && s.dedupScope == filter.DedupCycle {
+		s.seen.Reset()
+	}
+	s.cycleMu.Lock()
+	s.cycleIDs = make(map[string]bool)
+	s.cycleMu.Unlock()
+
+	// Cancelled early if a failure means the rest of the cycle is pointless
+	parent := ctx
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	errs := &errorCounts{live: &s.progress.errors}
+	started := time.Now()
+	s.progress.reset(len(targets), started)
+	defer s.progress.running.Store(false)
+	if s.progressInterval > 0 {
+		stopProgress := make(chan struct{})
+		defer close(stopProgress)
+		go s.reportProgress(stopProgress, started)
+	}
+
+	jobQueue := make(chan fetchJob, len(targets))
+	resultQueue := make(chan domain.Post, s.queueSize())
+	var workerWg sync.WaitGroup
+	var writerWg sync.WaitGroup
+
+	var writer storage.Sink
+	if s.openSearch != nil {
+		writer = &storage.ParallelSink{Writers: s.storageWriters, New: func() storage.Sink {
+			osw := *s.openSearch // fresh counters every cycle
+			return &osw
+		}}
+	} else {
+		fw := &storage.WriterService{FilePath: s.dataFile, Fields: s.outputFields, SplitDir: s.splitDir, Encoding: s.outputEncoding}
+		fw.BufferSize, fw.FlushInterval = s.outputBuffer, s.flushInterval
+		fw.DailyDir, fw.DailyLocation = s.dailyDir, s.dailyLoc
+		if !s.combinedOutput {
+			fw.FilePath = ""
+		}
+		writer = fw
+	}
+	writerDone := s.active.start("writer")
+	writerWg.Add(1)
+	go writer.Start(&writerWg, resultQueue)
+
+	// Comment enrichment, when enabled, sits right in front of the writer so
+	// it only spends requests on posts that survived the other stages
+	toWriter := resultQueue
+	var enrichWg sync.WaitGroup
+	if s.comments != nil {
+		toWriter = make(chan domain.Post, s.queueSize())
+		enrichWg.Add(1)
+		go func() {
+			defer enrichWg.Done()
+			s.enrichComments(ctx, toWriter, resultQueue, errs)
+		}()
+	}
+
+	// Workers publish to matched. With a single target that's the writer's
+	// queue; with several, the cycle's posts are buffered so a post surfaced
+	// by more than one target is written once listing all of them, and title
+	// dedup (which needs the whole cycle anyway) runs on the buffer.
+	matched := toWriter
+	buffered := s.dedupTitles || s.interleave || len(targets) > 1
+	var bufferWg sync.WaitGroup
+	if buffered {
+		matched = make(chan domain.Post, s.queueSize())
+		bufferWg.Add(1)
+		go func() {
+			defer bufferWg.Done()
+			var posts []domain.Post
+			for p := range matched {
+				posts = append(posts, p)
+			}
+			kept := filter.MergeByID(posts)
+			if s.dedupTitles {
+				n := len(kept)
+				kept = filter.DedupTitles(kept, s.titleThreshold)
+				s.logger.Info("Title dedup complete", "in", n, "kept", len(kept))
+			}
+			for _, p := range kept {
+				toWriter <- p
+			}
+		}()
+	}
+
+	// Fetch workers -> analysis (inline or its own pool) -> matched
+	publish, stopAnalysis := s.startAnalysis(matched, errs)
+
+	var rounds *fetchRounds
+	if s.interleave {
+		rounds = newFetchRounds(targets, s.targetSorts, s.requestBudget)
+	}
+
+	// pending counts queued targets not yet finished, so the retry pass can
+	// start once the main pass is done while the workers stay up
+	var pending sync.WaitGroup
+	for i := 0; i < s.numWorkers; i++ {
+		workerWg.Add(1)
+		go func(id int) {
+			defer workerWg.Done()
+			if s.workerStartJitter > 0 {
+				// On cancellation fall through: the loop below drains the queue
+				select {
+				case <-ctx.Done():
+				case <-time.After(rand.N(s.workerStartJitter)):
+				}
+			}
+			for j := range jobQueue {
+				// Once cancelled, drain the queue without scraping
+				finished := true
+				targets := []domain.Target{j.target}
+				if ctx.Err() == nil {
+					switch {
+					case len(j.group) > 0:
+						targets = j.group
+						s.scrapeCombined(ctx, j.group, publish, errs, abort)
+					case j.sort == "":
+						s.scrapeTarget(ctx, j.target, publish, errs, abort)
+					default:
+						finished = s.scrapeRound(ctx, j, rounds, publish, errs, abort)
+					}
+					// A target cut short by cancellation isn't finished
+					if finished && resumable && ctx.Err() == nil {
+						for _, t := range targets {
+							s.queue.done(t)
+						}
+					}
+				} else if len(j.group) > 0 {
+					targets = j.group
+				}
+				if finished {
+					s.progress.done.Add(int64(len(targets)))
+				}
+				pending.Done()
+			}
+		}(i)
+	}
+
+	s.logger.Info("Starting scrape cycle", "targets", len(targets))
+	order := targets
+	if s.shuffle != nil {
+		// Shuffle a copy so reloads and the next cycle start from file order
+		order = slices.Clone(targets)
+		s.shuffle.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	enqueue := func(jobs []fetchJob) {
+		for i, j := range jobs {
+			if i > 0 && s.enqueueJitter > 0 {
+				// Spread targets over the cycle instead of handing them all out at once
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(rand.N(s.enqueueJitter)):
+				}
+			}
+			pending.Add(1)
+			jobQueue <- j
+		}
+	}
+	if rounds == nil {
+		enqueue(s.fetchJobs(order))
+		pending.Wait()
+	} else {
+		// Each round waits for the one before, so nobody gets ahead
+		for n := 0; ctx.Err() == nil; n++ {
+			jobs := rounds.round(order, n)
+			if len(jobs) == 0 {
+				break
+			}
+			enqueue(jobs)
+			pending.Wait()
+		}
+		if rest := rounds.flush(order); len(rest) > 0 && ctx.Err() == nil {
+			s.logger.Warn("Request budget spent, publishing partly fetched targets", "budget", s.requestBudget, "targets", len(rest))
+			for _, r := range rest {
+				publish(r.target, r.posts)
+				if resumable {
+					s.queue.done(r.target)
+				}
+				s.progress.done.Add(1)
+			}
+		}
+	}
+	// With a budget, retries only run while some of it is left
+	if rounds == nil || !rounds.spent() {
+		s.retryFailed(ctx, jobQueue, &pending, errs, rounds)
+	}
+	close(jobQueue)
+
+	workerWg.Wait()
+	stopAnalysis()
+	if buffered {
+		close(matched)
+		bufferWg.Wait()
+	}
+	if s.comments != nil {
+		close(toWriter)
+		enrichWg.Wait()
+	}
+	close(resultQueue)
+	writerWg.Wait()
+	writerDone()
+	s.saveHealth()
+	// A shutdown leaves the state behind for the next start to resume;
+	// a cycle that ran to the end (or was aborted) is over
+	if resumable && parent.Err() == nil {
+		s.queue.finish()
+	}
+	if s.rotation != nil && parent.Err() == nil {
+		s.rotation.advance()
+	}
+
+	if len(errs.counts) > 0 {
+		s.logger.Warn("Scrape cycle had failures", "by_cause", errs.counts)
+	}
+	if errs.tripped {
+		return writer.Consumed(), errTooManyFailures
+	}
+	return writer.Consumed(), nil
+}
+
+// scrapeTarget waits for the target's SUBREDDIT_MIN_INTERVAL slot, then
+// fetches and publishes it
+func (s *scraper) scrapeTarget(ctx context.Context, t domain.Target, publish publishFunc, errs *errorCounts, abort context.CancelFunc) {
+	if s.spacing != nil {
+		if wait := s.spacing.Reserve(t, time.Now()); wait > 0 {
+			s.logger.Debug("Spacing requests to target", "sub", t.Name(), "wait", wait)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}
+	s.fetchTarget(ctx, t, publish, errs, abort)
+}
+
+// fetchTarget fetches one target and publishes its posts for analysis. A panic (e.g. from a buggy
+// collector) is contained here so the worker logs it and moves on to its next
+// job instead of dying with the job lost.
+func (s *scraper) fetchTarget(ctx context.Context, t domain.Target, publish publishFunc, errs *errorCounts, abort context.CancelFunc) {
+	defer s.active.start("target " + t.Name())()
+	defer func() {
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			errs.inc("panic")
+			s.logger.Error("Worker panic recovered", "sub", t.Name(), "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+
+	posts, err := s.fetchWithRetry(ctx, t)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Cancelled by shutdown or an abort, not a failure of this target
+			return
+		}
+		s.recordHealth(t, err)
+		if errs.fail(collector.Cause(err), s.maxConsecFailures) {
+			s.logger.Error("Too many consecutive target failures, aborting cycle", "failures", s.maxConsecFailures, "last_err", err)
+			abort()
+		}
+		switch {
+		case errors.Is(err, collector.ErrUnauthorized):
+			s.logger.Error("Authentication failed, aborting cycle", "sub", t.Name(), "err", err)
+			abort()
+		case errors.Is(err, collector.ErrNotFound), errors.Is(err, collector.ErrForbidden):
+			s.logger.Warn("Target unavailable, skipping", "sub", t.Name(), "err", err)
+		default:
+			s.logger.Error("Scrape failed", "sub", t.Name(), "err", err)
+			errs.queueRetry(t)
+		}
+		return
+	}
+	errs.succeed()
+	s.recordHealth(t, nil)
+	s.logger.Debug("Fetched target", "sub", t.Name(), "posts", len(posts))
+	publish(t, posts)
+}
+
+// processPosts returns the target's posts that pass every gate, in order:
+// GLOBAL_MIN_SCORE, MIN_UPVOTE_RATIO, FILTER_NSFW, FILTER_DISTINGUISHED,
+// LANG_FILTER, the kind allowlist and DROP_REMOVED, then the score/keyword
+// test (MATCH_POLICY, or NO_KEYWORDS when no keywords are configured) and
+// FILTER_EXPR. Keyword hits can rescue a post below MinScore but never one
+// below the global floor.
+func (s *scraper) processPosts(t domain.Target, posts []domain.Post) []domain.Post {
+	kinds := t.Kinds
+	if len(kinds) == 0 {
+		kinds = s.kinds
+	}
+	minHits := max(s.minKeywordsHit, 1)
+	policy, keepAll := s.matchPolicy, false
+	if len(s.keywords) == 0 {
+		policy, keepAll = filter.MatchScoreOnly, s.noKeywords == filter.NoKeywordsAll
+	}
+	scrapedAt := time.Now().UTC().Truncate(time.Second)
+
+	var kept []domain.Post
+	for _, p := range posts {
+		if p.Score < s.globalMinScore {
+			continue
+		}
+		// Posts without a reported ratio are kept rather than guessed at
+		if p.UpvoteRatio > 0 && p.UpvoteRatio < s.minUpvoteRatio {
+			continue
+		}
+		if !filter.KeepNSFW(s.nsfwPolicy, p.NSFW) || !filter.KeepDistinguished(s.modPolicy, p.Distinguished) {
+			continue
+		}
+		if s.detectLang {
+			p.Lang = filter.DetectLang(p.Title)
+			if len(s.langs) > 0 && p.Lang != filter.LangUnknown && !slices.Contains(s.langs, p.Lang) {
+				continue
+			}
+		}
+		// Kind is checked before matching; it never depends on keywords
+		if !kindAllowed(p.Kind, kinds) || (p.Removed && s.dropRemoved) {
+			continue
+		}
+		p.KeywordsHit = append(p.KeywordsHit, s.matcher.Match(p.Title)...)
+		if (keepAll || filter.KeepMatch(policy, p.Score >= t.MinScore, len(p.KeywordsHit) >= minHits)) && (s.filterExpr == nil || s.filterExpr.Keep(p)) {
+			s.logger.Debug("Post matched", "sub", t.Name(), "id", p.ID, "score", p.Score, "keywords", p.KeywordsHit)
+			// Truncate only after matching so keywords in the tail still count
+			p.Title = filter.TruncateRunes(p.Title, s.maxTitleLen)
+			p.MatchedTargets = []string{t.Name()}
+			if p.ScrapedAt.IsZero() {
+				p.ScrapedAt = scrapedAt
+			}
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// kindAllowed reports whether a post of kind k passes the allowlist. An empty
+// allowlist allows everything, as do posts the collector couldn't classify.
+func kindAllowed(k domain.PostKind, allow []domain.PostKind) bool {
+	if len(allow) == 0 || k == "" {
+		return true
+	}
+	return slices.Contains(allow, k)
+}
+
+// retryFailed requeues up to retryMax targets that failed transiently in the
+// main pass, once, after retryWait. The limiter paces them like any other
+// request. Targets failing again are logged and counted as "retry_failed".
+func (s *scraper) retryFailed(ctx context.Context, jobQueue chan<- fetchJob, pending *sync.WaitGroup, errs *errorCounts, rounds *fetchRounds) {
+	failed := errs.takeRetries()
+	if s.retryMax <= 0 || len(failed) == 0 || ctx.Err() != nil {
+		return
+	}
+	if rounds != nil {
+		failed = rounds.whole(failed)
+	}
+	if len(failed) > s.retryMax {
+		s.logger.Warn("Too many failed targets to retry all", "failed", len(failed), "retrying", s.retryMax)
+		failed = failed[:s.retryMax]
+	}
+	s.logger.Info("Retrying failed targets", "targets", len(failed), "delay", s.retryWait)
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(s.retryWait):
+	}
+	for _, t := range failed {
+		pending.Add(1)
+		s.progress.total.Add(1)
+		jobQueue <- fetchJob{target: t}
+	}
+	pending.Wait()
+
+	for _, t := range errs.takeRetries() {
+		errs.inc("retry_failed")
+		s.logger.Error("Target failed end-of-cycle retry", "sub", t.Name())
+	}
+}
+
+// retryDelay is how long a worker backs off before retrying a transient failure
+const retryDelay = 5 * time.Second
+
+// fetchWithRetry retries once on rate limiting or network errors; everything
+// else is returned to the worker to skip or abort on
+func (s *scraper) fetchWithRetry(ctx context.Context, t domain.Target) ([]domain.Post, error) {
+	posts, err := s.fetch(ctx, t)
+	if !errors.Is(err, collector.ErrRateLimited) && !errors.Is(err, collector.ErrNetwork) {
+		return posts, err
+	}
+
+	s.logger.Warn("Transient scrape failure, retrying", "sub", t.Name(), "err", err)
+	select {
+	case <-ctx.Done():
+		return nil, err
+	case <-time.After(retryDelay):
+	}
+	return s.fetch(ctx, t)
+}
+
+// fetch pulls every configured sort for a target and merges them by post ID.
+// Each listing is a separate request, so the collector's rate limiter paces
+// the extra sorts just like extra targets.
+func (s *scraper) fetch(ctx context.Context, t domain.Target) ([]domain.Post, error) {
+	sorts := s.targetSorts(t)
+	var listings [][]domain.Post
+	for _, sort := range sorts {
+		fetchSort := s.fetchSort
+		if s.incremental && sort == "new" && t.Kind != domain.KindMulti {
+			fetchSort = s.fetchIncremental
+		}
+		posts, err := fetchSort(ctx, t, sort)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, posts)
+	}
+	if len(listings) == 1 {
+		return listings[0], nil
+	}
+	return filter.MergeByID(listings...), nil
+}
+
+// targetSorts is the listings fetched for t: its own sorts, else SORTS, else /new
+func (s *scraper) targetSorts(t domain.Target) []string {
+	if len(t.Sorts) > 0 {
+		return t.Sorts
+	}
+	if len(s.sorts) > 0 {
+		return s.sorts
+	}
+	return []string{"new"}
+}
+
+// fetchSort dispatches a target to the collector call matching its kind
+func (s *scraper) fetchSort(ctx context.Context, t domain.Target, sort string) ([]domain.Post, error) {
+	switch t.Kind {
+	case domain.KindMulti:
+		return s.client.FetchMultiPosts(ctx, t.Owner, t.Multi, sort, s.searchLimit)
+	default:
+		return s.client.FetchPosts(ctx, t.Subreddit, sort, s.searchLimit)
+	}
+}
+
+// fetchIncremental polls /new for only the posts newer than the target's last
+// seen fullname, advancing the cursor on success. Note Reddit returns an empty
+// page if the cursor post is deleted; the cursor then stays put until restart.
+func (s *scraper) fetchIncremental(ctx context.Context, t domain.Target, _ string) ([]domain.Post, error) {
+	s.cursorMu.Lock()
+	before := s.cursors[t.Subreddit]
+	s.cursorMu.Unlock()
+
+	posts, cursor, err := s.client.FetchNewSince(ctx, t.Subreddit, before, s.searchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cursorMu.Lock()
+	if s.cursors == nil {
+		s.cursors = make(map[string]string)
+	}
+	s.cursors[t.Subreddit] = cursor
+	s.cursorMu.Unlock()
+	s.logger.Debug("Incremental poll", "sub", t.Name(), "before", before, "new_posts", len(posts), "cursor", cursor)
+	return posts, nil
+}
+
+// errorCounts tallies fetch failures by collector.Cause for the cycle summary
+type errorCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+	// consecutive counts target failures since the last success
+	consecutive int
+	tripped     bool
+	// retry holds transiently failed targets for the end-of-cycle retry
+	retry []domain.Target
+	// live, when set, mirrors the failure total for the dashboard
+	live *atomic.Int64
+}
+
+func (c *errorCounts) queueRetry(t domain.Target) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry = append(c.retry, t)
+}
+
+// takeRetries returns the queued targets and empties the queue
+func (c *errorCounts) takeRetries() []domain.Target {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.retry
+	c.retry = nil
+	return t
+}
+
+// fail records a failed target and reports whether max consecutive failures
+// (0 = no limit) has just been reached
+func (c *errorCounts) fail(cause string, max int) bool {
+	c.inc(cause)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consecutive++
+	if max > 0 && c.consecutive >= max && !c.tripped {
+		c.tripped = true
+		return true
+	}
+	return false
+}
+
+func (c *errorCounts) succeed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consecutive = 0
+}
+
+func (c *errorCounts) inc(cause string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[cause]++
+	if c.live != nil {
+		c.live.Add(1)
+	}
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/schedule"
+)
+
+// sortStub serves overlapping /new and /hot listings and records the sorts asked for
+type sortStub struct {
+	collector.MockClient
+	mu    sync.Mutex
+	sorts []string
+}
+
+func (c *sortStub) FetchPosts(_ context.Context, sub, sort string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	c.sorts = append(c.sorts, sort)
+	c.mu.Unlock()
+	if sort == "hot" {
+		return []domain.Post{
+			{ID: "b", Title: "Splunk b", Subreddit: sub, Score: 90},
+			{ID: "c", Title: "Splunk c", Subreddit: sub, Score: 70},
+		}, nil
+	}
+	return []domain.Post{
+		{ID: "a", Title: "Splunk a", Subreddit: sub, Score: 1},
+		{ID: "b", Title: "Splunk b", Subreddit: sub, Score: 2},
+	}, nil
+}
+
+func TestFetchMergesSorts(t *testing.T) {
+	stub := &sortStub{}
+	s := newPipelineScraper(t, stub, nil, "Splunk")
+	s.sorts = []string{"new", "hot"}
+
+	posts, err := s.fetch(context.Background(), domain.Target{Subreddit: "netsec"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(stub.sorts, []string{"new", "hot"}) {
+		t.Errorf("fetched sorts %v, want new hot", stub.sorts)
+	}
+	var got []string
+	for _, p := range posts {
+		got = append(got, p.ID)
+		if p.ID == "b" && p.Score != 90 {
+			t.Errorf("b score = %d, want the max 90", p.Score)
+		}
+	}
+	if !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("merged %v, want a b c", got)
+	}
+
+	// A target's own sorts replace SORTS
+	stub.sorts = nil
+	if _, err := s.fetch(context.Background(), domain.Target{Subreddit: "netsec", Sorts: []string{"hot"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(stub.sorts, []string{"hot"}) {
+		t.Errorf("fetched sorts %v, want the target's hot", stub.sorts)
+	}
+}
+
+// panicStub panics for one subreddit and lists a post everywhere else
+type panicStub struct {
+	collector.MockClient
+	bad string
+}
+
+func (c *panicStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	if sub == c.bad {
+		panic("collector bug")
+	}
+	return []domain.Post{{ID: "p_" + sub, Title: "Splunk in " + sub, Subreddit: sub}}, nil
+}
+
+func TestWorkerPanicDoesNotStopTheCycle(t *testing.T) {
+	targets := []domain.Target{{Subreddit: "netsec"}, {Subreddit: "broken"}, {Subreddit: "malware"}, {Subreddit: "blueteamsec"}}
+	s := newPipelineScraper(t, &panicStub{bad: "broken"}, targets, "Splunk")
+	s.numWorkers = 1 // the same worker must survive to take the later jobs
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range readPosts(t, s.dataFile) {
+		got = append(got, p.Subreddit)
+	}
+	slices.Sort(got)
+	if want := []string{"blueteamsec", "malware", "netsec"}; !slices.Equal(got, want) {
+		t.Errorf("wrote posts from %v, want %v", got, want)
+	}
+	if n := s.panics.Load(); n != 1 {
+		t.Errorf("panics = %d, want 1", n)
+	}
+}
+
+// cursorStub lists one new post per poll, numbered per subreddit, and
+// records the cursor each poll was given
+type cursorStub struct {
+	collector.MockClient
+	mu      sync.Mutex
+	polls   map[string]int
+	befores []string
+}
+
+func (c *cursorStub) FetchNewSince(_ context.Context, sub, before string, _ int) ([]domain.Post, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.befores = append(c.befores, sub+"@"+before)
+	c.polls[sub]++
+	p := domain.Post{ID: fmt.Sprintf("%s%d", sub, c.polls[sub]), Title: "Splunk", Subreddit: sub}
+	return []domain.Post{p}, p.Fullname(), nil
+}
+
+func TestIncrementalPollingAdvancesCursors(t *testing.T) {
+	stub := &cursorStub{polls: map[string]int{}}
+	s := newPipelineScraper(t, stub, []domain.Target{{Subreddit: "netsec"}, {Subreddit: "malware"}}, "Splunk")
+	s.incremental = true
+	for range 2 {
+		if _, err := s.runCycle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	slices.Sort(stub.befores)
+	want := []string{"malware@", "malware@t3_malware1", "netsec@", "netsec@t3_netsec1"}
+	if !slices.Equal(stub.befores, want) {
+		t.Errorf("polls %v, want %v", stub.befores, want)
+	}
+	if got := s.cursors["netsec"]; got != "t3_netsec2" {
+		t.Errorf("netsec cursor = %q, want t3_netsec2", got)
+	}
+}
+
+// timedStub records when each subreddit was fetched
+type timedStub struct {
+	collector.MockClient
+	mu      sync.Mutex
+	fetched []time.Time
+	first   chan struct{}
+}
+
+func (c *timedStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetched = append(c.fetched, time.Now())
+	if len(c.fetched) == 1 && c.first != nil {
+		close(c.first)
+	}
+	return nil, nil
+}
+
+func manyTargets(n int) []domain.Target {
+	targets := make([]domain.Target, n)
+	for i := range targets {
+		targets[i] = domain.Target{Subreddit: fmt.Sprintf("sub%d", i)}
+	}
+	return targets
+}
+
+func TestEnqueueJitterPacesTargets(t *testing.T) {
+	const jitter = 10 * time.Millisecond
+	stub := &timedStub{}
+	s := newPipelineScraper(t, stub, manyTargets(20), "Splunk")
+	s.enqueueJitter = jitter
+
+	start := time.Now()
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.fetched) != 20 {
+		t.Fatalf("fetched %d targets, want 20", len(stub.fetched))
+	}
+	// 19 random waits of up to 10ms each: the cycle is spread out but each
+	// gap stays under the jitter
+	spread := stub.fetched[len(stub.fetched)-1].Sub(start)
+	if spread < 2*jitter {
+		t.Errorf("targets were handed out within %v, want them spread by ENQUEUE_JITTER", spread)
+	}
+	slices.SortFunc(stub.fetched, func(a, b time.Time) int { return a.Compare(b) })
+	for i := 1; i < len(stub.fetched); i++ {
+		if gap := stub.fetched[i].Sub(stub.fetched[i-1]); gap > jitter+50*time.Millisecond {
+			t.Errorf("gap of %v between targets %d and %d, want at most about %v", gap, i-1, i, jitter)
+		}
+	}
+}
+
+func TestEnqueueJitterStopsOnCancel(t *testing.T) {
+	stub := &timedStub{first: make(chan struct{})}
+	s := newPipelineScraper(t, stub, manyTargets(3), "Splunk")
+	s.enqueueJitter = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stub.first
+		cancel()
+	}()
+	done := make(chan struct{})
+	go func() {
+		s.runCycle(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling didn't interrupt the enqueue delay")
+	}
+	if len(stub.fetched) != 1 {
+		t.Errorf("fetched %d targets, want only the first before cancelling", len(stub.fetched))
+	}
+}
+
+// orderStub records the subreddits in the order they're fetched
+type orderStub struct {
+	collector.MockClient
+	mu   sync.Mutex
+	subs []string
+}
+
+func (c *orderStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	c.subs = append(c.subs, sub)
+	c.mu.Unlock()
+	return nil, nil
+}
+
+func TestShuffleTargetsEnqueuesEachOnce(t *testing.T) {
+	targets := manyTargets(20)
+	var fileOrder []string
+	for _, tg := range targets {
+		fileOrder = append(fileOrder, tg.Subreddit)
+	}
+	stub := &orderStub{}
+	s := newPipelineScraper(t, stub, targets, "Splunk")
+	s.numWorkers = 1
+	s.shuffle = rand.New(rand.NewPCG(7, 7))
+
+	var orders [][]string
+	for range 2 {
+		stub.subs = nil
+		if _, err := s.runCycle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		orders = append(orders, stub.subs)
+		if got := slices.Sorted(slices.Values(stub.subs)); !slices.Equal(got, slices.Sorted(slices.Values(fileOrder))) {
+			t.Fatalf("cycle fetched %v, want every target exactly once", stub.subs)
+		}
+	}
+	if slices.Equal(orders[0], fileOrder) || slices.Equal(orders[0], orders[1]) {
+		t.Errorf("orders %v and %v, want a fresh shuffle each cycle", orders[0], orders[1])
+	}
+	// The configured list keeps file order for reloads
+	if s.targets[0].Subreddit != "sub0" || s.targets[19].Subreddit != "sub19" {
+		t.Errorf("shuffling reordered s.targets")
+	}
+}
+
+// failStub fails every subreddit except those in ok, counting the fetches
+type failStub struct {
+	collector.MockClient
+	ok      map[string]bool
+	fetches atomic.Int32
+}
+
+func (c *failStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	c.fetches.Add(1)
+	if c.ok[sub] {
+		return nil, nil
+	}
+	return nil, &collector.FetchError{Mode: "public", Target: "r/" + sub, Err: collector.ErrForbidden}
+}
+
+func TestMaxConsecutiveFailuresAbortsCycle(t *testing.T) {
+	stub := &failStub{}
+	s := newPipelineScraper(t, stub, manyTargets(20), "Splunk")
+	s.numWorkers = 1
+	s.maxConsecFailures = 3
+
+	_, err := s.runCycle(context.Background())
+	if !errors.Is(err, errTooManyFailures) {
+		t.Fatalf("err = %v, want errTooManyFailures", err)
+	}
+	if n := stub.fetches.Load(); n < 3 || n > 4 {
+		t.Errorf("fetched %d targets, want the cycle cut short after 3 failures", n)
+	}
+}
+
+func TestMaxConsecutiveFailuresResetOnSuccess(t *testing.T) {
+	// Every third target succeeds, so the streak never reaches 3
+	stub := &failStub{ok: map[string]bool{}}
+	for i := 2; i < 20; i += 3 {
+		stub.ok[fmt.Sprintf("sub%d", i)] = true
+	}
+	s := newPipelineScraper(t, stub, manyTargets(20), "Splunk")
+	s.numWorkers = 1
+	s.maxConsecFailures = 3
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatalf("err = %v, want the cycle to finish", err)
+	}
+	if n := stub.fetches.Load(); n != 20 {
+		t.Errorf("fetched %d targets, want all 20", n)
+	}
+}
+
+// flakyStub fails each subreddit in fails that many times before returning
+// one matching post for it
+type flakyStub struct {
+	collector.MockClient
+	mu      sync.Mutex
+	fails   map[string]int
+	fetches atomic.Int32
+}
+
+func (c *flakyStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	c.fetches.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fails[sub] > 0 {
+		c.fails[sub]--
+		return nil, errors.New("unexpected status: 500")
+	}
+	return []domain.Post{{ID: sub, Subreddit: sub, Title: "Splunk news"}}, nil
+}
+
+func TestCycleRetryRecoversFailedTarget(t *testing.T) {
+	stub := &flakyStub{fails: map[string]int{"sub0": 1}}
+	s := newPipelineScraper(t, stub, manyTargets(2), "Splunk")
+	s.retryMax = 5
+	s.retryWait = 10 * time.Millisecond
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range readPosts(t, s.dataFile) {
+		ids = append(ids, p.ID)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"sub0", "sub1"}) {
+		t.Errorf("saved %v, want sub0 recovered by the end-of-cycle retry", ids)
+	}
+	if n := stub.fetches.Load(); n != 3 {
+		t.Errorf("fetched %d times, want 3 (one retry)", n)
+	}
+}
+
+func TestCycleRetryCountsTargetsFailingAgain(t *testing.T) {
+	stub := &flakyStub{fails: map[string]int{"sub0": 10}}
+	s := newPipelineScraper(t, stub, manyTargets(2), "Splunk")
+	s.retryMax = 5
+	s.retryWait = 10 * time.Millisecond
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.fetches.Load(); n != 3 {
+		t.Errorf("fetched %d times, want sub0 retried only once", n)
+	}
+	// Two fetch failures plus the retry_failed count
+	if n := s.progress.errors.Load(); n != 3 {
+		t.Errorf("counted %d failures, want 3", n)
+	}
+}
+
+func TestCycleRetryOff(t *testing.T) {
+	stub := &flakyStub{fails: map[string]int{"sub0": 1}}
+	s := newPipelineScraper(t, stub, manyTargets(2), "Splunk")
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := stub.fetches.Load(); n != 2 {
+		t.Errorf("fetched %d times, want no retry with CYCLE_RETRY_MAX=0", n)
+	}
+}
+
+func TestSpacingHoldsAcrossCycles(t *testing.T) {
+	const gap = 100 * time.Millisecond
+	stub := &timedStub{}
+	s := newPipelineScraper(t, stub, manyTargets(1), "Splunk")
+	s.spacing = schedule.NewSpacing(gap)
+
+	for range 3 {
+		if _, err := s.runCycle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(stub.fetched) != 3 {
+		t.Fatalf("fetched %d times, want 3", len(stub.fetched))
+	}
+	// Fetches start a little after their slot, by however late the timer
+	// fired, so allow some slack
+	for i := 1; i < len(stub.fetched); i++ {
+		if d := stub.fetched[i].Sub(stub.fetched[i-1]); d < gap-10*time.Millisecond {
+			t.Errorf("cycle %d hit the subreddit %v after the last, want at least %v", i+1, d, gap)
+		}
+	}
+}
+
+// listingStub records each listing fetched as "sub/sort" and returns one
+// post per listing
+type listingStub struct {
+	collector.MockClient
+	mu       sync.Mutex
+	listings []string
+}
+
+func (c *listingStub) FetchPosts(_ context.Context, sub, sort string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listings = append(c.listings, sub+"/"+sort)
+	return []domain.Post{{ID: sub + "_" + sort, Title: "Splunk " + sort, Subreddit: sub}}, nil
+}
+
+func TestInterleaveFetchesInRounds(t *testing.T) {
+	stub := &listingStub{}
+	targets := manyTargets(3)
+	targets[1].Sorts = []string{"new"} // one listing only
+	s := newPipelineScraper(t, stub, targets, "Splunk")
+	s.numWorkers = 1
+	s.sorts = []string{"new", "hot", "top"}
+	s.interleave = true
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"sub0/new", "sub1/new", "sub2/new",
+		"sub0/hot", "sub2/hot",
+		"sub0/top", "sub2/top",
+	}
+	if !slices.Equal(stub.listings, want) {
+		t.Errorf("fetched %v, want every target's first listing before any second:\n%v", stub.listings, want)
+	}
+	if n := len(readPosts(t, s.dataFile)); n != len(want) {
+		t.Errorf("wrote %d posts, want one per listing (%d)", n, len(want))
+	}
+}
+
+func TestInterleaveBudgetPublishesPartialTargets(t *testing.T) {
+	stub := &listingStub{}
+	s := newPipelineScraper(t, stub, manyTargets(3), "Splunk")
+	s.numWorkers = 1
+	s.sorts = []string{"new", "hot"}
+	s.interleave = true
+	s.requestBudget = 4
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sub0/new", "sub1/new", "sub2/new", "sub0/hot"}
+	if !slices.Equal(stub.listings, want) {
+		t.Errorf("fetched %v, want %v", stub.listings, want)
+	}
+	// Targets the budget cut short still publish what they fetched
+	var ids []string
+	for _, p := range readPosts(t, s.dataFile) {
+		ids = append(ids, p.ID)
+	}
+	slices.Sort(ids)
+	if wantIDs := []string{"sub0_hot", "sub0_new", "sub1_new", "sub2_new"}; !slices.Equal(ids, wantIDs) {
+		t.Errorf("wrote %v, want %v", ids, wantIDs)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
+)
+
+// crossPostStub surfaces the same post in every subreddit
+type crossPostStub struct {
+	collector.MockClient
+}
+
+func (*crossPostStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	return []domain.Post{{ID: "x", Subreddit: sub, Title: "Splunk outage"}}, nil
+}
+
+func TestDedupScopes(t *testing.T) {
+	tests := []struct {
+		scope string
+		// records after two cycles over two targets that both surface the post
+		want int
+	}{
+		// Every cycle's observation is kept for trends; within a cycle the
+		// targets' copies are merged into one record
+		{filter.DedupNone, 2},
+		{filter.DedupCycle, 2},
+		{filter.DedupGlobal, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			s := newPipelineScraper(t, &crossPostStub{}, manyTargets(2), "Splunk")
+			if tt.scope != filter.DedupNone {
+				s.seen = filter.NewSeenSet(100)
+				s.dedupScope = tt.scope
+			}
+			for range 2 {
+				if _, err := s.runCycle(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			posts := readPosts(t, s.dataFile)
+			if len(posts) != tt.want {
+				t.Fatalf("wrote %d records, want %d", len(posts), tt.want)
+			}
+			if got := len(posts[0].MatchedTargets); got != 2 {
+				t.Errorf("record lists %d matched targets, want both targets merged", got)
+			}
+		})
+	}
+}
+
+func TestSeedSeenCarriesGlobalDedupOverRestarts(t *testing.T) {
+	s := newPipelineScraper(t, &crossPostStub{}, manyTargets(1), "Splunk")
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := filter.NewSeenSet(100)
+	seedSeen(slog.New(slog.NewTextHandler(io.Discard, nil)), seen, s.dataFile)
+	if seen.Add("x") {
+		t.Error("post written before the restart isn't in the seeded set")
+	}
+	if !seen.Add("y") {
+		t.Error("unseen post reported as seen")
+	}
+}
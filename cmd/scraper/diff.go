@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/qepting91/reddit-scraper/internal/filter"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// diffSnapshots compares two NDJSON captures and writes the differences to
+// w, as text or JSON, returning the process exit code
+func diffSnapshots(logger *slog.Logger, w io.Writer, oldPath, newPath string, asJSON bool) int {
+	old, err := storage.LoadPosts(oldPath)
+	if err != nil {
+		logger.Error("Failed to read snapshot", "path", oldPath, "err", err)
+		return exitNoInput
+	}
+	cur, err := storage.LoadPosts(newPath)
+	if err != nil {
+		logger.Error("Failed to read snapshot", "path", newPath, "err", err)
+		return exitNoInput
+	}
+	d := filter.DiffPosts(old, cur)
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			logger.Error("Failed to write diff", "err", err)
+			return exitConfig
+		}
+		return 0
+	}
+
+	fmt.Fprintf(w, "%s -> %s: %d added, %d removed, %d score changes\n", oldPath, newPath, len(d.Added), len(d.Removed), len(d.Changed))
+	for _, p := range d.Added {
+		fmt.Fprintf(w, "+ %s %s %q (score %d)\n", p.ID, p.Subreddit, p.Title, p.Score)
+	}
+	for _, p := range d.Removed {
+		fmt.Fprintf(w, "- %s %s %q (score %d)\n", p.ID, p.Subreddit, p.Title, p.Score)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(w, "~ %s %s %q score %d -> %d (%+d)\n", c.ID, c.Subreddit, c.Title, c.Old, c.New, c.New-c.Old)
+	}
+	if len(d.KeywordDeltas) > 0 {
+		keywords := make([]string, 0, len(d.KeywordDeltas))
+		for k := range d.KeywordDeltas {
+			keywords = append(keywords, k)
+		}
+		sort.Strings(keywords)
+		fmt.Fprintln(w, "Keyword mentions:")
+		for _, k := range keywords {
+			fmt.Fprintf(w, "  %s %+d\n", k, d.KeywordDeltas[k])
+		}
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/filter"
+)
+
+// writeSnapshot writes NDJSON records to name in dir and returns its path
+func writeSnapshot(t *testing.T, dir, name string, records ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(records, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := writeSnapshot(t, dir, "old.ndjson",
+		`{"_schema":1}`,
+		`{"id":"kept","title":"Splunk tips","subreddit":"netsec","score":10,"keywords_hit":["splunk"]}`,
+		`{"id":"gone","title":"MISP feeds","subreddit":"netsec","score":4,"keywords_hit":["misp"]}`,
+		`{"id":"rising","title":"Splunk outage","subreddit":"sysadmin","score":5,"keywords_hit":["splunk"]}`,
+	)
+	newPath := writeSnapshot(t, dir, "new.ndjson",
+		`{"_schema":1}`,
+		`{"id":"kept","title":"Splunk tips","subreddit":"netsec","score":10,"keywords_hit":["splunk"]}`,
+		`{"id":"rising","title":"Splunk outage","subreddit":"sysadmin","score":40,"keywords_hit":["splunk"]}`,
+		// A later cycle's copy of the same post wins
+		`{"id":"rising","title":"Splunk outage","subreddit":"sysadmin","score":90,"keywords_hit":["splunk"]}`,
+		`{"id":"fresh","title":"Splunk and MISP","subreddit":"netsec","score":2,"keywords_hit":["splunk","misp"]}`,
+	)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var out bytes.Buffer
+	if code := diffSnapshots(logger, &out, oldPath, newPath, false); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	for _, want := range []string{
+		"1 added, 1 removed, 1 score changes",
+		`+ fresh netsec "Splunk and MISP" (score 2)`,
+		`- gone netsec "MISP feeds" (score 4)`,
+		`~ rising sysadmin "Splunk outage" score 5 -> 90 (+85)`,
+		"  splunk +1",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("text diff is missing %q:\n%s", want, out.String())
+		}
+	}
+	// misp lost one mention and gained one, so it isn't listed
+	if strings.Contains(out.String(), "  misp") {
+		t.Errorf("unchanged keyword listed:\n%s", out.String())
+	}
+
+	out.Reset()
+	if code := diffSnapshots(logger, &out, oldPath, newPath, true); code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	var d filter.PostDiff
+	if err := json.Unmarshal(out.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Added) != 1 || len(d.Removed) != 1 || len(d.Changed) != 1 || d.Changed[0].New != 90 || d.KeywordDeltas["splunk"] != 1 {
+		t.Errorf("JSON diff = %+v", d)
+	}
+}
+
+func TestDiffSnapshotsMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := writeSnapshot(t, dir, "new.ndjson", `{"id":"a"}`)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if code := diffSnapshots(logger, io.Discard, filepath.Join(dir, "none.ndjson"), existing, false); code != exitNoInput {
+		t.Errorf("exit code = %d, want exitNoInput", code)
+	}
+}
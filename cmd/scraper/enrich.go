@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// enrichComments is the optional stage between the scrape workers and the
+// writer. A small pool fetches each matched post's comments and records the
+// keywords found there. Every post is forwarded to out whether or not its
+// comments could be read, so enrichment can only add to a post, never lose it.
+func (s *scraper) enrichComments(ctx context.Context, in <-chan domain.Post, out chan<- domain.Post, errs *errorCounts) {
+	// After a rate limit the pool stops fetching until this time (unix nanos)
+	// and passes posts straight through, leaving the budget to the listings
+	var pausedUntil atomic.Int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.commentWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range in {
+				if ctx.Err() == nil && time.Now().UnixNano() >= pausedUntil.Load() {
+					if err := s.enrichPost(ctx, &p); err != nil {
+						errs.inc("comments_" + collector.Cause(err))
+						if errors.Is(err, collector.ErrRateLimited) {
+							pausedUntil.Store(time.Now().Add(retryDelay).UnixNano())
+						}
+						s.logger.Warn("Comment enrichment failed, writing post without it", "id", p.ID, "err", err)
+					}
+				}
+				out <- p
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// enrichPost scans a post's comments for keywords into CommentKeywordsHit
+func (s *scraper) enrichPost(ctx context.Context, p *domain.Post) error {
+	comments, err := s.comments.FetchComments(ctx, p.ID, s.commentLimit)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		for _, k := range s.matcher.Match(c.Body) {
+			if !slices.Contains(p.CommentKeywordsHit, k) {
+				p.CommentKeywordsHit = append(p.CommentKeywordsHit, k)
+			}
+		}
+	}
+	s.logger.Debug("Comments scanned", "id", p.ID, "comments", len(comments), "keywords", p.CommentKeywordsHit)
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// commentStub serves a Splunk comment for every post except "broken", which
+// fails, and tracks how many fetches run at once
+type commentStub struct {
+	inflight, peak atomic.Int32
+	calls          atomic.Int32
+}
+
+func (c *commentStub) FetchComments(_ context.Context, postID string, _ int) ([]domain.Comment, error) {
+	c.calls.Add(1)
+	n := c.inflight.Add(1)
+	defer c.inflight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if postID == "broken" {
+		return nil, fmt.Errorf("fetch comments: %w", collector.ErrNotFound)
+	}
+	return []domain.Comment{{Body: "we moved to Splunk"}, {Body: "and MISP"}, {Body: "Splunk again"}}, nil
+}
+
+func (c *commentStub) FetchCommentsByURL(context.Context, string) ([]domain.Comment, int, error) {
+	return nil, 0, nil
+}
+
+// runEnrichment pushes posts through the enrichment stage and collects its output
+func runEnrichment(s *scraper, posts []domain.Post) ([]domain.Post, *errorCounts) {
+	in := make(chan domain.Post)
+	out := make(chan domain.Post, len(posts))
+	errs := &errorCounts{}
+	go func() {
+		for _, p := range posts {
+			in <- p
+		}
+		close(in)
+	}()
+	s.enrichComments(context.Background(), in, out, errs)
+	close(out)
+	var got []domain.Post
+	for p := range out {
+		got = append(got, p)
+	}
+	slices.SortFunc(got, func(a, b domain.Post) int { return cmp.Compare(a.ID, b.ID) })
+	return got, errs
+}
+
+func TestEnrichCommentsPool(t *testing.T) {
+	stub := &commentStub{}
+	s := newPipelineScraper(t, nil, nil, "Splunk", "MISP")
+	s.comments, s.commentWorkers = stub, 2
+
+	var posts []domain.Post
+	for i := range 8 {
+		posts = append(posts, domain.Post{ID: fmt.Sprintf("p%d", i)})
+	}
+	posts = append(posts, domain.Post{ID: "broken"})
+	got, errs := runEnrichment(s, posts)
+
+	// Every post comes out, the failed one without comment hits
+	if len(got) != len(posts) {
+		t.Fatalf("forwarded %d posts, want %d", len(got), len(posts))
+	}
+	for _, p := range got {
+		want := []string{"splunk", "misp"}
+		if p.ID == "broken" {
+			want = nil
+		}
+		if !slices.Equal(p.CommentKeywordsHit, want) {
+			t.Errorf("%s CommentKeywordsHit = %v, want %v", p.ID, p.CommentKeywordsHit, want)
+		}
+	}
+	if peak := stub.peak.Load(); peak > 2 {
+		t.Errorf("%d comment fetches ran at once, want at most the 2 workers", peak)
+	}
+	if errs.counts["comments_not_found"] != 1 {
+		t.Errorf("error counts = %v, want one comments_not_found", errs.counts)
+	}
+}
+
+// rateLimitedComments is rate limited on every call
+type rateLimitedComments struct{ commentStub }
+
+func (c *rateLimitedComments) FetchComments(context.Context, string, int) ([]domain.Comment, error) {
+	c.calls.Add(1)
+	return nil, collector.ErrRateLimited
+}
+
+func TestEnrichCommentsBacksOffWhenRateLimited(t *testing.T) {
+	stub := &rateLimitedComments{}
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+	s.comments, s.commentWorkers = stub, 1
+
+	posts := []domain.Post{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	got, _ := runEnrichment(s, posts)
+	if len(got) != len(posts) {
+		t.Fatalf("forwarded %d posts, want %d", len(got), len(posts))
+	}
+	// The rest pass straight through instead of spending more requests
+	if n := stub.calls.Load(); n != 1 {
+		t.Errorf("%d comment fetches, want 1 before pausing", n)
+	}
+}
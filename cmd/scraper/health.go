@@ -0,0 +1,51 @@
+package main
+
+import (
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// recordHealth notes a fetch attempt for t; err == nil marks it a success.
+// Workers call it concurrently.
+func (s *scraper) recordHealth(t domain.Target, err error) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.loadHealth()
+	h := s.health[t.Name()]
+	h.Target = t.Name()
+	h.LastAttempt = time.Now().UTC()
+	if err != nil {
+		h.LastError = err.Error()
+	} else {
+		h.LastSuccess = h.LastAttempt
+		h.LastError = ""
+	}
+	s.health[t.Name()] = h
+}
+
+// saveHealth persists target health for the dashboard once per cycle
+func (s *scraper) saveHealth() {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	if s.health == nil {
+		return
+	}
+	if err := storage.SaveHealth(s.healthFile, s.health); err != nil {
+		s.logger.Error("Could not save target health", "path", s.healthFile, "err", err)
+	}
+}
+
+// loadHealth reads the previous run's health on first use so LastSuccess
+// survives restarts. Callers hold healthMu.
+func (s *scraper) loadHealth() {
+	if s.health != nil {
+		return
+	}
+	health, err := storage.LoadHealth(s.healthFile)
+	if err != nil {
+		s.logger.Warn("Could not read target health, starting fresh", "path", s.healthFile, "err", err)
+	}
+	s.health = health
+}
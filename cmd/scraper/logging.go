@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// newLogger builds the slog logger from LOG_LEVEL (debug|info|warn|error) and
+// LOG_FORMAT (json|text). Invalid values fall back to info/json and are
+// reported as warnings once the logger exists.
+func newLogger(w io.Writer, level, format string) *slog.Logger {
+	lvl, levelOK := parseLogLevel(level)
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	formatOK := true
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		formatOK = false
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	logger := slog.New(handler)
+	if !levelOK {
+		logger.Warn("Invalid LOG_LEVEL (use debug, info, warn or error), defaulting to info", "val", level)
+	}
+	if !formatOK {
+		logger.Warn("Invalid LOG_FORMAT (use json or text), defaulting to json", "val", format)
+	}
+	return logger
+}
+
+// parseLogLevel maps a LOG_LEVEL value onto a slog.Level. Empty means info.
+func parseLogLevel(s string) (slog.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "info":
+		return slog.LevelInfo, true
+	case "debug":
+		return slog.LevelDebug, true
+	case "warn", "warning":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	default:
+		return slog.LevelInfo, false
+	}
+}
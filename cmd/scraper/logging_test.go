@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+		ok   bool
+	}{
+		{"", slog.LevelInfo, true},
+		{"info", slog.LevelInfo, true},
+		{"DEBUG", slog.LevelDebug, true},
+		{" warn ", slog.LevelWarn, true},
+		{"warning", slog.LevelWarn, true},
+		{"error", slog.LevelError, true},
+		{"verbose", slog.LevelInfo, false},
+		{"3", slog.LevelInfo, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLogLevel(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseLogLevel(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNewLoggerFallsBackWithWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&buf, "loud", "yaml")
+	if logger.Enabled(context.Background(), slog.LevelDebug) || !logger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("invalid LOG_LEVEL should fall back to info")
+	}
+	out := buf.String()
+	for _, want := range []string{`"msg":"Invalid LOG_LEVEL`, `"msg":"Invalid LOG_FORMAT`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %s, want a JSON warning containing %s", out, want)
+		}
+	}
+
+	buf.Reset()
+	newLogger(&buf, "debug", "text").Debug("per-request detail")
+	if !strings.HasPrefix(buf.String(), "time=") || !strings.Contains(buf.String(), "per-request detail") {
+		t.Errorf("debug text logger wrote %q", buf.String())
+	}
+}
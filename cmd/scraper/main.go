@@ -2,28 +2,77 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log/slog"
+	"math"
+	"math/rand/v2"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv" // Added for converting env string to int
 	"strings"
-	"sync"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/qepting91/reddit-scraper/internal/alert"
 	"github.com/qepting91/reddit-scraper/internal/collector"
 	"github.com/qepting91/reddit-scraper/internal/dashboard"
 	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
 	"github.com/qepting91/reddit-scraper/internal/ingest"
+	"github.com/qepting91/reddit-scraper/internal/schedule"
 	"github.com/qepting91/reddit-scraper/internal/storage"
 )
 
+// Process exit codes
+const (
+	exitConfig   = 1 // invalid configuration or collector setup
+	exitNoInput  = 2 // targets file missing, unreadable, or empty
+	exitInvalid  = 3 // -validate found targets that don't exist or can't be read
+	exitFailures = 4 // RUN_ONCE cycle aborted by MAX_CONSECUTIVE_FAILURES
+	exitShutdown = 5 // shutdown overran SHUTDOWN_TIMEOUT and was forced
+)
+
 func main() {
+	validate := flag.Bool("validate", false, "check every target exists and is readable, then exit")
+	commentsURL := flag.String("comments", "", "print the comments of this thread `url` as JSON, then exit")
+	targetsPath := flag.String("targets", "input/subreddits.csv", "targets CSV `file`; \"-\" reads stdin")
+	targetsHeader := flag.Bool("targets-header", true, "the targets CSV starts with a header row")
+	fixTargets := flag.Bool("fix-targets", false, "auto-correct malformed subreddit names (e.g. \"r/netsec\") instead of skipping them")
+	reprocessPath := flag.String("reprocess", "", "re-match the current keywords against the titles stored in `file` (bodies aren't stored; records without a title are left alone), rewrite it, then exit")
+	diff := flag.Bool("diff", false, "compare two snapshot files given as arguments (old new), print what changed, then exit")
+	diffJSON := flag.Bool("json", false, "print -diff output as JSON")
+	reportPath := flag.String("render-report", "", "write the dashboard over the stored posts to `file` as standalone HTML, then exit")
+	flag.Parse()
+
 	// 1. Setup
 	godotenv.Load()
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	// OUTPUT_PATH=- streams the posts to stdout for piping into other tools
+	dataFile := os.Getenv("OUTPUT_PATH")
+	if dataFile == "" {
+		dataFile = "data/current.json"
+	}
+	toStdout := dataFile == storage.StdoutPath
+	logOut := os.Stdout
+	if *commentsURL != "" || *diff || toStdout {
+		logOut = os.Stderr // stdout carries the output
+	}
+	logger := newLogger(logOut, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
 	slog.SetDefault(logger)
 
+	if *commentsURL != "" {
+		os.Exit(dumpComments(context.Background(), logger, os.Stdout, *commentsURL))
+	}
+	if *diff {
+		if flag.NArg() != 2 {
+			logger.Error("-diff needs two snapshot files: -diff old.ndjson new.ndjson")
+			os.Exit(exitConfig)
+		}
+		os.Exit(diffSnapshots(logger, os.Stdout, flag.Arg(0), flag.Arg(1), *diffJSON))
+	}
+
 	// Load Port
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -40,97 +89,951 @@ func main() {
 		}
 	}
 
+	// Listing sorts to fetch per target each cycle, merged by post ID (e.g. "new,hot")
+	sorts := []string{"new"}
+	if envSorts := os.Getenv("SORTS"); envSorts != "" {
+		sorts = nil
+		for _, sort := range strings.Split(envSorts, ",") {
+			sort = strings.ToLower(strings.TrimSpace(sort))
+			if !domain.ValidSort(sort) {
+				logger.Warn("Ignoring unknown sort in SORTS", "val", sort)
+				continue
+			}
+			sorts = append(sorts, sort)
+		}
+		if len(sorts) == 0 {
+			logger.Warn("No valid SORTS, defaulting to new")
+			sorts = []string{"new"}
+		}
+	}
+
+	// Post kinds to keep for targets without their own list, e.g. "self,link"
+	var kinds []domain.PostKind
+	if envKinds := splitList(os.Getenv("POST_KINDS")); len(envKinds) > 0 {
+		kinds = ingest.ParseKinds(envKinds)
+		if len(kinds) != len(envKinds) {
+			logger.Warn("Ignoring unknown kinds in POST_KINDS (self, link, image, video, gallery)", "val", os.Getenv("POST_KINDS"))
+		}
+		if len(kinds) == 0 {
+			logger.Warn("No valid POST_KINDS, allowing all kinds")
+		}
+	}
+
+	// Optional field projection for stored posts, e.g. "id,subreddit,title,score,keywords_hit"
+	outputFields := splitList(os.Getenv("OUTPUT_FIELDS"))
+	if len(outputFields) > 0 {
+		if err := storage.ValidateFields(outputFields); err != nil {
+			logger.Error("Invalid OUTPUT_FIELDS", "err", err)
+			os.Exit(exitConfig)
+		}
+	}
+
+	// Optional dedup by post ID, within each cycle or across all of them
+	// (bounded to DEDUP_CAPACITY recent IDs). DEDUP_IDS=true predates
+	// DEDUP_SCOPE and means global.
+	dedupScope := strings.ToLower(os.Getenv("DEDUP_SCOPE"))
+	if dedupScope == "" {
+		dedupScope = filter.DedupNone
+		if os.Getenv("DEDUP_IDS") == "true" {
+			dedupScope = filter.DedupGlobal
+		}
+	} else if !filter.ValidDedupScope(dedupScope) {
+		logger.Warn("Invalid DEDUP_SCOPE (none, cycle, global), not deduplicating", "val", dedupScope)
+		dedupScope = filter.DedupNone
+	}
+	var seen *filter.SeenSet
+	if dedupScope != filter.DedupNone {
+		capacity := filter.DefaultSeenCapacity
+		if envCap := os.Getenv("DEDUP_CAPACITY"); envCap != "" {
+			if val, err := strconv.Atoi(envCap); err == nil && val > 0 {
+				capacity = val
+			} else {
+				logger.Warn("Invalid DEDUP_CAPACITY (must be > 0), using default", "val", envCap, "default", capacity)
+			}
+		}
+		seen = filter.NewSeenSet(capacity)
+		if dedupScope == filter.DedupGlobal && !toStdout {
+			seedSeen(logger, seen, dataFile)
+		}
+	}
+
+	// Optional near-duplicate title suppression within a cycle
+	dedupTitles := os.Getenv("DEDUP_TITLES") == "true"
+	titleThreshold := filter.DefaultTitleThreshold
+	if envThreshold := os.Getenv("DEDUP_TITLE_THRESHOLD"); envThreshold != "" {
+		if val, err := strconv.ParseFloat(envThreshold, 64); err == nil && val > 0 && val <= 1 {
+			titleThreshold = val
+		} else {
+			logger.Warn("Invalid DEDUP_TITLE_THRESHOLD (must be 0-1), using default", "val", envThreshold, "default", titleThreshold)
+		}
+	}
+
+	// Keyword matching strategy: substring (default), word, regex or fuzzy
+	matchMode := strings.ToLower(os.Getenv("MATCH_MODE"))
+	if matchMode == "" {
+		matchMode = filter.MatchSubstring
+	} else if !filter.ValidMatchMode(matchMode) {
+		logger.Warn("Invalid MATCH_MODE (substring, word, regex, fuzzy), defaulting to substring", "val", matchMode)
+		matchMode = filter.MatchSubstring
+	}
+	// Keep only the longest of nested hits ("crowdstrike", not also "crowd")
+	collapseHits := os.Getenv("COLLAPSE_OVERLAPS") == "true"
+
+	if *reprocessPath != "" {
+		os.Exit(reprocess(logger, *reprocessPath, "input/keywords.csv", "input/synonyms.csv", matchMode, collapseHits))
+	}
+
+	// Where posts go: the NDJSON file (default) or an OpenSearch index
+	var openSearch *storage.OpenSearchWriter
+	switch backend := strings.ToLower(os.Getenv("STORAGE_BACKEND")); backend {
+	case "", "file":
+	case "opensearch", "elasticsearch":
+		openSearch = &storage.OpenSearchWriter{
+			URL:      os.Getenv("ES_URL"),
+			Index:    os.Getenv("ES_INDEX"),
+			Username: os.Getenv("ES_USERNAME"),
+			Password: os.Getenv("ES_PASSWORD"),
+			APIKey:   os.Getenv("ES_API_KEY"),
+			Fields:   outputFields,
+		}
+		if openSearch.URL == "" {
+			logger.Error("STORAGE_BACKEND=opensearch requires ES_URL")
+			os.Exit(exitConfig)
+		}
+		if env := os.Getenv("ES_BATCH_SIZE"); env != "" {
+			if val, err := strconv.Atoi(env); err == nil && val > 0 {
+				openSearch.BatchSize = val
+			} else {
+				logger.Warn("Invalid ES_BATCH_SIZE (must be > 0), using default", "val", env, "default", storage.DefaultBulkSize)
+			}
+		}
+	default:
+		logger.Warn("Unknown STORAGE_BACKEND (file, opensearch), writing to file", "val", backend)
+	}
+
+	// Controversy gate; posts whose source reports no ratio always pass
+	var minUpvoteRatio float64
+	if env := os.Getenv("MIN_UPVOTE_RATIO"); env != "" {
+		if val, err := strconv.ParseFloat(env, 64); err == nil && val >= 0 && val <= 1 {
+			minUpvoteRatio = val
+		} else {
+			logger.Warn("Invalid MIN_UPVOTE_RATIO (0-1, e.g. 0.6), disabling", "val", env)
+		}
+	}
+
+	// Mention-rate alerts; the rules file is optional but must be valid if present
+	rules, err := alert.LoadRules("input/alerts.csv")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error("Invalid alert rules", "err", err)
+		os.Exit(exitConfig)
+	}
+	alerts := alert.NewTracker(rules)
+	var webhook *alert.Webhook
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		webhook = &alert.Webhook{URL: url}
+	}
+	if alerts != nil {
+		logger.Info("Mention rate alerts enabled", "rules", len(rules), "webhook", webhook != nil)
+	}
+
+	// SFW by default; the public client only asks for NSFW listings when they're kept
+	nsfwPolicy := strings.ToLower(os.Getenv("FILTER_NSFW"))
+	if nsfwPolicy == "" {
+		nsfwPolicy = filter.NSFWExclude
+	} else if !filter.ValidNSFWPolicy(nsfwPolicy) {
+		logger.Warn("Invalid FILTER_NSFW (exclude, include, only), defaulting to exclude", "val", nsfwPolicy)
+		nsfwPolicy = filter.NSFWExclude
+	}
+	// Moderator/admin posts: keep, drop, or keep nothing else
+	distinguishedPolicy := strings.ToLower(os.Getenv("FILTER_DISTINGUISHED"))
+	if distinguishedPolicy == "" {
+		distinguishedPolicy = filter.DistinguishedInclude
+	} else if !filter.ValidDistinguishedPolicy(distinguishedPolicy) {
+		logger.Warn("Invalid FILTER_DISTINGUISHED (include, exclude, only), defaulting to include", "val", distinguishedPolicy)
+		distinguishedPolicy = filter.DistinguishedInclude
+	}
+
+	// Language allowlist; filtering needs detection, so it turns that on too
+	var langs []string
+	for _, l := range splitList(os.Getenv("LANG_FILTER")) {
+		langs = append(langs, strings.ToLower(l))
+	}
+	detectLang := os.Getenv("LANG_DETECT") == "true" || len(langs) > 0
+
+	// Custom keep rule ANDed with the built-in filters; a bad rule is fatal
+	// since silently ignoring it would store posts the user meant to drop
+	var filterExpr *filter.Expr
+	if env := os.Getenv("FILTER_EXPR"); env != "" {
+		var err error
+		if filterExpr, err = filter.CompileExpr(env); err != nil {
+			logger.Error("Invalid FILTER_EXPR", "expr", env, "err", err)
+			os.Exit(exitConfig)
+		}
+		logger.Info("Post filter expression enabled", "expr", env)
+	}
+
+	// Concurrent writers for backends with idempotent writes; the file
+	// backend always has exactly one so lines never interleave
+	storageWriters := 1
+	if env := os.Getenv("STORAGE_WRITERS"); env != "" {
+		if val, err := strconv.Atoi(env); err != nil || val < 1 {
+			logger.Warn("Invalid STORAGE_WRITERS (must be >= 1), defaulting to 1", "val", env)
+		} else if openSearch == nil && val > 1 {
+			logger.Warn("STORAGE_WRITERS only applies to remote storage backends, the file backend uses one writer", "val", env)
+		} else {
+			storageWriters = val
+		}
+	}
+
+	// Score floor applied to every post before the per-target gate
+	globalMinScore := math.MinInt
+	if env := os.Getenv("GLOBAL_MIN_SCORE"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil {
+			globalMinScore = val
+		} else {
+			logger.Warn("Invalid GLOBAL_MIN_SCORE (must be an integer), not applying a floor", "val", env)
+		}
+	}
+
+	// How many keywords a post below its target's min score must mention
+	minKeywordsHit := 0
+	if envHits := os.Getenv("MIN_KEYWORDS_HIT"); envHits != "" {
+		if val, err := strconv.Atoi(envHits); err == nil && val >= 0 {
+			minKeywordsHit = val
+		} else {
+			logger.Warn("Invalid MIN_KEYWORDS_HIT (must be >= 0), defaulting to 0", "val", envHits)
+		}
+	}
+
+	// Whether keyword hits rescue low scores, are required on top, or don't count
+	matchPolicy := strings.ToLower(os.Getenv("MATCH_POLICY"))
+	if matchPolicy == "" {
+		matchPolicy = filter.MatchScoreOrKeyword
+	} else if !filter.ValidMatchPolicy(matchPolicy) {
+		logger.Warn("Invalid MATCH_POLICY (score_or_keyword, keyword_required, score_only), defaulting to score_or_keyword", "val", matchPolicy)
+		matchPolicy = filter.MatchScoreOrKeyword
+	}
+
+	// What to keep when there are no keywords to match
+	noKeywords := strings.ToLower(os.Getenv("NO_KEYWORDS"))
+	switch noKeywords {
+	case filter.NoKeywordsScore, filter.NoKeywordsAll:
+	case "":
+		noKeywords = filter.NoKeywordsScore
+	default:
+		logger.Warn("Invalid NO_KEYWORDS (score, all), defaulting to score", "val", noKeywords)
+		noKeywords = filter.NoKeywordsScore
+	}
+
+	// Optional cap on stored title length (in characters)
+	maxTitleLen := 0
+	if envLen := os.Getenv("MAX_TITLE_LEN"); envLen != "" {
+		if val, err := strconv.Atoi(envLen); err == nil && val > 0 {
+			maxTitleLen = val
+		} else {
+			logger.Warn("Invalid MAX_TITLE_LEN (must be > 0), titles will not be truncated", "val", envLen)
+		}
+	}
+
+	// Output encoding for sinks that can't take raw unicode. Matching always
+	// runs on the original text; this only changes serialization.
+	outputEncoding := strings.ToLower(os.Getenv("OUTPUT_ENCODING"))
+	if !storage.ValidEncoding(outputEncoding) {
+		logger.Warn("Invalid OUTPUT_ENCODING (utf8, ascii, ascii-strip), defaulting to utf8", "val", outputEncoding)
+		outputEncoding = storage.EncodingUTF8
+	}
+
+	// Buffer data file writes; the interval bounds how stale the dashboard can be
+	outputBuffer := storage.DefaultBufferSize
+	if env := os.Getenv("OUTPUT_BUFFER_SIZE"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 {
+			outputBuffer = val
+		} else {
+			logger.Warn("Invalid OUTPUT_BUFFER_SIZE (bytes, 0 = unbuffered), using default", "val", env, "default", outputBuffer)
+		}
+	}
+	flushInterval := storage.DefaultFlushInterval
+	if env := os.Getenv("OUTPUT_FLUSH_INTERVAL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val > 0 {
+			flushInterval = val
+		} else {
+			logger.Warn("Invalid OUTPUT_FLUSH_INTERVAL (e.g. 2s), using default", "val", env, "default", flushInterval)
+		}
+	}
+
+	// Per-subreddit output files, optionally replacing the combined file
+	splitDir := ""
+	if os.Getenv("SPLIT_BY_SUBREDDIT") == "true" {
+		splitDir = "data/by-sub"
+	}
+	// One output file per day, dated in UTC or the local time zone
+	dailyDir := ""
+	var dailyLoc *time.Location
+	switch env := strings.ToLower(os.Getenv("DAILY_OUTPUT")); env {
+	case "", "false":
+	case "utc":
+		dailyDir, dailyLoc = "data/daily", time.UTC
+	case "local":
+		dailyDir, dailyLoc = "data/daily", time.Local
+	default:
+		logger.Warn("Invalid DAILY_OUTPUT (utc, local), not writing daily files", "val", env)
+	}
+	combinedOutput := os.Getenv("COMBINED_OUTPUT") != "false"
+	if !combinedOutput && splitDir == "" && dailyDir == "" {
+		logger.Warn("COMBINED_OUTPUT=false without SPLIT_BY_SUBREDDIT or DAILY_OUTPUT would discard results, keeping the combined file")
+		combinedOutput = true
+	}
+
+	// Optional random delay between enqueueing targets, e.g. "500ms"
+	var enqueueJitter time.Duration
+	if envJitter := os.Getenv("ENQUEUE_JITTER"); envJitter != "" {
+		if val, err := time.ParseDuration(envJitter); err == nil && val >= 0 {
+			enqueueJitter = val
+		} else {
+			logger.Warn("Invalid ENQUEUE_JITTER (e.g. 500ms), enqueueing without delay", "val", envJitter)
+		}
+	}
+
+	var workerStartJitter time.Duration
+	if env := os.Getenv("WORKER_START_JITTER"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			workerStartJitter = val
+		} else {
+			logger.Warn("Invalid WORKER_START_JITTER (e.g. 1s), starting workers without delay", "val", env)
+		}
+	}
+
+	// Optional per-cycle target shuffling; SHUFFLE_SEED makes the order reproducible
+	var shuffle *rand.Rand
+	if os.Getenv("SHUFFLE_TARGETS") == "true" {
+		seed := uint64(time.Now().UnixNano())
+		if envSeed := os.Getenv("SHUFFLE_SEED"); envSeed != "" {
+			if val, err := strconv.ParseUint(envSeed, 10, 64); err == nil {
+				seed = val
+			} else {
+				logger.Warn("Invalid SHUFFLE_SEED (must be an unsigned integer), using a random seed", "val", envSeed)
+			}
+		}
+		shuffle = rand.New(rand.NewPCG(seed, seed))
+	}
+
+	// Optional retention window for stored posts, e.g. "168h"
+	var retention time.Duration
+	if envRetention := os.Getenv("RETENTION_AGE"); envRetention != "" {
+		if val, err := time.ParseDuration(envRetention); err == nil && val > 0 {
+			retention = val
+		} else {
+			logger.Warn("Invalid RETENTION_AGE (e.g. 168h), keeping all posts", "val", envRetention)
+		}
+	}
+	keepUndated := os.Getenv("RETENTION_KEEP_UNDATED") != "false"
+
+	// Chart theme for the dashboard (go-echarts preset name)
+	theme := os.Getenv("DASHBOARD_THEME")
+	if theme != "" && !dashboard.ValidTheme(theme) {
+		logger.Warn("Invalid DASHBOARD_THEME, using the default (westeros)", "val", theme)
+		theme = ""
+	}
+	// Which Reddit front end the dashboard links to
+	linkHost := strings.ToLower(os.Getenv("REDDIT_LINK_HOST"))
+	if linkHost == "" {
+		linkHost = dashboard.LinkHostWWW
+	} else if !dashboard.ValidLinkHost(linkHost) {
+		logger.Warn("Invalid REDDIT_LINK_HOST (www, old, np), defaulting to www", "val", linkHost)
+		linkHost = dashboard.LinkHostWWW
+	}
+	// Offline dashboards need the chart scripts compiled in (go generate)
+	localAssets := os.Getenv("LOCAL_ASSETS") == "true"
+	if localAssets && !dashboard.HasLocalAssets(theme) {
+		// An offline dashboard quietly falling back to the CDN would render no charts
+		logger.Error("LOCAL_ASSETS set but the chart scripts aren't embedded; run go generate ./internal/dashboard and rebuild", "theme", theme)
+		os.Exit(exitConfig)
+	}
+
+	// How long shutdown may wait for in-flight work before the process is killed
+	shutdownTimeout := defaultShutdownTimeout
+	if env := os.Getenv("SHUTDOWN_TIMEOUT"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val > 0 {
+			shutdownTimeout = val
+		} else {
+			logger.Warn("Invalid SHUTDOWN_TIMEOUT (e.g. 30s), using default", "val", env, "default", shutdownTimeout)
+		}
+	}
+
+	// Weekly windows in which no cycles run, e.g. "22:00-06:00; Sat-Sun 00:00-24:00"
+	quietLoc := time.Local
+	if env := os.Getenv("QUIET_HOURS_TZ"); env != "" {
+		if loc, err := time.LoadLocation(env); err == nil {
+			quietLoc = loc
+		} else {
+			logger.Warn("Invalid QUIET_HOURS_TZ (e.g. America/New_York), using local time", "val", env, "err", err)
+		}
+	}
+	quiet, err := schedule.ParseQuietHours(os.Getenv("QUIET_HOURS"), quietLoc)
+	if err != nil {
+		logger.Error("Invalid QUIET_HOURS", "err", err)
+		os.Exit(exitConfig)
+	}
+	quietReason := func() string {
+		if quiet.Active(time.Now()) {
+			return "quiet hours"
+		}
+		return ""
+	}
+
+	// Targets without a successful fetch for this long are highlighted as stale
+	staleAfter := dashboard.DefaultStaleAfter
+	if env := os.Getenv("STALE_AFTER"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			staleAfter = val
+		} else {
+			logger.Warn("Invalid STALE_AFTER (e.g. 6h), using default", "val", env, "default", staleAfter)
+		}
+	}
+
+	// How long the dashboard reuses parsed posts before re-checking the data file
+	var cacheTTL time.Duration
+	if env := os.Getenv("DASHBOARD_CACHE_TTL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil {
+			cacheTTL = val
+		} else {
+			logger.Warn("Invalid DASHBOARD_CACHE_TTL (e.g. 10s, negative disables), using default", "val", env, "default", dashboard.DefaultCacheTTL)
+		}
+	}
+
+	// How long POST /api/scrape waits for the cycle before answering 202
+	scrapeTimeout := dashboard.DefaultScrapeTimeout
+	if envTimeout := os.Getenv("SCRAPE_TRIGGER_TIMEOUT"); envTimeout != "" {
+		if val, err := time.ParseDuration(envTimeout); err == nil && val > 0 {
+			scrapeTimeout = val
+		} else {
+			logger.Warn("Invalid SCRAPE_TRIGGER_TIMEOUT (e.g. 45s), using default", "val", envTimeout, "default", scrapeTimeout)
+		}
+	}
+
+	// Abort a cycle once this many targets fail in a row; RUN_ONCE then exits
+	// non-zero, otherwise the loop backs off for FAILURE_BACKOFF
+	maxConsecFailures := 0
+	if env := os.Getenv("MAX_CONSECUTIVE_FAILURES"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 {
+			maxConsecFailures = val
+		} else {
+			logger.Warn("Invalid MAX_CONSECUTIVE_FAILURES (must be >= 0), not limiting failures", "val", env)
+		}
+	}
+	// Transiently failed targets get one more try at the end of the cycle
+	retryMax := 10
+	if env := os.Getenv("CYCLE_RETRY_MAX"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 {
+			retryMax = val
+		} else {
+			logger.Warn("Invalid CYCLE_RETRY_MAX (must be >= 0), using default", "val", env, "default", retryMax)
+		}
+	}
+	retryWait := 10 * time.Second
+	if env := os.Getenv("CYCLE_RETRY_DELAY"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			retryWait = val
+		} else {
+			logger.Warn("Invalid CYCLE_RETRY_DELAY (e.g. 10s), using default", "val", env, "default", retryWait)
+		}
+	}
+	// Floor on the time between two requests for one target, across cycles
+	var minTargetGap time.Duration
+	if env := os.Getenv("SUBREDDIT_MIN_INTERVAL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			minTargetGap = val
+		} else {
+			logger.Warn("Invalid SUBREDDIT_MIN_INTERVAL (e.g. 60s), not spacing requests", "val", env)
+		}
+	}
+	// Spend a fixed number of listing requests per cycle, fairly: every target
+	// gets its first listing before any gets its second
+	var requestBudget int
+	if env := os.Getenv("CYCLE_REQUEST_BUDGET"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 {
+			requestBudget = val
+		} else {
+			logger.Warn("Invalid CYCLE_REQUEST_BUDGET (must be >= 0), not limiting requests", "val", env)
+		}
+	}
+	interleave := os.Getenv("INTERLEAVE_FETCHES") == "true" || requestBudget > 0
+	// Read several subreddits' /new in one request to save budget
+	var combineSubs int
+	if env := os.Getenv("COMBINE_SUBREDDITS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 && val <= collector.MaxCombinedSubreddits {
+			combineSubs = val
+		} else {
+			logger.Warn("Invalid COMBINE_SUBREDDITS (0-100), not combining requests", "val", env)
+		}
+	}
+	// Scrape only part of a long target list per cycle, a different part each time
+	var maxTargets int
+	if env := os.Getenv("MAX_TARGETS_PER_CYCLE"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 {
+			maxTargets = val
+		} else {
+			logger.Warn("Invalid MAX_TARGETS_PER_CYCLE (must be >= 0), scraping every target", "val", env)
+		}
+	}
+	// Long cycles log how far they've got; short ones finish before the first tick
+	progressInterval := 15 * time.Second
+	if env := os.Getenv("PROGRESS_INTERVAL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			progressInterval = val
+		} else {
+			logger.Warn("Invalid PROGRESS_INTERVAL (e.g. 15s, 0 = off), using default", "val", env, "default", progressInterval)
+		}
+	}
+	// Monitor mode: keep polling each target on its own interval (targets
+	// CSV column) or this default, besides on-demand scrapes
+	var scrapeInterval time.Duration
+	if env := os.Getenv("SCRAPE_INTERVAL"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			scrapeInterval = val
+		} else {
+			logger.Warn("Invalid SCRAPE_INTERVAL (e.g. 30m), only scraping on demand", "val", env)
+		}
+	}
+	failureBackoff := 5 * time.Minute
+	if env := os.Getenv("FAILURE_BACKOFF"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val > 0 {
+			failureBackoff = val
+		} else {
+			logger.Warn("Invalid FAILURE_BACKOFF (e.g. 5m), using default", "val", env, "default", failureBackoff)
+		}
+	}
+
+	// RUN_ONCE exits after the first cycle instead of waiting for on-demand scrapes
+	runOnce := os.Getenv("RUN_ONCE") == "true"
+
+	// SERVE_ONLY runs just the dashboard over existing data
+	serveOnly := os.Getenv("SERVE_ONLY") == "true"
+
+	// Cycle counters, shared with the dashboard's live cards
+	progress := &cycleProgress{}
+	liveRefresh := dashboard.DefaultLiveRefresh
+	if env := os.Getenv("DASHBOARD_LIVE_REFRESH"); env != "" {
+		if val, err := time.ParseDuration(env); err == nil && val >= 0 {
+			liveRefresh = val
+			if val == 0 {
+				liveRefresh = -1 // hides the cards
+			}
+		} else {
+			logger.Warn("Invalid DASHBOARD_LIVE_REFRESH (e.g. 5s, 0 = off), using default", "val", env, "default", liveRefresh)
+		}
+	}
+
 	// 2. Run Dashboard
+	// The scrape loop below receives on trigger only while idle, which lets
+	// the dashboard reject on-demand scrapes that overlap a running cycle.
+	// Triggered cycles run under ctx, so shutdown cancels them as well.
+	ctx, cancel := context.WithCancel(context.Background())
+	trigger := make(chan dashboard.ScrapeRequest)
+	srv := &dashboard.Server{
+		DataFile:      dataFile,
+		StatsFile:     "data/subreddit_stats.json",
+		BasePath:      dashboard.CleanBasePath(os.Getenv("BASE_PATH")),
+		HealthFile:    "data/target_health.json",
+		StaleAfter:    staleAfter,
+		Port:          port,
+		AuthToken:     os.Getenv("DASHBOARD_TOKEN"),
+		Theme:         theme,
+		TitleLen:      maxTitleLen,
+		Trigger:       trigger,
+		BaseContext:   ctx,
+		ScrapeTimeout: scrapeTimeout,
+		Paused:        quietReason,
+		CacheTTL:      cacheTTL,
+		Highlight:     os.Getenv("HIGHLIGHT_KEYWORDS") != "false",
+		LocalAssets:   localAssets,
+
+		LinkHost:        linkHost,
+		SubredditPrefix: os.Getenv("SUBREDDIT_PREFIX") != "false",
+
+		Live:        progress.live,
+		LiveRefresh: liveRefresh,
+
+		DailyDir: dailyDir,
+	}
+	if toStdout {
+		// The stream isn't kept anywhere the dashboard could read it back
+		srv.DataFile = "data/current.json"
+	}
+	if *reportPath != "" {
+		os.Exit(renderReport(logger, srv, *reportPath))
+	}
+	// On by default, except when streaming to stdout leaves it nothing new to show
+	dashboardOn := os.Getenv("DASHBOARD") != "false"
+	if toStdout {
+		dashboardOn = os.Getenv("DASHBOARD") == "true"
+	}
+	if !dashboardOn {
+		logger.Info("Dashboard disabled", "output", dataFile)
+	} else if !*validate {
+		go func() {
+			logger.Info("Starting Dashboard", "port", port, "base_path", srv.BasePath+"/")
+			if err := srv.Start(); err != nil {
+				logger.Error("Dashboard failed", "err", err)
+			}
+		}()
+	}
+
+	// 3. Graceful Shutdown, bounded by SHUTDOWN_TIMEOUT
+	active := &activity{}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		logger.Info("Starting Dashboard", "port", port)
-		if err := dashboard.StartServer("data/current.json", port); err != nil {
-			logger.Error("Dashboard failed", "err", err)
-		}
+		<-sigChan
+		logger.Info("Shutdown signal received", "timeout", shutdownTimeout)
+		forceExitAfter(logger, shutdownTimeout, active)
+		cancel()
 	}()
 
-	// 3. Load Inputs
-	targets, _ := ingest.LoadTargets("input/subreddits.csv")
-	keywords, _ := ingest.LoadKeywords("input/keywords.csv")
+	if serveOnly {
+		logger.Info("SERVE_ONLY is set, serving the dashboard without scraping")
+		<-ctx.Done()
+		return
+	}
 
-	// 4. Initialize Client
+	// 4. Load Inputs
+	inputs := newInputFiles(*targetsPath, "input/keywords.csv", "input/synonyms.csv", ingest.TargetOptions{Header: *targetsHeader, AutoCorrect: *fixTargets})
+	exclude := splitList(os.Getenv("EXCLUDE_SUBREDDITS"))
+	targets, err := loadTargets(logger, inputs, exclude)
+	if errors.Is(err, errNoTargets) {
+		logger.Error("No valid targets to scrape; add subreddits to the targets file or set SERVE_ONLY=true to run just the dashboard", "path", inputs.targetsPath)
+		os.Exit(exitNoInput)
+	} else if err != nil {
+		logger.Error("Failed to load targets", "path", inputs.targetsPath, "err", err)
+		os.Exit(exitNoInput)
+	}
+	keywords, err := ingest.LoadKeywords(inputs.keywordsPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		// The file is there but broken (e.g. a bad regex); don't run half-configured
+		logger.Error("Failed to load keywords", "path", inputs.keywordsPath, "err", err)
+		os.Exit(exitConfig)
+	} else if err != nil {
+		logger.Warn("Failed to load keywords", "path", inputs.keywordsPath, "err", err)
+	}
+	if len(keywords) == 0 {
+		logNoKeywords(logger, noKeywords, inputs.keywordsPath)
+	}
+	synonyms, err := loadSynonyms(inputs.synonymsPath)
+	if err != nil {
+		logger.Error("Failed to load synonyms", "path", inputs.synonymsPath, "err", err)
+		os.Exit(exitConfig)
+	} else if len(synonyms) > 0 {
+		logger.Info("Keyword synonyms loaded", "path", inputs.synonymsPath, "count", len(synonyms))
+	}
+	matcher, err := buildMatcher(matchMode, collapseHits, synonyms, keywords)
+	if err != nil {
+		logger.Error("Invalid keywords for MATCH_MODE", "mode", matchMode, "path", inputs.keywordsPath, "err", err)
+		os.Exit(exitConfig)
+	}
+
+	// 5. Initialize Client
 	client, err := collector.NewCollector()
 	if err != nil {
 		logger.Error("Failed to initialize collector", "error", err)
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 	logger.Info("Collector initialized",
 		"mode", os.Getenv("COLLECTOR_MODE"),
 		"search_limit", searchLimit,
 	)
 
-	// 5. Concurrency Setup
-	jobQueue := make(chan domain.Target, len(targets))
-	resultQueue := make(chan domain.Post, 100)
-	var workerWg sync.WaitGroup
-	var writerWg sync.WaitGroup
+	// Save every listing, comment tree and stats response fetched for replay
+	// with COLLECTOR_MODE=file. Wrapped first so comment and stats fetching
+	// below go through the recorder too.
+	if dir := os.Getenv("RECORD_DIR"); dir != "" {
+		rc, err := collector.NewRecordingCollector(client, dir)
+		if err != nil {
+			logger.Error("Cannot create RECORD_DIR", "dir", dir, "err", err)
+			os.Exit(exitConfig)
+		}
+		client = rc
+		logger.Info("Recording collector responses", "dir", dir)
+	}
 
-	writer := &storage.WriterService{FilePath: "data/current.json"}
-	writerWg.Add(1)
-	go writer.Start(&writerWg, resultQueue)
+	// Optional comment enrichment stage (extra request per matched post)
+	var comments domain.CommentFetcher
+	commentWorkers, commentLimit := 2, 50
+	if os.Getenv("COMMENT_ENRICH") == "true" {
+		if cf, ok := client.(domain.CommentFetcher); ok {
+			comments = cf
+		} else {
+			logger.Warn("COMMENT_ENRICH is set but this collector can't fetch comments", "mode", os.Getenv("COLLECTOR_MODE"))
+		}
+		if env := os.Getenv("COMMENT_WORKERS"); env != "" {
+			if val, err := strconv.Atoi(env); err == nil && val > 0 {
+				commentWorkers = val
+			} else {
+				logger.Warn("Invalid COMMENT_WORKERS (must be > 0), defaulting to 2", "val", env)
+			}
+		}
+		if env := os.Getenv("COMMENT_LIMIT"); env != "" {
+			if val, err := strconv.Atoi(env); err == nil && val > 0 {
+				commentLimit = val
+			} else {
+				logger.Warn("Invalid COMMENT_LIMIT (must be > 0), defaulting to 50", "val", env)
+			}
+		}
+	}
 
-	// Start Workers
-	ctx, cancel := context.WithCancel(context.Background())
+	// Optional subreddit size stats for the dashboard, refetched every SUBREDDIT_STATS_TTL
+	var stats domain.StatsFetcher
+	statsTTL := 24 * time.Hour
+	if os.Getenv("SUBREDDIT_STATS") == "true" {
+		if sf, ok := client.(domain.StatsFetcher); ok {
+			stats = sf
+		} else {
+			logger.Warn("SUBREDDIT_STATS is set but this collector can't fetch subreddit stats", "mode", os.Getenv("COLLECTOR_MODE"))
+		}
+		if env := os.Getenv("SUBREDDIT_STATS_TTL"); env != "" {
+			if val, err := time.ParseDuration(env); err == nil && val > 0 {
+				statsTTL = val
+			} else {
+				logger.Warn("Invalid SUBREDDIT_STATS_TTL (e.g. 24h), using default", "val", env, "default", statsTTL)
+			}
+		}
+	}
 
+	// 6. Concurrency Setup
 	numWorkers := 4
-	if os.Getenv("COLLECTOR_MODE") == "public" {
+	if mode := os.Getenv("COLLECTOR_MODE"); mode == "public" || mode == "archive" {
 		numWorkers = 2
 	}
+	if env := os.Getenv("FETCH_WORKERS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val > 0 {
+			numWorkers = val
+		} else {
+			logger.Warn("Invalid FETCH_WORKERS (must be > 0), using default", "val", env, "default", numWorkers)
+		}
+	}
+	// Analysis runs inline in the fetch workers unless given its own pool
+	analysisWorkers, pipelineQueue := 0, defaultQueueSize
+	if env := os.Getenv("ANALYSIS_WORKERS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val >= 0 {
+			analysisWorkers = val
+		} else {
+			logger.Warn("Invalid ANALYSIS_WORKERS (must be >= 0), analyzing inline", "val", env)
+		}
+	}
+	if env := os.Getenv("PIPELINE_QUEUE_SIZE"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val > 0 {
+			pipelineQueue = val
+		} else {
+			logger.Warn("Invalid PIPELINE_QUEUE_SIZE (must be > 0), using default", "val", env, "default", pipelineQueue)
+		}
+	}
 
-	for i := 0; i < numWorkers; i++ {
-		workerWg.Add(1)
-		go func(id int) {
-			defer workerWg.Done()
-			for t := range jobQueue {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					// USE THE VARIABLE HERE
-					posts, err := client.FetchNewPosts(ctx, t.Subreddit, searchLimit)
-					if err != nil {
-						logger.Error("Scrape failed", "sub", t.Subreddit, "err", err)
-						continue
-					}
-					for _, p := range posts {
-						for _, k := range keywords {
-							if strings.Contains(strings.ToLower(p.Title), k) {
-								p.KeywordsHit = append(p.KeywordsHit, k)
-							}
-						}
-						if p.Score >= t.MinScore || len(p.KeywordsHit) > 0 {
-							resultQueue <- p
-						}
-					}
-				}
-			}
-		}(i)
+	s := &scraper{
+		logger:      logger,
+		client:      client,
+		targets:     targets,
+		keywords:    keywords,
+		matcher:     matcher,
+		matchMode:   matchMode,
+		inputs:      inputs,
+		searchLimit: searchLimit,
+		sorts:       sorts,
+		numWorkers:  numWorkers,
+		dataFile:    dataFile,
+
+		analysisWorkers: analysisWorkers,
+		pipelineQueue:   pipelineQueue,
+
+		exclude:        exclude,
+		kinds:          kinds,
+		comments:       comments,
+		commentWorkers: commentWorkers,
+		commentLimit:   commentLimit,
+		stats:          stats,
+		statsTTL:       statsTTL,
+		statsFile:      "data/subreddit_stats.json",
+		healthFile:     "data/target_health.json",
+		outputFields:   outputFields,
+		outputEncoding: outputEncoding,
+		outputBuffer:   outputBuffer,
+		flushInterval:  flushInterval,
+		splitDir:       splitDir,
+		dailyDir:       dailyDir,
+		dailyLoc:       dailyLoc,
+		combinedOutput: combinedOutput,
+		enqueueJitter:  enqueueJitter,
+		shuffle:        shuffle,
+		retention:      retention,
+		keepUndated:    keepUndated,
+		seen:           seen,
+		dedupScope:     dedupScope,
+		dedupTitles:    dedupTitles,
+		titleThreshold: titleThreshold,
+		minKeywordsHit: minKeywordsHit,
+		matchPolicy:    matchPolicy,
+		noKeywords:     noKeywords,
+		dropRemoved:    os.Getenv("DROP_REMOVED") == "true",
+		maxTitleLen:    maxTitleLen,
+		incremental:    os.Getenv("INCREMENTAL") == "true",
+
+		maxConsecFailures: maxConsecFailures,
+		workerStartJitter: workerStartJitter,
+
+		retryMax:  retryMax,
+		retryWait: retryWait,
+
+		progress:         progress,
+		progressInterval: progressInterval,
+
+		interleave:    interleave,
+		requestBudget: requestBudget,
+		combineSubs:   combineSubs,
+
+		active: active,
+
+		globalMinScore: globalMinScore,
+		openSearch:     openSearch,
+		collapseHits:   collapseHits,
+		storageWriters: storageWriters,
+
+		filterExpr:     filterExpr,
+		minUpvoteRatio: minUpvoteRatio,
+		nsfwPolicy:     nsfwPolicy,
+		modPolicy:      distinguishedPolicy,
+		detectLang:     detectLang,
+		langs:          langs,
+		alerts:         alerts,
+		webhook:        webhook,
 	}
 
-	// 6. Enqueue Jobs
-	logger.Info("Starting scrape cycle", "targets", len(targets))
-	for _, t := range targets {
-		jobQueue <- t
+	if combineSubs > 1 {
+		if _, ok := s.client.(domain.CombinedFetcher); !ok {
+			logger.Warn("COMBINE_SUBREDDITS is ignored: this collector can't combine listings", "mode", os.Getenv("COLLECTOR_MODE"))
+		} else if s.interleave || s.incremental {
+			logger.Warn("COMBINE_SUBREDDITS is ignored with INTERLEAVE_FETCHES, CYCLE_REQUEST_BUDGET or INCREMENTAL")
+		}
+	}
+	if maxTargets > 0 {
+		s.rotation = newTargetRotation(maxTargets, "data/rotation_state.json", logger)
+		if len(s.targets) > maxTargets {
+			logger.Info("Rotating targets across cycles", "per_cycle", maxTargets, "targets", len(s.targets), "cycles_per_pass", (len(s.targets)+maxTargets-1)/maxTargets)
+		}
+	}
+	if os.Getenv("RESUME_CYCLES") == "true" {
+		s.queue = &cycleQueue{path: "data/queue_state.json", logger: logger}
+	}
+	if minTargetGap > 0 {
+		s.spacing = schedule.NewSpacing(minTargetGap)
+		if scrapeInterval > 0 && scrapeInterval < minTargetGap {
+			logger.Warn("SCRAPE_INTERVAL is shorter than SUBREDDIT_MIN_INTERVAL; targets are polled at most once per minimum", "interval", scrapeInterval, "min", minTargetGap)
+		}
+	}
+	if scrapeInterval > 0 && !runOnce {
+		s.intervals = schedule.NewIntervals(scrapeInterval)
+		logger.Info("Monitor mode enabled", "interval", scrapeInterval)
+	} else if slices.ContainsFunc(s.targets, func(t domain.Target) bool { return t.Interval > 0 }) {
+		logger.Warn("Per-target intervals are ignored without SCRAPE_INTERVAL")
 	}
-	close(jobQueue)
 
-	// 7. Graceful Shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		logger.Info("Shutdown signal received")
-		cancel()
-	}()
+	if *validate {
+		if bad := validateTargets(ctx, logger, client, s.targets); bad > 0 {
+			os.Exit(exitInvalid)
+		}
+		return
+	}
 
-	workerWg.Wait()
-	close(resultQueue)
-	writerWg.Wait()
-	logger.Info("Scrape complete. Data saved.")
+	// 7. Run the initial cycle (unless in quiet hours), then keep serving on-demand scrapes
+	if quietReason() != "" {
+		logger.Info("Quiet hours, skipping the initial scrape cycle")
+	} else if added, err := s.runCycle(ctx); err != nil {
+		logger.Error("Scrape cycle failed", "err", err, "posts_added", added)
+		if runOnce {
+			os.Exit(exitFailures)
+		}
+		backOff(ctx, logger, failureBackoff)
+	} else {
+		logger.Info("Scrape complete. Data saved.", "posts_added", added)
+	}
+	if runOnce {
+		return
+	}
 
-	select {}
+	for {
+		// In monitor mode wake up when the next target is due
+		var due <-chan time.Time
+		if s.intervals != nil {
+			due = time.After(time.Until(s.intervals.Next(s.targets, time.Now())))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-due:
+			if quietReason() != "" {
+				// Skip what's due now rather than catching up after the window
+				s.intervals.Due(s.targets, time.Now())
+				continue
+			}
+			added, err := s.runDue(ctx)
+			if err != nil {
+				logger.Error("Scrape cycle failed", "err", err, "posts_added", added)
+				backOff(ctx, logger, failureBackoff)
+				continue
+			}
+			logger.Info("Scheduled scrape complete", "posts_added", added)
+		case req := <-trigger:
+			if quietReason() != "" {
+				// The dashboard already refuses these; this covers a window opening mid-request
+				logger.Info("Quiet hours, skipping on-demand scrape")
+				req.Done <- dashboard.ScrapeResult{Err: errQuietHours}
+				continue
+			}
+			logger.Info("On-demand scrape triggered")
+			added, err := s.runCycle(req.Ctx)
+			if err == nil {
+				err = req.Ctx.Err()
+			}
+			req.Done <- dashboard.ScrapeResult{PostsAdded: added, Err: err}
+			if errors.Is(err, errTooManyFailures) {
+				logger.Error("Scrape cycle failed", "err", err, "posts_added", added)
+				backOff(ctx, logger, failureBackoff)
+				continue
+			}
+			logger.Info("Scrape complete. Data saved.", "posts_added", added)
+		}
+	}
+}
+
+// backOff pauses the scrape loop after a failed cycle. Triggers arriving
+// meanwhile are rejected as busy, which keeps a broken setup from hammering Reddit.
+func backOff(ctx context.Context, logger *slog.Logger, d time.Duration) {
+	logger.Warn("Backing off before accepting new scrapes", "for", d)
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// splitList parses a comma-separated env value, trimming blanks
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// seedSeen loads the IDs already in the data file so global dedup carries
+// over restarts. The newest posts are added last and so kept by the LRU.
+func seedSeen(logger *slog.Logger, seen *filter.SeenSet, path string) {
+	posts, err := storage.LoadPosts(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warn("Could not read stored posts for dedup, starting with what was read", "path", path, "err", err)
+	}
+	for _, p := range posts {
+		seen.Add(p.ID)
+	}
+	if len(posts) > 0 {
+		logger.Info("Seeded dedup from stored posts", "path", path, "ids", seen.Len())
+	}
 }
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// crosspostStub lists the same post in every subreddit, plus one of its own
+type crosspostStub struct {
+	collector.MockClient
+}
+
+func (c *crosspostStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	return []domain.Post{
+		{ID: "shared", Title: "Splunk detections crossposted", Subreddit: "netsec", Score: 50},
+		{ID: "own_" + sub, Title: "Splunk notes", Subreddit: sub, Score: 50},
+	}, nil
+}
+
+func TestProcessPostsSetsMatchedTargets(t *testing.T) {
+	s := newPipelineScraper(t, &crosspostStub{}, nil, "Splunk")
+	target := domain.Target{Kind: domain.KindMulti, Owner: "someone", Multi: "security"}
+	posts, _ := s.client.FetchPosts(context.Background(), "netsec", "new", 25)
+	for _, p := range s.processPosts(target, posts) {
+		if !slices.Equal(p.MatchedTargets, []string{"user/someone/m/security"}) {
+			t.Errorf("post %s MatchedTargets = %v, want the multi", p.ID, p.MatchedTargets)
+		}
+	}
+}
+
+func TestCrosspostRecordsEveryTarget(t *testing.T) {
+	for _, scope := range []string{filter.DedupNone, filter.DedupCycle, filter.DedupGlobal} {
+		t.Run(scope, func(t *testing.T) {
+			targets := []domain.Target{{Subreddit: "netsec"}, {Subreddit: "blueteamsec"}}
+			s := newPipelineScraper(t, &crosspostStub{}, targets, "Splunk")
+			if scope != filter.DedupNone {
+				s.seen, s.dedupScope = filter.NewSeenSet(0), scope
+			}
+			if _, err := s.runCycle(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			posts, err := storage.LoadPosts(s.dataFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var shared []domain.Post
+			for _, p := range posts {
+				if p.ID == "shared" {
+					shared = append(shared, p)
+				}
+			}
+			if len(posts) != 3 || len(shared) != 1 {
+				t.Fatalf("wrote %d posts (%d shared), want 3 with the crosspost once", len(posts), len(shared))
+			}
+			got := slices.Sorted(slices.Values(shared[0].MatchedTargets))
+			if want := []string{"blueteamsec", "netsec"}; !slices.Equal(got, want) {
+				t.Errorf("MatchedTargets = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestProcessPostsMatchesBeforeTruncating(t *testing.T) {
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+	s.maxTitleLen = 10
+	posts := []domain.Post{{ID: "a", Title: "A very long title that mentions Splunk at the end"}}
+
+	kept := s.processPosts(domain.Target{Subreddit: "netsec"}, posts)
+	if len(kept) != 1 || !slices.Equal(kept[0].KeywordsHit, []string{"splunk"}) {
+		t.Fatalf("kept %+v, want the post matched on its full title", kept)
+	}
+	if kept[0].Title != "A very lo…" {
+		t.Errorf("stored title = %q, want it truncated to 10 runes", kept[0].Title)
+	}
+}
+
+func TestProcessPostsFiltersByKind(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "self", Title: "Splunk question", Kind: domain.PostSelf},
+		{ID: "link", Title: "Splunk writeup", Kind: domain.PostLink},
+		{ID: "image", Title: "Splunk dashboard screenshot", Kind: domain.PostImage},
+		{ID: "video", Title: "Splunk talk", Kind: domain.PostVideo},
+		{ID: "unknown", Title: "Splunk from an old replay"},
+	}
+	ids := func(kept []domain.Post) []string {
+		var out []string
+		for _, p := range kept {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+
+	// Everything by default
+	if got := ids(s.processPosts(domain.Target{Subreddit: "netsec"}, posts)); len(got) != len(posts) {
+		t.Errorf("default kept %v, want every kind", got)
+	}
+	// Global allowlist; posts without a kind aren't dropped for it
+	s.kinds = []domain.PostKind{domain.PostSelf, domain.PostLink}
+	if got, want := ids(s.processPosts(domain.Target{Subreddit: "netsec"}, posts)), []string{"self", "link", "unknown"}; !slices.Equal(got, want) {
+		t.Errorf("global allowlist kept %v, want %v", got, want)
+	}
+	// A target's own kinds replace the global list
+	target := domain.Target{Subreddit: "netsec", Kinds: []domain.PostKind{domain.PostImage, domain.PostVideo}}
+	if got, want := ids(s.processPosts(target, posts)), []string{"image", "video", "unknown"}; !slices.Equal(got, want) {
+		t.Errorf("per-target allowlist kept %v, want %v", got, want)
+	}
+}
+
+func TestProcessPostsMinKeywordsHit(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "popular_one_hit", Title: "Splunk licensing again", Score: 500},
+		{ID: "quiet_two_hits", Title: "Splunk vs MISP for a small team", Score: 1},
+		{ID: "quiet_one_hit", Title: "Splunk question", Score: 1},
+		{ID: "popular_no_hits", Title: "Weekly hiring thread", Score: 500},
+	}
+	target := domain.Target{Subreddit: "netsec", MinScore: 100}
+	ids := func(kept []domain.Post) []string {
+		var out []string
+		for _, p := range kept {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		minHits int
+		policy  string
+		want    []string
+	}{
+		// 0 behaves like 1: any hit rescues a post below MinScore
+		{0, "", []string{"popular_one_hit", "quiet_two_hits", "quiet_one_hit", "popular_no_hits"}},
+		// Two hits needed to rescue; MinScore alone still keeps popular posts
+		{2, "", []string{"popular_one_hit", "quiet_two_hits", "popular_no_hits"}},
+		// With keywords required, both gates must pass
+		{1, filter.MatchKeywordRequired, []string{"popular_one_hit"}},
+		{2, filter.MatchKeywordRequired, nil},
+	}
+	for _, tt := range tests {
+		s := newPipelineScraper(t, nil, nil, "Splunk", "MISP")
+		s.minKeywordsHit, s.matchPolicy = tt.minHits, tt.policy
+		if got := ids(s.processPosts(target, posts)); !slices.Equal(got, tt.want) {
+			t.Errorf("MIN_KEYWORDS_HIT=%d policy %q: kept %v, want %v", tt.minHits, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestProcessPostsDropRemoved(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "live", Title: "Splunk tips"},
+		{ID: "removed", Title: "Splunk giveaway", Removed: true},
+	}
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+	if kept := s.processPosts(domain.Target{Subreddit: "netsec"}, posts); len(kept) != 2 || !kept[1].Removed {
+		t.Errorf("default kept %+v, want both with the removed one flagged", kept)
+	}
+	s.dropRemoved = true
+	if kept := s.processPosts(domain.Target{Subreddit: "netsec"}, posts); len(kept) != 1 || kept[0].ID != "live" {
+		t.Errorf("DROP_REMOVED kept %+v, want only the live post", kept)
+	}
+}
+
+func TestProcessPostsSetsScrapedAtOnce(t *testing.T) {
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+	target := domain.Target{Subreddit: "netsec"}
+	before := time.Now().UTC().Truncate(time.Second)
+
+	first := s.processPosts(target, []domain.Post{{ID: "a", Title: "Splunk tips", Score: 1}})
+	if len(first) != 1 {
+		t.Fatalf("kept %d posts, want 1", len(first))
+	}
+	captured := first[0].ScrapedAt
+	if captured.Before(before) || captured.Location() != time.UTC || captured.Nanosecond() != 0 {
+		t.Errorf("ScrapedAt = %v, want the capture time in whole UTC seconds", captured)
+	}
+	data, _ := json.Marshal(first[0])
+	if want := `"scraped_at":"` + captured.Format(time.RFC3339) + `"`; !strings.Contains(string(data), want) {
+		t.Errorf("JSON %s, want %s", data, want)
+	}
+
+	// Seen again later with a higher score: the upsert keeps the first capture
+	time.Sleep(time.Second)
+	again := s.processPosts(target, []domain.Post{{ID: "a", Title: "Splunk tips", Score: 9}})
+	if !again[0].ScrapedAt.After(captured) {
+		t.Fatalf("second capture at %v, want it after %v", again[0].ScrapedAt, captured)
+	}
+	merged := filter.MergeByID(again, first)
+	if merged[0].Score != 9 || !merged[0].ScrapedAt.Equal(captured) {
+		t.Errorf("upserted post = %+v, want score 9 and the first ScrapedAt %v", merged[0], captured)
+	}
+	// A post that already carries a capture time keeps it
+	if kept := s.processPosts(target, first); !kept[0].ScrapedAt.Equal(captured) {
+		t.Errorf("reprocessed ScrapedAt = %v, want %v", kept[0].ScrapedAt, captured)
+	}
+}
+
+func TestProcessPostsGlobalMinScore(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "junk_hit", Title: "Splunk?", Score: 0},
+		{ID: "low_hit", Title: "Splunk alert tuning", Score: 3},
+		{ID: "low_no_hit", Title: "Weekly thread", Score: 3},
+		{ID: "high_no_hit", Title: "Incident writeup", Score: 60},
+	}
+	target := domain.Target{Subreddit: "netsec", MinScore: 50}
+	ids := func(kept []domain.Post) []string {
+		var out []string
+		for _, p := range kept {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		floor int
+		want  []string
+	}{
+		// No floor: keyword hits rescue posts below the target's MinScore
+		{0, []string{"junk_hit", "low_hit", "high_no_hit"}},
+		// The floor goes first and a keyword hit can't get a post past it
+		{1, []string{"low_hit", "high_no_hit"}},
+		// A floor above MinScore is the stricter of the two
+		{100, nil},
+	}
+	for _, tt := range tests {
+		s := newPipelineScraper(t, nil, nil, "Splunk")
+		s.globalMinScore = tt.floor
+		if got := ids(s.processPosts(target, posts)); !slices.Equal(got, tt.want) {
+			t.Errorf("GLOBAL_MIN_SCORE=%d kept %v, want %v", tt.floor, got, tt.want)
+		}
+	}
+}
+
+func TestProcessPostsMinUpvoteRatio(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "liked", Title: "Splunk tips", UpvoteRatio: 0.95},
+		{ID: "borderline", Title: "Splunk pricing", UpvoteRatio: 0.7},
+		{ID: "controversial", Title: "Splunk is dead", UpvoteRatio: 0.4},
+		// Collectors that don't report a ratio leave it at 0
+		{ID: "no_ratio", Title: "Splunk question"},
+	}
+	ids := func(kept []domain.Post) []string {
+		var out []string
+		for _, p := range kept {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		ratio float64
+		want  []string
+	}{
+		{0, []string{"liked", "borderline", "controversial", "no_ratio"}},
+		{0.7, []string{"liked", "borderline", "no_ratio"}},
+		{0.9, []string{"liked", "no_ratio"}},
+	}
+	for _, tt := range tests {
+		s := newPipelineScraper(t, nil, nil, "Splunk")
+		s.minUpvoteRatio = tt.ratio
+		if got := ids(s.processPosts(domain.Target{Subreddit: "netsec"}, posts)); !slices.Equal(got, tt.want) {
+			t.Errorf("MIN_UPVOTE_RATIO=%v kept %v, want %v", tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestProcessPostsFilterNSFW(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "sfw", Title: "Splunk tips"},
+		{ID: "nsfw", Title: "Splunk memes", NSFW: true},
+	}
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{filter.NSFWExclude, []string{"sfw"}},
+		{filter.NSFWInclude, []string{"sfw", "nsfw"}},
+		{filter.NSFWOnly, []string{"nsfw"}},
+	}
+	for _, tt := range tests {
+		s := newPipelineScraper(t, nil, nil, "Splunk")
+		s.nsfwPolicy = tt.policy
+		var got []string
+		for _, p := range s.processPosts(domain.Target{Subreddit: "netsec"}, posts) {
+			got = append(got, p.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FILTER_NSFW=%s kept %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestProcessPostsLangFilter(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "en", Title: "How is everyone handling the Splunk license changes this year?"},
+		{ID: "es", Title: "¿Cuál es la mejor alternativa a Splunk para los registros de la empresa?"},
+		{ID: "short", Title: "Splunk?"},
+	}
+	s := newPipelineScraper(t, nil, nil, "Splunk")
+	s.detectLang = true
+	s.langs = []string{"en"}
+
+	langs := map[string]string{}
+	for _, p := range s.processPosts(domain.Target{Subreddit: "netsec"}, posts) {
+		langs[p.ID] = p.Lang
+	}
+	// Titles too short to call are kept rather than guessed at
+	want := map[string]string{"en": "en", "short": filter.LangUnknown}
+	if !maps.Equal(langs, want) {
+		t.Errorf("LANG_FILTER=en kept %v, want %v", langs, want)
+	}
+}
+
+func TestProcessPostsMatchPolicy(t *testing.T) {
+	// MinScore is 10: one post for each combination of score and keyword
+	posts := []domain.Post{
+		{ID: "neither", Title: "Weekly thread", Score: 1},
+		{ID: "keyword", Title: "Splunk question", Score: 1},
+		{ID: "score", Title: "Big outage today", Score: 50},
+		{ID: "both", Title: "Splunk outage today", Score: 50},
+	}
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{filter.MatchScoreOrKeyword, []string{"keyword", "score", "both"}},
+		{filter.MatchKeywordRequired, []string{"both"}},
+		{filter.MatchScoreOnly, []string{"score", "both"}},
+	}
+	for _, tt := range tests {
+		s := newPipelineScraper(t, nil, nil, "Splunk")
+		s.matchPolicy = tt.policy
+		var got []string
+		for _, p := range s.processPosts(domain.Target{Subreddit: "netsec", MinScore: 10}, posts) {
+			got = append(got, p.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("MATCH_POLICY=%s kept %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestProcessPostsNoKeywords(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "low", Title: "Weekly thread", Score: 1},
+		{ID: "high", Title: "Big outage today", Score: 50},
+	}
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{filter.NoKeywordsScore, []string{"high"}},
+		{filter.NoKeywordsAll, []string{"low", "high"}},
+	}
+	for _, tt := range tests {
+		// With no keywords MATCH_POLICY doesn't apply, even keyword_required
+		for _, policy := range []string{filter.MatchScoreOrKeyword, filter.MatchKeywordRequired} {
+			s := newPipelineScraper(t, nil, nil)
+			s.noKeywords = tt.mode
+			s.matchPolicy = policy
+			var got []string
+			for _, p := range s.processPosts(domain.Target{Subreddit: "netsec", MinScore: 10}, posts) {
+				got = append(got, p.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("NO_KEYWORDS=%s MATCH_POLICY=%s kept %v, want %v", tt.mode, policy, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestLogNoKeywordsNamesTheMode(t *testing.T) {
+	for mode, want := range map[string]string{
+		filter.NoKeywordsScore: "NO_KEYWORDS=score",
+		filter.NoKeywordsAll:   "NO_KEYWORDS=all",
+	} {
+		var logs strings.Builder
+		logNoKeywords(slog.New(slog.NewTextHandler(&logs, nil)), mode, "keywords.csv")
+		if out := logs.String(); !strings.Contains(out, want) || !strings.Contains(out, "keywords.csv") {
+			t.Errorf("startup log for %s = %q, want it to name %s and the file", mode, out, want)
+		}
+	}
+}
+
+func TestProcessPostsFilterDistinguished(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "organic", Title: "Splunk tips"},
+		{ID: "mod", Title: "Splunk AMA announcement", Distinguished: "moderator"},
+	}
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{filter.DistinguishedInclude, []string{"organic", "mod"}},
+		{filter.DistinguishedExclude, []string{"organic"}},
+		{filter.DistinguishedOnly, []string{"mod"}},
+	}
+	for _, tt := range tests {
+		s := newPipelineScraper(t, nil, nil, "Splunk")
+		s.modPolicy = tt.policy
+		var got []string
+		for _, p := range s.processPosts(domain.Target{Subreddit: "netsec"}, posts) {
+			got = append(got, p.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FILTER_DISTINGUISHED=%s kept %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/dashboard"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/ingest"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// newPipelineScraper is a scraper writing to a temp file and matching the
+// given keywords
+func newPipelineScraper(t *testing.T, client domain.Collector, targets []domain.Target, terms ...string) *scraper {
+	t.Helper()
+	var keywords []ingest.Keyword
+	for _, term := range terms {
+		keywords = append(keywords, ingest.Keyword{Term: term})
+	}
+	matcher, err := buildMatcher("", false, nil, keywords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &scraper{
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		client:      client,
+		targets:     targets,
+		keywords:    keywords,
+		matcher:     matcher,
+		searchLimit: 25,
+		numWorkers:  2,
+		dataFile:    filepath.Join(t.TempDir(), "current.ndjson"),
+
+		combinedOutput: true,
+		progress:       &cycleProgress{},
+	}
+}
+
+// readPosts returns the records written to path, skipping the schema header
+func readPosts(t *testing.T, path string) []domain.Post {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var posts []domain.Post
+	err = storage.ReadLines(f, storage.MaxLineBytes, func(line []byte) {
+		if storage.IsHeader(line) {
+			return
+		}
+		var p domain.Post
+		if err := json.Unmarshal(line, &p); err != nil {
+			t.Errorf("bad record %s: %v", line, err)
+			return
+		}
+		posts = append(posts, p)
+	}, func(lineNo int) {
+		t.Errorf("record on line %d too long", lineNo)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return posts
+}
+
+var statValue = regexp.MustCompile(`<div class="stat-value[^"]*">([^<]*)</div>`)
+
+// dashboardKPIs renders the dashboard over path and returns its
+// TotalMentions and TopTool cards
+func dashboardKPIs(t *testing.T, path string) (int, string) {
+	t.Helper()
+	srv := &dashboard.Server{DataFile: path}
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dashboard: status %d", rec.Code)
+	}
+	m := statValue.FindAllStringSubmatch(rec.Body.String(), 2)
+	if len(m) < 2 {
+		t.Fatalf("dashboard: KPI cards not found")
+	}
+	total, err := strconv.Atoi(m[0][1])
+	if err != nil {
+		t.Fatalf("dashboard: total mentions %q: %v", m[0][1], err)
+	}
+	return total, m[1][1]
+}
+
+// topKeywords counts keyword hits the way the dashboard does and returns
+// every keyword tied for the most
+func topKeywords(posts []domain.Post) map[string]bool {
+	counts := map[string]int{}
+	best := 0
+	for _, p := range posts {
+		for _, k := range p.KeywordsHit {
+			counts[k]++
+			best = max(best, counts[k])
+		}
+	}
+	top := map[string]bool{}
+	for k, n := range counts {
+		if n == best {
+			top[k] = true
+		}
+	}
+	return top
+}
+
+func TestPipelineMockToDashboard(t *testing.T) {
+	targets := []domain.Target{{Subreddit: "netsec"}, {Subreddit: "threatintel"}}
+	s := newPipelineScraper(t, collector.NewMockClient(), targets,
+		"Mandiant", "CrowdStrike", "MISP", "Analyst1", "Recorded Future", "ZeroFox", "OpenCTI")
+	s.runCycle(context.Background())
+
+	posts := readPosts(t, s.dataFile)
+	// Every mock post names one of the keywords, so none are filtered
+	if want := len(targets) * s.searchLimit; len(posts) != want {
+		t.Fatalf("wrote %d posts, want %d", len(posts), want)
+	}
+	for _, p := range posts {
+		if len(p.KeywordsHit) != 1 {
+			t.Errorf("post %s hit %v, want one keyword", p.ID, p.KeywordsHit)
+		}
+	}
+
+	total, top := dashboardKPIs(t, s.dataFile)
+	if total != len(posts) {
+		t.Errorf("TotalMentions = %d, want %d", total, len(posts))
+	}
+	if want := topKeywords(posts); !want[top] {
+		t.Errorf("TopTool = %q, want one of %v", top, want)
+	}
+}
+
+const cannedListing = `{"kind":"Listing","data":{"children":[
+{"kind":"t3","data":{"id":"p1","title":"Splunk query tips","subreddit":"netsec","author":"a","score":120,"permalink":"/r/netsec/comments/p1/","url":"https://example.com/1","created_utc":1700000000}},
+{"kind":"t3","data":{"id":"p2","title":"Another Splunk dashboard","subreddit":"netsec","author":"b","score":3,"permalink":"/r/netsec/comments/p2/","url":"https://example.com/2","created_utc":1700000100}},
+{"kind":"t3","data":{"id":"p3","title":"MISP feeds worth adding","subreddit":"netsec","author":"c","score":40,"permalink":"/r/netsec/comments/p3/","url":"https://example.com/3","created_utc":1700000200}},
+{"kind":"t3","data":{"id":"p4","title":"Weekly hiring thread","subreddit":"netsec","author":"d","score":2,"permalink":"/r/netsec/comments/p4/","url":"https://example.com/4","created_utc":1700000300}}
+]}}`
+
+func TestPipelinePublicClientToDashboard(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, cannedListing)
+	}))
+	defer srv.Close()
+
+	client, err := collector.NewPublicClientWithHTTP("test-agent", srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// p4 is below MinScore and names no keyword, so it's the only one dropped
+	s := newPipelineScraper(t, client, []domain.Target{{Subreddit: "netsec", MinScore: 10}}, "Splunk", "MISP")
+	s.runCycle(context.Background())
+
+	if len(paths) != 1 || paths[0] != "/r/netsec/new.json" {
+		t.Errorf("requested %v, want /r/netsec/new.json", paths)
+	}
+	total, top := dashboardKPIs(t, s.dataFile)
+	if total != 3 {
+		t.Errorf("TotalMentions = %d, want 3", total)
+	}
+	// Keywords are matched and reported lowercased
+	if top != "splunk" {
+		t.Errorf("TopTool = %q, want splunk", top)
+	}
+}
+
+func TestBuildMatcherCapturesRegexHits(t *testing.T) {
+	keywords := []ingest.Keyword{
+		{Term: "Splunk"},
+		{Term: `CVE-\d{4}-\d+`, Regex: true},
+	}
+	m, err := buildMatcher("", false, nil, keywords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.Match("Splunk advisory for CVE-2024-3400 and cve-2023-20198, again CVE-2024-3400")
+	if want := []string{"splunk", "cve-2024-3400", "cve-2023-20198"}; !slices.Equal(got, want) {
+		t.Errorf("Match = %q, want %q", got, want)
+	}
+	if got := m.Match("CVE-24-1 is not an ID"); got != nil {
+		t.Errorf("Match = %q, want no hits", got)
+	}
+
+	if _, err := buildMatcher("", false, nil, []ingest.Keyword{{Term: "CVE-(", Regex: true}}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
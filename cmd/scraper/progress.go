@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/dashboard"
+)
+
+// cycleProgress counts how far the running cycle has got. Workers and the
+// analysis stage bump the counters directly; a ticker reads them every
+// PROGRESS_INTERVAL so a long cycle shows it isn't stuck, and the dashboard
+// polls them through live.
+type cycleProgress struct {
+	total   atomic.Int64
+	done    atomic.Int64
+	matched atomic.Int64
+	errors  atomic.Int64
+
+	running atomic.Bool
+	// started is the cycle's start in Unix nanoseconds
+	started atomic.Int64
+}
+
+// reset starts the counters for a cycle over total targets. Retries add to
+// the total as they're queued.
+func (p *cycleProgress) reset(total int, now time.Time) {
+	p.total.Store(int64(total))
+	p.done.Store(0)
+	p.matched.Store(0)
+	p.errors.Store(0)
+	p.started.Store(now.UnixNano())
+	p.running.Store(true)
+}
+
+// live snapshots the counters for the dashboard (/api/live)
+func (p *cycleProgress) live() dashboard.LiveStats {
+	stats := dashboard.LiveStats{
+		Running:      p.running.Load(),
+		TargetsDone:  p.done.Load(),
+		TargetsTotal: p.total.Load(),
+		PostsMatched: p.matched.Load(),
+		Errors:       p.errors.Load(),
+	}
+	if ns := p.started.Load(); ns != 0 {
+		stats.CycleStarted = time.Unix(0, ns).UTC()
+	}
+	if remaining, ok := collector.RateLimitRemaining(); ok {
+		stats.RateLimitRemaining = &remaining
+	}
+	return stats
+}
+
+// reportProgress logs the progress of the cycle that began at started every
+// progressInterval until stop is closed. Cycles shorter than one interval
+// log nothing.
+func (s *scraper) reportProgress(stop <-chan struct{}, started time.Time) {
+	tick := time.NewTicker(s.progressInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-tick.C:
+			s.logger.Info("Cycle progress",
+				"targets_done", s.progress.done.Load(),
+				"targets_total", s.progress.total.Load(),
+				"posts_matched", s.progress.matched.Load(),
+				"elapsed", now.Sub(started).Round(time.Second))
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/dashboard"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// gatedStub returns one matching post per subreddit, each fetch waiting for
+// a value on next
+type gatedStub struct {
+	collector.MockClient
+	next chan struct{}
+}
+
+func (c *gatedStub) FetchPosts(ctx context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	select {
+	case <-c.next:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []domain.Post{{ID: sub, Subreddit: sub, Title: "Splunk news"}}, nil
+}
+
+func TestProgressCountersAdvance(t *testing.T) {
+	stub := &gatedStub{next: make(chan struct{})}
+	s := newPipelineScraper(t, stub, manyTargets(3), "Splunk")
+	s.numWorkers = 1
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.runCycle(context.Background())
+		done <- err
+	}()
+
+	// waitFor polls until cond holds
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: %+v", what, s.progress.live())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor("the cycle to start", s.progress.running.Load)
+	if live := s.progress.live(); live.TargetsTotal != 3 || live.TargetsDone != 0 {
+		t.Errorf("at the start: %+v", live)
+	}
+	for i := range int64(3) {
+		stub.next <- struct{}{}
+		waitFor("the target to finish", func() bool { return s.progress.done.Load() == i+1 })
+		if live := s.progress.live(); live.TargetsTotal != 3 || live.PostsMatched != i+1 {
+			t.Errorf("after target %d: %+v", i+1, live)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if live := s.progress.live(); live.Running || live.TargetsDone != 3 || live.Errors != 0 {
+		t.Errorf("after the cycle: %+v", live)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to write from the progress ticker
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestReportProgressLogsCounters(t *testing.T) {
+	var logs syncBuffer
+	s := &scraper{
+		logger:           slog.New(slog.NewTextHandler(&logs, nil)),
+		progress:         &cycleProgress{},
+		progressInterval: 5 * time.Millisecond,
+	}
+	s.progress.reset(200, time.Now())
+	s.progress.done.Store(37)
+	s.progress.matched.Store(1204)
+
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		s.reportProgress(stop, time.Now())
+		close(finished)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	close(stop)
+	<-finished
+
+	out := logs.String()
+	for _, want := range []string{"Cycle progress", "targets_done=37", "targets_total=200", "posts_matched=1204"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("progress log is missing %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestLiveStatsReflectWorkerActivity(t *testing.T) {
+	stub := &flakyStub{fails: map[string]int{"sub0": 10}}
+	s := newPipelineScraper(t, stub, manyTargets(4), "Splunk")
+	srv := httptest.NewServer((&dashboard.Server{DataFile: s.dataFile, Live: s.progress.live}).Handler())
+	defer srv.Close()
+
+	live := func() dashboard.LiveStats {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/api/live")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var stats dashboard.LiveStats
+		if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := live()
+	if got.Running || got.TargetsTotal != 4 || got.TargetsDone != 4 || got.PostsMatched != 3 || got.Errors != 1 {
+		t.Errorf("after the first cycle /api/live = %+v, want 4 targets done, 3 matched, 1 error", got)
+	}
+	first := got.CycleStarted
+
+	// The next cycle starts its counts afresh, now with sub0 working
+	delete(stub.fails, "sub0")
+	if _, err := s.runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got = live()
+	if got.TargetsDone != 4 || got.PostsMatched != 4 || got.Errors != 0 || !got.CycleStarted.After(first) {
+		t.Errorf("after the second cycle /api/live = %+v, want 4 matched, no errors and a later start", got)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
+	"github.com/qepting91/reddit-scraper/internal/ingest"
+)
+
+// inputFiles remembers where the targets/keywords came from so edits to the
+// CSVs can be picked up between cycles without restarting
+type inputFiles struct {
+	targetsPath  string
+	keywordsPath string
+	targetsMod   time.Time
+	keywordsMod  time.Time
+
+	// targetOpts says how to read the targets CSV (-targets-header, -fix-targets)
+	targetOpts ingest.TargetOptions
+
+	// synonymsPath maps keyword aliases to canonical names (optional)
+	synonymsPath string
+	synonymsMod  time.Time
+}
+
+// newInputFiles records the inputs' mtimes. Targets read from stdin ("-")
+// have none, so they are never reloaded.
+func newInputFiles(targetsPath, keywordsPath, synonymsPath string, targetOpts ingest.TargetOptions) *inputFiles {
+	return &inputFiles{
+		targetsPath:  targetsPath,
+		keywordsPath: keywordsPath,
+		targetsMod:   modTime(targetsPath),
+		keywordsMod:  modTime(keywordsPath),
+		targetOpts:   targetOpts,
+		synonymsPath: synonymsPath,
+		synonymsMod:  modTime(synonymsPath),
+	}
+}
+
+// reloadInputs re-reads any input file whose mtime changed since the last
+// successful load. A bad reload is logged and the previous config is kept.
+func (s *scraper) reloadInputs() {
+	if s.inputs == nil {
+		return
+	}
+
+	if mod := modTime(s.inputs.targetsPath); !mod.Equal(s.inputs.targetsMod) {
+		targets, err := loadTargets(s.logger, s.inputs, s.exclude)
+		if err != nil {
+			s.logger.Error("Target reload rejected, keeping previous targets", "path", s.inputs.targetsPath, "err", err)
+		} else {
+			added, removed := diffNames(targetNames(s.targets), targetNames(targets))
+			s.logger.Info("Targets reloaded", "count", len(targets), "added", added, "removed", removed)
+			s.targets = targets
+		}
+		// Record the mtime either way so a broken file isn't re-parsed every cycle
+		s.inputs.targetsMod = mod
+	}
+
+	// Synonyms feed the matcher too, so an edit to either file rebuilds it
+	mod, synMod := modTime(s.inputs.keywordsPath), modTime(s.inputs.synonymsPath)
+	if !mod.Equal(s.inputs.keywordsMod) || !synMod.Equal(s.inputs.synonymsMod) {
+		keywords, err := ingest.LoadKeywords(s.inputs.keywordsPath)
+		var synonyms map[string]string
+		if err == nil {
+			synonyms, err = loadSynonyms(s.inputs.synonymsPath)
+		}
+		var matcher filter.Matcher
+		if err == nil {
+			matcher, err = buildMatcher(s.matchMode, s.collapseHits, synonyms, keywords)
+		}
+		if err != nil {
+			s.logger.Error("Keyword reload rejected, keeping previous keywords", "path", s.inputs.keywordsPath, "err", err)
+		} else {
+			added, removed := diffNames(ingest.KeywordTerms(s.keywords), ingest.KeywordTerms(keywords))
+			s.logger.Info("Keywords reloaded", "count", len(keywords), "added", added, "removed", removed, "synonyms", len(synonyms))
+			if len(keywords) == 0 && len(s.keywords) > 0 {
+				logNoKeywords(s.logger, s.noKeywords, s.inputs.keywordsPath)
+			}
+			s.keywords = keywords
+			s.matcher = matcher
+		}
+		s.inputs.keywordsMod = mod
+		s.inputs.synonymsMod = synMod
+	}
+}
+
+// logNoKeywords says which NO_KEYWORDS behaviour applies now that there are
+// no keywords to match
+func logNoKeywords(logger *slog.Logger, mode, path string) {
+	if mode == filter.NoKeywordsAll {
+		logger.Warn("No keywords configured, keeping every post regardless of min_score (NO_KEYWORDS=all)", "path", path)
+		return
+	}
+	logger.Warn("No keywords configured, keeping only posts that meet their target's min_score (NO_KEYWORDS=score)", "path", path)
+}
+
+// buildMatcher matches the literal keywords in mode (MATCH_MODE) and any
+// regex entries as patterns, reporting what they matched. With collapse
+// (COLLAPSE_OVERLAPS) hits nested in a longer hit are dropped. Aliases in
+// synonyms are matched as keywords and reported under their canonical name.
+func buildMatcher(mode string, collapse bool, synonyms map[string]string, keywords []ingest.Keyword) (filter.Matcher, error) {
+	for alias, canonical := range synonyms {
+		keywords = append(keywords, ingest.Keyword{Term: alias}, ingest.Keyword{Term: strings.ToLower(canonical)})
+	}
+	m, err := buildBaseMatcher(mode, keywords)
+	if err != nil {
+		return nil, err
+	}
+	if collapse {
+		m = filter.CollapsingMatcher{Matcher: m}
+	}
+	if len(synonyms) > 0 {
+		m = filter.SynonymMatcher{Matcher: m, Canonical: synonyms}
+	}
+	return m, nil
+}
+
+// loadSynonyms reads the synonyms file; a missing file just means no aliases
+func loadSynonyms(path string) (map[string]string, error) {
+	synonyms, err := ingest.LoadSynonyms(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return synonyms, err
+}
+
+func buildBaseMatcher(mode string, keywords []ingest.Keyword) (filter.Matcher, error) {
+	var literals, patterns []string
+	for _, kw := range keywords {
+		if kw.Regex {
+			patterns = append(patterns, kw.Term)
+		} else {
+			literals = append(literals, kw.Term)
+		}
+	}
+	literal, err := filter.NewMatcher(mode, literals)
+	if err != nil || len(patterns) == 0 {
+		return literal, err
+	}
+	regex, err := filter.NewCaptureRegexMatcher(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return filter.MultiMatcher{literal, regex}, nil
+}
+
+// errNoTargets means the targets file was read but left nothing to scrape
+var errNoTargets = errors.New("no valid targets found")
+
+// loadTargets reads the targets file and applies EXCLUDE_SUBREDDITS, logging
+// what was dropped. A missing or unreadable file is returned as is; one that
+// leaves no targets is errNoTargets.
+func loadTargets(logger *slog.Logger, inputs *inputFiles, exclude []string) ([]domain.Target, error) {
+	targets, err := ingest.LoadTargetsWith(inputs.targetsPath, inputs.targetOpts)
+	if err != nil {
+		return nil, err
+	}
+	kept, excluded := ingest.ExcludeTargets(targets, exclude)
+	if len(excluded) > 0 {
+		logger.Info("Excluded targets", "targets", targetNames(excluded))
+	}
+	if len(kept) == 0 {
+		return nil, errNoTargets
+	}
+	return kept, nil
+}
+
+func modTime(path string) time.Time {
+	if path == "-" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func targetNames(targets []domain.Target) []string {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+// diffNames reports which names appear only in next (added) or only in prev (removed)
+func diffNames(prev, next []string) (added, removed []string) {
+	inPrev := make(map[string]bool, len(prev))
+	for _, n := range prev {
+		inPrev[n] = true
+	}
+	inNext := make(map[string]bool, len(next))
+	for _, n := range next {
+		inNext[n] = true
+		if !inPrev[n] {
+			added = append(added, n)
+		}
+	}
+	for _, n := range prev {
+		if !inNext[n] {
+			removed = append(removed, n)
+		}
+	}
+	return added, removed
+}
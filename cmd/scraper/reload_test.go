@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/ingest"
+)
+
+// rewrite replaces path's content and bumps its mtime so the change is seen
+// even within the filesystem's timestamp resolution
+func rewrite(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-age)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newReloadScraper loads targets and keywords from fresh files in a temp dir
+func newReloadScraper(t *testing.T, targetsCSV, keywordsCSV string) (*scraper, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	targetsPath := filepath.Join(dir, "subreddits.csv")
+	keywordsPath := filepath.Join(dir, "keywords.csv")
+	rewrite(t, targetsPath, targetsCSV, time.Hour)
+	rewrite(t, keywordsPath, keywordsCSV, time.Hour)
+
+	targets, err := ingest.LoadTargets(targetsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keywords, err := ingest.LoadKeywords(keywordsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newPipelineScraper(t, nil, targets)
+	s.keywords = keywords
+	if s.matcher, err = buildMatcher("", false, nil, keywords); err != nil {
+		t.Fatal(err)
+	}
+	s.inputs = newInputFiles(targetsPath, keywordsPath, filepath.Join(dir, "synonyms.csv"), ingest.TargetOptions{Header: true})
+	return s, targetsPath, keywordsPath
+}
+
+func TestReloadPicksUpEditedTargets(t *testing.T) {
+	s, targetsPath, _ := newReloadScraper(t, "subreddit,min_score\nnetsec,5\nmalware,5\n", "keyword,category\nSplunk,siem\n")
+
+	// Unchanged files are left alone
+	s.reloadInputs()
+	if got := targetNames(s.targets); !slices.Equal(got, []string{"netsec", "malware"}) {
+		t.Fatalf("targets = %v before the edit", got)
+	}
+
+	rewrite(t, targetsPath, "subreddit,min_score\nnetsec,5\nblueteamsec,0\n", 0)
+	s.reloadInputs()
+	if got := targetNames(s.targets); !slices.Equal(got, []string{"netsec", "blueteamsec"}) {
+		t.Errorf("targets = %v after the edit, want netsec blueteamsec", got)
+	}
+}
+
+func TestReloadKeepsPreviousConfigAfterBadFile(t *testing.T) {
+	s, targetsPath, keywordsPath := newReloadScraper(t, "subreddit,min_score\nnetsec,5\n", "keyword,category\nSplunk,siem\n")
+
+	// No valid rows, and a keyword regex that doesn't compile
+	rewrite(t, targetsPath, "subreddit,min_score\nr/!!,5\n", 0)
+	rewrite(t, keywordsPath, "keyword,category\nSplunk,siem\n/cve-(\\d+/,regex\n", 0)
+	s.reloadInputs()
+	if got := targetNames(s.targets); !slices.Equal(got, []string{"netsec"}) {
+		t.Errorf("targets = %v, want the previous netsec", got)
+	}
+	if got := ingest.KeywordTerms(s.keywords); !slices.Equal(got, []string{"Splunk"}) {
+		t.Errorf("keywords = %v, want the previous Splunk", got)
+	}
+	if len(s.matcher.Match("splunk alerts")) == 0 {
+		t.Error("matcher was replaced by the rejected reload")
+	}
+
+	// Fixing the files is picked up on the next reload
+	rewrite(t, keywordsPath, "keyword,category\nSplunk,siem\nMISP,tip\n", -time.Minute)
+	s.reloadInputs()
+	if got := ingest.KeywordTerms(s.keywords); !slices.Equal(got, []string{"Splunk", "MISP"}) {
+		t.Errorf("keywords = %v after the fix, want Splunk MISP", got)
+	}
+}
+
+func TestLoadTargetsMissingAndEmptyFiles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	load := func(name, content string, exclude ...string) ([]string, error) {
+		path := filepath.Join(dir, name)
+		if content != "" {
+			rewrite(t, path, content, 0)
+		}
+		targets, err := loadTargets(logger, newInputFiles(path, "", "", ingest.TargetOptions{Header: true}), exclude)
+		return targetNames(targets), err
+	}
+
+	if _, err := load("missing.csv", ""); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: err = %v, want ErrNotExist", err)
+	}
+	if _, err := load("header-only.csv", "subreddit,min_score\n"); !errors.Is(err, errNoTargets) {
+		t.Errorf("empty file: err = %v, want errNoTargets", err)
+	}
+	if _, err := load("invalid.csv", "subreddit,min_score\nr/!!,5\n"); !errors.Is(err, errNoTargets) {
+		t.Errorf("no valid rows: err = %v, want errNoTargets", err)
+	}
+	if _, err := load("excluded.csv", "subreddit,min_score\nnetsec,5\n", "netsec"); !errors.Is(err, errNoTargets) {
+		t.Errorf("everything excluded: err = %v, want errNoTargets", err)
+	}
+	if got, err := load("ok.csv", "subreddit,min_score\nnetsec,5\nmalware,0\n", "malware"); err != nil || !slices.Equal(got, []string{"netsec"}) {
+		t.Errorf("targets = %v (%v), want netsec", got, err)
+	}
+}
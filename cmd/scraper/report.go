@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/dashboard"
+)
+
+// renderReport writes the dashboard over the stored posts to path as a
+// standalone HTML file, returning the process exit code
+func renderReport(logger *slog.Logger, srv *dashboard.Server, path string) int {
+	f, err := os.Create(path)
+	if err != nil {
+		logger.Error("Failed to create report", "path", path, "err", err)
+		return exitConfig
+	}
+	if err := srv.RenderReport(f, time.Now()); err != nil {
+		f.Close()
+		logger.Error("Failed to render report", "path", path, "err", err)
+		return exitConfig
+	}
+	if err := f.Close(); err != nil {
+		logger.Error("Failed to write report", "path", path, "err", err)
+		return exitConfig
+	}
+	logger.Info("Report written", "path", path, "data", srv.DataFile)
+	return 0
+}
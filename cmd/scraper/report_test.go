@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/dashboard"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestRenderReportWritesKPIsAndCharts(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "current.ndjson")
+	created := float64(time.Now().Add(-time.Hour).Unix())
+	var lines []string
+	for _, p := range []domain.Post{
+		{ID: "a", Subreddit: "netsec", Title: "Splunk outage", Score: 42, CreatedUTC: created, KeywordsHit: []string{"splunk"}},
+		{ID: "b", Subreddit: "netsec", Title: "Splunk pricing", Score: 7, CreatedUTC: created, KeywordsHit: []string{"splunk"}},
+		{ID: "c", Subreddit: "sysadmin", Title: "MISP feeds", Score: 3, CreatedUTC: created, KeywordsHit: []string{"misp"}},
+	} {
+		line, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(line))
+	}
+	if err := os.WriteFile(dataFile, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "report.html")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if code := renderReport(logger, &dashboard.Server{DataFile: dataFile}, out); code != 0 {
+		t.Fatalf("renderReport exited %d", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		// KPIs: total mentions, top tool, top subreddit, highest score
+		`<div class="stat-value">3</div>`,
+		`<div class="stat-value highlight">splunk</div>`,
+		`<div class="stat-value">netsec</div>`,
+		`<div class="stat-value">42</div>`,
+		// Chart markup and the table
+		"echarts.init(",
+		"Splunk outage",
+		"generated ",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+	// A standalone report has no search form to submit
+	if strings.Contains(report, `<form`) {
+		t.Error("report includes the search form")
+	}
+}
+
+func TestRenderReportBadPath(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	out := filepath.Join(t.TempDir(), "missing", "report.html")
+	if code := renderReport(logger, &dashboard.Server{}, out); code != exitConfig {
+		t.Errorf("renderReport to a missing directory exited %d, want %d", code, exitConfig)
+	}
+}
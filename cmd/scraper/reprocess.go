@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/qepting91/reddit-scraper/internal/ingest"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// reprocess re-runs the current keywords over a stored NDJSON file, replacing
+// each record's keywords_hit, and returns the process exit code. Only titles
+// are matched: post bodies aren't stored, and a title cut by MAX_TITLE_LEN is
+// matched as stored. Records written without a title (OUTPUT_FIELDS) keep
+// their keywords_hit. Comment hits are left alone.
+func reprocess(logger *slog.Logger, path, keywordsPath, synonymsPath, matchMode string, collapse bool) int {
+	keywords, err := ingest.LoadKeywords(keywordsPath)
+	if err != nil {
+		logger.Error("Failed to load keywords", "path", keywordsPath, "err", err)
+		return exitConfig
+	}
+	synonyms, err := loadSynonyms(synonymsPath)
+	if err != nil {
+		logger.Error("Failed to load synonyms", "path", synonymsPath, "err", err)
+		return exitConfig
+	}
+	matcher, err := buildMatcher(matchMode, collapse, synonyms, keywords)
+	if err != nil {
+		logger.Error("Invalid keywords for MATCH_MODE", "mode", matchMode, "path", keywordsPath, "err", err)
+		return exitConfig
+	}
+
+	res, err := storage.Retag(path, matcher.Match)
+	if err != nil {
+		logger.Error("Reprocess failed", "path", path, "err", err)
+		return exitNoInput
+	}
+	if res.Untitled > 0 {
+		logger.Warn("Records without a stored title were left as they were", "path", path, "untitled", res.Untitled)
+	}
+	if res.Skipped > 0 {
+		logger.Warn("Dropped oversized records while reprocessing", "path", path, "skipped", res.Skipped)
+	}
+	logger.Info("Reprocess complete", "path", path, "records", res.Records, "changed", res.Changed, "keywords", len(keywords))
+	return 0
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+func TestReprocessTagsPostsWithNewKeyword(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "current.ndjson")
+	keywordsFile := filepath.Join(dir, "keywords.csv")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	w := &storage.WriterService{FilePath: dataFile}
+	posts := []domain.Post{
+		{ID: "a", Title: "Splunk tips", KeywordsHit: []string{"splunk"}},
+		{ID: "b", Title: "MISP feed recommendations"},
+	}
+	ch := make(chan domain.Post, len(posts))
+	for _, p := range posts {
+		ch <- p
+	}
+	close(ch)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	w.Start(&wg, ch)
+
+	// MISP was added to keywords.csv after b was stored
+	if err := os.WriteFile(keywordsFile, []byte("keyword,category\nSplunk,siem\nMISP,intel\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := reprocess(logger, dataFile, keywordsFile, filepath.Join(dir, "synonyms.csv"), "", false); code != 0 {
+		t.Fatalf("reprocess exited %d", code)
+	}
+
+	stored, err := storage.LoadPosts(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits := map[string][]string{}
+	for _, p := range stored {
+		hits[p.ID] = p.KeywordsHit
+	}
+	if !slices.Equal(hits["a"], []string{"splunk"}) || !slices.Equal(hits["b"], []string{"misp"}) {
+		t.Errorf("keywords_hit after reprocess = %v, want a: splunk, b: misp", hits)
+	}
+}
+
+func TestReprocessRejectsBadKeywords(t *testing.T) {
+	dir := t.TempDir()
+	keywordsFile := filepath.Join(dir, "keywords.csv")
+	if err := os.WriteFile(keywordsFile, []byte("keyword,category\n/CVE-(/,regex\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if code := reprocess(logger, filepath.Join(dir, "current.ndjson"), keywordsFile, "", "", false); code != exitConfig {
+		t.Errorf("exit code = %d, want exitConfig", code)
+	}
+}
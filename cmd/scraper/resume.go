@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// cycleQueue persists which targets a full cycle has finished (RESUME_CYCLES),
+// so a restart part-way through picks up the rest of that cycle
+type cycleQueue struct {
+	path   string
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	state storage.QueueState
+}
+
+// begin starts a cycle over targets and returns the ones still to scrape.
+// A saved state from an interrupted cycle is resumed: its finished targets
+// are skipped. Otherwise a new cycle ID is saved with an empty Done list.
+func (q *cycleQueue) begin(targets []domain.Target, now time.Time) []domain.Target {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state, err := storage.LoadQueueState(q.path)
+	if err != nil {
+		q.logger.Warn("Could not read queue state, starting a new cycle", "path", q.path, "err", err)
+	}
+	if state.CycleID != "" {
+		remaining := slices.DeleteFunc(slices.Clone(targets), func(t domain.Target) bool {
+			return slices.Contains(state.Done, t.Name())
+		})
+		q.state = state
+		q.logger.Info("Resuming interrupted cycle", "cycle", state.CycleID, "started", state.Started, "done", len(targets)-len(remaining), "remaining", len(remaining))
+		return remaining
+	}
+
+	q.state = storage.QueueState{CycleID: now.UTC().Format("20060102T150405.000Z"), Started: now.UTC()}
+	for _, t := range targets {
+		q.state.Targets = append(q.state.Targets, t.Name())
+	}
+	q.save()
+	return targets
+}
+
+// done records a finished target; a retried target is recorded once
+func (q *cycleQueue) done(t domain.Target) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if slices.Contains(q.state.Done, t.Name()) {
+		return
+	}
+	q.state.Done = append(q.state.Done, t.Name())
+	q.save()
+}
+
+// finish clears the state once the cycle has run to the end, so the next
+// cycle starts fresh
+func (q *cycleQueue) finish() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.state = storage.QueueState{}
+	if err := storage.ClearQueueState(q.path); err != nil {
+		q.logger.Error("Could not clear queue state", "path", q.path, "err", err)
+	}
+}
+
+// save writes the state; callers hold mu
+func (q *cycleQueue) save() {
+	if err := storage.SaveQueueState(q.path, q.state); err != nil {
+		q.logger.Error("Could not save queue state", "path", q.path, "err", err)
+	}
+}
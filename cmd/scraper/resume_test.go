@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// restartStub records the subreddits fetched and, when stop is set, calls
+// it on fetch number stopAfter as a shutdown signal would
+type restartStub struct {
+	collector.MockClient
+	mu        sync.Mutex
+	fetched   []string
+	stopAfter int
+	stop      context.CancelFunc
+}
+
+func (c *restartStub) FetchPosts(_ context.Context, sub, _ string, _ int) ([]domain.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetched = append(c.fetched, sub)
+	if c.stop != nil && len(c.fetched) == c.stopAfter {
+		c.stop()
+	}
+	return nil, nil
+}
+
+func TestResumeAfterRestartScrapesOnlyTheRest(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "queue_state.json")
+	targets := manyTargets(6)
+	newScraper := func(stub *restartStub) *scraper {
+		s := newPipelineScraper(t, stub, targets, "Splunk")
+		s.numWorkers = 1
+		s.queue = &cycleQueue{path: statePath, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+		return s
+	}
+
+	// Shut down while the third target is in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := &restartStub{stopAfter: 3, stop: cancel}
+	newScraper(first).runCycle(ctx)
+
+	state, err := storage.LoadQueueState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sub0", "sub1"}; !slices.Equal(state.Done, want) {
+		t.Fatalf("saved Done = %v, want %v", state.Done, want)
+	}
+
+	second := &restartStub{}
+	if _, err := newScraper(second).runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sub2", "sub3", "sub4", "sub5"}; !slices.Equal(second.fetched, want) {
+		t.Errorf("resumed cycle fetched %v, want only the remaining %v", second.fetched, want)
+	}
+	// The finished cycle clears its state, so the next one starts over
+	if _, err := os.Stat(statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("queue state left behind after the cycle finished: %v", err)
+	}
+	third := &restartStub{}
+	if _, err := newScraper(third).runCycle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(third.fetched) != len(targets) {
+		t.Errorf("next cycle fetched %v, want every target", third.fetched)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// pruneData drops posts older than the retention window from the data file
+// (and any per-subreddit or daily files) before a cycle appends new ones
+func (s *scraper) pruneData() {
+	if s.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-s.retention)
+
+	var files []string
+	if s.dataFile != storage.StdoutPath {
+		files = append(files, s.dataFile)
+	}
+	if s.splitDir != "" {
+		split, _ := filepath.Glob(filepath.Join(s.splitDir, "*.ndjson"))
+		files = append(files, split...)
+	}
+	if s.dailyDir != "" {
+		daily, _ := filepath.Glob(filepath.Join(s.dailyDir, "*.ndjson"))
+		files = append(files, daily...)
+	}
+	for _, path := range files {
+		res, err := storage.Prune(path, cutoff, s.keepUndated)
+		if err != nil {
+			s.logger.Error("Retention pruning failed", "path", path, "err", err)
+			continue
+		}
+		if res.Removed > 0 || res.Skipped > 0 {
+			s.logger.Info("Pruned old posts", "path", path, "removed", res.Removed, "kept", res.Kept, "oversized_dropped", res.Skipped)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// targetRotation caps how many targets a cycle scrapes (MAX_TARGETS_PER_CYCLE)
+// and rotates through the list across cycles, so every target still gets its
+// turn. The offset is saved after each cycle that ran to the end; an
+// interrupted one is repeated, which lets RESUME_CYCLES skip what it finished.
+type targetRotation struct {
+	max    int
+	path   string
+	logger *slog.Logger
+
+	// offset is where the next window starts, taken modulo the list length
+	// so a reload that shortens the list doesn't strand it past the end
+	offset int
+	// next is the offset to save once the current window has been scraped
+	next int
+}
+
+// newTargetRotation picks up the offset saved by an earlier run, if any
+func newTargetRotation(limit int, path string, logger *slog.Logger) *targetRotation {
+	r := &targetRotation{max: limit, path: path, logger: logger}
+	state, err := storage.LoadRotationState(path)
+	if err != nil {
+		logger.Warn("Could not read rotation state, starting at the first target", "path", path, "err", err)
+	}
+	r.offset = max(state.Offset, 0)
+	r.next = r.offset
+	return r
+}
+
+// window returns the next max targets from the offset, wrapping around the
+// end of the list. Lists no longer than max are returned whole.
+func (r *targetRotation) window(targets []domain.Target) []domain.Target {
+	if len(targets) <= r.max {
+		r.next = r.offset
+		return targets
+	}
+	start := r.offset % len(targets)
+	out := make([]domain.Target, 0, r.max)
+	for i := range r.max {
+		out = append(out, targets[(start+i)%len(targets)])
+	}
+	r.next = (start + r.max) % len(targets)
+	r.logger.Info("Target rotation", "from", start, "count", r.max, "total", len(targets))
+	return out
+}
+
+// advance moves the offset past the last window and saves it
+func (r *targetRotation) advance() {
+	if r.next == r.offset {
+		return
+	}
+	r.offset = r.next
+	if err := storage.SaveRotationState(r.path, storage.RotationState{Offset: r.offset}); err != nil {
+		r.logger.Error("Could not save rotation state", "path", r.path, "err", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRotationCyclesCoverDisjointSubsets(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "rotation_state.json")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	stub := &listingStub{}
+	s := newPipelineScraper(t, stub, manyTargets(6), "Splunk")
+	s.numWorkers = 1
+	s.rotation = newTargetRotation(3, statePath, logger)
+
+	cycle := func() []string {
+		t.Helper()
+		stub.listings = nil
+		if _, err := s.runCycle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return slices.Clone(stub.listings)
+	}
+	first, second := cycle(), cycle()
+	if want := []string{"sub0/new", "sub1/new", "sub2/new"}; !slices.Equal(first, want) {
+		t.Errorf("first cycle fetched %v, want %v", first, want)
+	}
+	if want := []string{"sub3/new", "sub4/new", "sub5/new"}; !slices.Equal(second, want) {
+		t.Errorf("second cycle fetched %v, want %v", second, want)
+	}
+
+	// A restart picks up the saved offset, which has wrapped to the start
+	s.rotation = newTargetRotation(3, statePath, logger)
+	if got := cycle(); !slices.Equal(got, first) {
+		t.Errorf("cycle after a restart fetched %v, want %v", got, first)
+	}
+}
+
+func TestRotationWrapsAroundTheList(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := newTargetRotation(3, filepath.Join(t.TempDir(), "rotation_state.json"), logger)
+	targets := manyTargets(5)
+
+	names := func() []string {
+		var out []string
+		for _, t := range r.window(targets) {
+			out = append(out, t.Subreddit)
+		}
+		r.advance()
+		return out
+	}
+	for _, want := range [][]string{
+		{"sub0", "sub1", "sub2"},
+		{"sub3", "sub4", "sub0"},
+		{"sub1", "sub2", "sub3"},
+	} {
+		if got := names(); !slices.Equal(got, want) {
+			t.Errorf("window = %v, want %v", got, want)
+		}
+	}
+	// A list no longer than the cap is scraped whole
+	if got := r.window(targets[:3]); len(got) != 3 {
+		t.Errorf("short list window = %v, want all 3", got)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
+)
+
+// fetchJob is one unit of work for a fetch worker: a whole target, or with
+// sort set just that listing of it, fetched in the given round. With group
+// set the job is a combined /new request for all of those targets instead.
+type fetchJob struct {
+	target domain.Target
+	sort   string
+	round  int
+	group  []domain.Target
+}
+
+// fetchRounds interleaves a cycle's listing requests (INTERLEAVE_FETCHES):
+// round n fetches the n-th sort of every target that has one, so each
+// target gets its first request before any gets a second. A target's
+// listings are merged and published once its last one is in. With a budget
+// (CYCLE_REQUEST_BUDGET) rounds stop when it's spent, and targets cut short
+// publish what they have.
+type fetchRounds struct {
+	// budget is how many listing requests are left this cycle, <0 = unlimited
+	budget int
+
+	mu      sync.Mutex
+	targets map[string]*roundTarget
+}
+
+// roundTarget is one target's progress through the rounds
+type roundTarget struct {
+	target   domain.Target
+	sorts    []string
+	listings [][]domain.Post
+	// finished is set once the target has been published or has failed
+	finished bool
+}
+
+// roundResult is a target whose rounds were cut short, with the posts it got
+type roundResult struct {
+	target domain.Target
+	posts  []domain.Post
+}
+
+func newFetchRounds(targets []domain.Target, sorts func(domain.Target) []string, budget int) *fetchRounds {
+	r := &fetchRounds{budget: -1, targets: make(map[string]*roundTarget, len(targets))}
+	if budget > 0 {
+		r.budget = budget
+	}
+	for _, t := range targets {
+		r.targets[roundKey(t)] = &roundTarget{target: t, sorts: sorts(t)}
+	}
+	return r
+}
+
+// round returns the jobs of round n in order, as far as the budget allows.
+// It's empty once there is nothing left to fetch or the budget is spent.
+func (r *fetchRounds) round(order []domain.Target, n int) []fetchJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []fetchJob
+	for _, t := range order {
+		rt := r.targets[roundKey(t)]
+		if rt == nil || rt.finished || n >= len(rt.sorts) {
+			continue
+		}
+		if r.budget == 0 {
+			break
+		}
+		if r.budget > 0 {
+			r.budget--
+		}
+		jobs = append(jobs, fetchJob{target: rt.target, sort: rt.sorts[n], round: n})
+	}
+	return jobs
+}
+
+// spent reports whether the budget has run out
+func (r *fetchRounds) spent() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.budget == 0
+}
+
+// add records one fetched listing of t. Once it was t's last, add returns
+// the merged listings and true.
+func (r *fetchRounds) add(t domain.Target, posts []domain.Post) ([]domain.Post, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rt := r.targets[roundKey(t)]
+	rt.listings = append(rt.listings, posts)
+	if len(rt.listings) < len(rt.sorts) {
+		return nil, false
+	}
+	rt.finished = true
+	return mergeListings(rt.listings), true
+}
+
+// fail drops t from later rounds; like a whole-target fetch, one failed
+// listing means none of its posts are published
+func (r *fetchRounds) fail(t domain.Target) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets[roundKey(t)].finished = true
+}
+
+// flush finishes the targets the budget cut short and returns what each of
+// them fetched, in order. Targets that got no request at all are left out.
+func (r *fetchRounds) flush(order []domain.Target) []roundResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rest []roundResult
+	for _, t := range order {
+		rt := r.targets[roundKey(t)]
+		if rt == nil || rt.finished || len(rt.listings) == 0 {
+			continue
+		}
+		rt.finished = true
+		rest = append(rest, roundResult{target: rt.target, posts: mergeListings(rt.listings)})
+	}
+	return rest
+}
+
+// whole maps targets queued for retry by a round job back to the targets
+// they came from, so the retry fetches every listing again
+func (r *fetchRounds) whole(targets []domain.Target) []domain.Target {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]domain.Target, 0, len(targets))
+	for _, t := range targets {
+		if rt := r.targets[roundKey(t)]; rt != nil {
+			t = rt.target
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func mergeListings(listings [][]domain.Post) []domain.Post {
+	if len(listings) == 1 {
+		return listings[0]
+	}
+	return filter.MergeByID(listings...)
+}
+
+// roundKey identifies a target within a cycle
+func roundKey(t domain.Target) string {
+	return strings.ToLower(t.Name())
+}
+
+// scrapeRound fetches one listing of a target and reports whether the
+// target is now finished. Only the first round waits for the target's
+// SUBREDDIT_MIN_INTERVAL slot; later rounds continue the same visit.
+func (s *scraper) scrapeRound(ctx context.Context, j fetchJob, rounds *fetchRounds, publish publishFunc, errs *errorCounts, abort context.CancelFunc) bool {
+	one := j.target
+	one.Sorts = []string{j.sort}
+	fetched, finished := false, false
+	collect := func(_ domain.Target, posts []domain.Post) {
+		fetched = true
+		var merged []domain.Post
+		if merged, finished = rounds.add(j.target, posts); finished {
+			publish(j.target, merged)
+		}
+	}
+	if j.round == 0 {
+		s.scrapeTarget(ctx, one, collect, errs, abort)
+	} else {
+		s.fetchTarget(ctx, one, collect, errs, abort)
+	}
+	if !fetched {
+		if ctx.Err() != nil {
+			return false
+		}
+		rounds.fail(j.target)
+		return true
+	}
+	return finished
+}
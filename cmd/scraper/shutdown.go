@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout bounds how long shutdown waits for in-flight work
+const defaultShutdownTimeout = 30 * time.Second
+
+// activity tracks the work a cycle has in flight (targets being fetched, the
+// writer draining) so a shutdown that overruns can say what it is stuck on.
+// A nil *activity tracks nothing.
+type activity struct {
+	mu    sync.Mutex
+	names map[string]int
+}
+
+// start marks name as running and returns the func that marks it done
+func (a *activity) start(name string) func() {
+	if a == nil {
+		return func() {}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.names == nil {
+		a.names = make(map[string]int)
+	}
+	a.names[name]++
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.names[name]--; a.names[name] <= 0 {
+			delete(a.names, name)
+		}
+	}
+}
+
+// pending lists what is still running, sorted
+func (a *activity) pending() []string {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	names := make([]string, 0, len(a.names))
+	for name := range a.names {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// forceExitAfter kills the process if shutdown hasn't finished within timeout,
+// e.g. because a worker is stuck in a call that ignores cancellation. A
+// shutdown that finishes in time returns from main, which ends the process
+// before the timer fires.
+func forceExitAfter(logger *slog.Logger, timeout time.Duration, active *activity) {
+	time.AfterFunc(timeout, func() {
+		logger.Error("Shutdown timed out, forcing exit", "timeout", timeout, "pending", active.pending())
+		os.Exit(exitShutdown)
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// stuckStub never returns from a fetch, ignoring cancellation
+type stuckStub struct {
+	collector.MockClient
+	started chan struct{}
+}
+
+func (c *stuckStub) FetchPosts(context.Context, string, string, int) ([]domain.Post, error) {
+	close(c.started)
+	select {}
+}
+
+// TestShutdownTimeoutForcesExit runs a cycle whose worker, and so the writer
+// draining behind it, never finishes. forceExitAfter calls os.Exit, so the
+// stuck shutdown runs in a child copy of the test binary.
+func TestShutdownTimeoutForcesExit(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	if os.Getenv("SCRAPER_TEST_STUCK_SHUTDOWN") == "1" {
+		stub := &stuckStub{started: make(chan struct{})}
+		s := newPipelineScraper(t, stub, []domain.Target{{Subreddit: "netsec"}}, "Splunk")
+		s.logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+		s.active = &activity{}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-stub.started
+			forceExitAfter(s.logger, timeout, s.active)
+			cancel()
+		}()
+		s.runCycle(ctx)
+		t.Fatal("the stuck cycle returned")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestShutdownTimeoutForcesExit$")
+	cmd.Env = append(os.Environ(), "SCRAPER_TEST_STUCK_SHUTDOWN=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start)
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitShutdown {
+		t.Fatalf("child exited with %v, want code %d\n%s", err, exitShutdown, stderr.String())
+	}
+	if elapsed > 10*time.Second {
+		t.Errorf("shutdown took %v, want it forced after about %v", elapsed, timeout)
+	}
+	log := stderr.String()
+	if !strings.Contains(log, "Shutdown timed out") || !strings.Contains(log, "writer") || !strings.Contains(log, "target netsec") {
+		t.Errorf("log doesn't name what was pending:\n%s", log)
+	}
+}
+
+func TestActivityPending(t *testing.T) {
+	a := &activity{}
+	doneWriter := a.start("writer")
+	doneA := a.start("target netsec")
+	doneB := a.start("target netsec")
+	if got := a.pending(); !slices.Equal(got, []string{"target netsec", "writer"}) {
+		t.Errorf("pending = %v", got)
+	}
+	doneA()
+	doneWriter()
+	if got := a.pending(); !slices.Equal(got, []string{"target netsec"}) {
+		t.Errorf("after two finished, pending = %v", got)
+	}
+	doneB()
+	if got := a.pending(); len(got) != 0 {
+		t.Errorf("pending = %v, want nothing", got)
+	}
+
+	// A nil activity tracks nothing
+	var none *activity
+	none.start("writer")()
+	if none.pending() != nil {
+		t.Error("nil activity reported pending work")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// refreshStats fetches the about stats of each subreddit target whose cached
+// entry is older than statsTTL and saves the whole cache for the dashboard.
+// Entries survive restarts through the stats file, so a restart doesn't
+// refetch everything.
+func (s *scraper) refreshStats(ctx context.Context) {
+	if s.stats == nil {
+		return
+	}
+	if s.statsCache == nil {
+		cache, err := storage.LoadStats(s.statsFile)
+		if err != nil {
+			s.logger.Warn("Could not read subreddit stats, refetching", "path", s.statsFile, "err", err)
+		}
+		s.statsCache = cache
+	}
+
+	fetched := 0
+	for _, t := range s.targets {
+		if t.Kind != domain.KindSubreddit || ctx.Err() != nil {
+			continue
+		}
+		key := domain.SubredditKey(t.Subreddit)
+		if cached, ok := s.statsCache[key]; ok && time.Since(cached.FetchedAt) < s.statsTTL {
+			continue
+		}
+		stats, err := s.stats.FetchSubredditStats(ctx, t.Subreddit)
+		if err != nil {
+			s.logger.Warn("Subreddit stats fetch failed", "sub", t.Name(), "err", err)
+			continue
+		}
+		s.statsCache[key] = stats
+		fetched++
+	}
+	if fetched == 0 {
+		return
+	}
+	if err := storage.SaveStats(s.statsFile, s.statsCache); err != nil {
+		s.logger.Error("Could not save subreddit stats", "path", s.statsFile, "err", err)
+		return
+	}
+	s.logger.Info("Subreddit stats refreshed", "fetched", fetched, "cached", len(s.statsCache))
+}
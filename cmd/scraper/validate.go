@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// validateTargets checks each subreddit target with the collector and logs
+// the outcome, returning how many are missing or unreadable. Multireddits are
+// skipped; their member subreddits aren't known without another request.
+func validateTargets(ctx context.Context, logger *slog.Logger, client domain.Collector, targets []domain.Target) int {
+	bad := 0
+	for _, t := range targets {
+		if t.Kind == domain.KindMulti {
+			logger.Info("Skipping multireddit validation", "sub", t.Name())
+			continue
+		}
+		exists, accessible, err := client.CheckSubreddit(ctx, t.Subreddit)
+		switch {
+		case exists && accessible:
+			logger.Info("Target OK", "sub", t.Name())
+		case exists:
+			bad++
+			logger.Warn("Target exists but is not accessible", "sub", t.Name(), "err", err)
+		case err != nil:
+			bad++
+			logger.Error("Target check failed", "sub", t.Name(), "err", err)
+		default:
+			bad++
+			logger.Warn("Target does not exist", "sub", t.Name())
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	logger.Info("Validation complete", "targets", len(targets), "problems", bad)
+	return bad
+}
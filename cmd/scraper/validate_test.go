@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/collector"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// aboutStub answers CheckSubreddit from a fixed table
+type aboutStub struct {
+	collector.MockClient
+}
+
+func (c *aboutStub) CheckSubreddit(_ context.Context, sub string) (bool, bool, error) {
+	switch sub {
+	case "netsec":
+		return true, true, nil
+	case "secretclub":
+		return true, false, collector.ErrPrivate
+	case "busy":
+		return false, false, collector.ErrRateLimited
+	}
+	return false, false, nil
+}
+
+func TestValidateTargets(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	targets := []domain.Target{
+		{Subreddit: "netsec"},
+		{Subreddit: "secretclub"},
+		{Subreddit: "busy"},
+		{Subreddit: "nettsec"},
+		{Kind: domain.KindMulti, Owner: "someone", Multi: "security"},
+	}
+	if bad := validateTargets(context.Background(), logger, &aboutStub{}, targets); bad != 3 {
+		t.Errorf("validateTargets = %d problems, want 3", bad)
+	}
+	for _, want := range []string{
+		`msg="Target OK" sub=netsec`,
+		`msg="Target exists but is not accessible" sub=secretclub err="subreddit is private"`,
+		`msg="Target check failed" sub=busy`,
+		`msg="Target does not exist" sub=nettsec`,
+		`msg="Skipping multireddit validation" sub=user/someone/m/security`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs lack %s", want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/go-echarts/go-echarts/v2 v2.6.7
 	github.com/joho/godotenv v1.5.1
 	github.com/loganintech/go-reddit/v2 v2.3.1
+	golang.org/x/sync v0.16.0
 	golang.org/x/time v0.14.0
 )
 
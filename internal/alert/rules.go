@@ -0,0 +1,200 @@
+// Package alert watches mention rates and notifies a webhook when they spike
+package alert
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// Rule kinds: what a rule counts mentions of
+const (
+	KindKeyword   = "keyword"
+	KindSubreddit = "subreddit"
+)
+
+// Any as a rule's name applies it to every keyword (or subreddit) separately
+const Any = "*"
+
+// Rule fires when more than Threshold posts mention Name within Window. After
+// firing for a name it stays quiet for Cooldown.
+type Rule struct {
+	Kind      string
+	Name      string
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+}
+
+// Alert is one rule crossing its threshold
+type Alert struct {
+	Kind   string
+	Name   string
+	Count  int
+	Window time.Duration
+	At     time.Time
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("%s %q: %d mentions in the last %s", a.Kind, a.Name, a.Count, a.Window)
+}
+
+// LoadRules reads an alert rules CSV with a header row and the columns
+// kind,name,threshold,window[,cooldown], e.g. "keyword,CrowdStrike,10,1h,6h".
+// The cooldown defaults to the window. Names compare case-insensitively.
+func LoadRules(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+
+	var rules []Rule
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rules, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if first {
+			continue // header
+		}
+		rule, err := parseRule(rec)
+		if err != nil {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("%s: line %d: %w", path, line, err)
+		}
+		rules = append(rules, rule)
+	}
+}
+
+func parseRule(rec []string) (Rule, error) {
+	if len(rec) < 4 {
+		return Rule{}, fmt.Errorf("want kind,name,threshold,window[,cooldown]")
+	}
+	for i := range rec {
+		rec[i] = strings.TrimSpace(rec[i])
+	}
+	rule := Rule{Kind: strings.ToLower(rec[0]), Name: strings.ToLower(rec[1])}
+	switch rule.Kind {
+	case KindKeyword:
+	case KindSubreddit:
+		rule.Name = normalizeSubreddit(rule.Name)
+	default:
+		return rule, fmt.Errorf("unknown kind %q (keyword, subreddit)", rec[0])
+	}
+	if rule.Name == "" {
+		return rule, fmt.Errorf("empty name (use %q for all)", Any)
+	}
+	var err error
+	if rule.Threshold, err = strconv.Atoi(rec[2]); err != nil || rule.Threshold < 1 {
+		return rule, fmt.Errorf("invalid threshold %q (must be > 0)", rec[2])
+	}
+	if rule.Window, err = time.ParseDuration(rec[3]); err != nil || rule.Window <= 0 {
+		return rule, fmt.Errorf("invalid window %q (e.g. 1h)", rec[3])
+	}
+	rule.Cooldown = rule.Window
+	if len(rec) > 4 && rec[4] != "" {
+		if rule.Cooldown, err = time.ParseDuration(rec[4]); err != nil || rule.Cooldown < 0 {
+			return rule, fmt.Errorf("invalid cooldown %q (e.g. 6h)", rec[4])
+		}
+	}
+	return rule, nil
+}
+
+// Tracker counts mentions in rolling windows and reports rules crossing their
+// thresholds. It is safe for concurrent use. State is kept in memory only,
+// so a restart starts every window afresh.
+type Tracker struct {
+	rules []Rule
+
+	mu sync.Mutex
+	// seen holds mention times per rule index and name, oldest first
+	seen  map[trackKey][]time.Time
+	fired map[trackKey]time.Time
+}
+
+type trackKey struct {
+	rule int
+	name string
+}
+
+// NewTracker returns a Tracker for rules, or nil when there are none
+func NewTracker(rules []Rule) *Tracker {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Tracker{rules: rules, seen: make(map[trackKey][]time.Time), fired: make(map[trackKey]time.Time)}
+}
+
+// Observe counts p's keywords and subreddit at now and returns the alerts
+// that fire as a result. A nil Tracker observes nothing.
+func (t *Tracker) Observe(p domain.Post, now time.Time) []Alert {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var alerts []Alert
+	for i, r := range t.rules {
+		for _, name := range mentions(r.Kind, p) {
+			if r.Name != Any && r.Name != name {
+				continue
+			}
+			key := trackKey{i, name}
+			times := append(prune(t.seen[key], now.Add(-r.Window)), now)
+			t.seen[key] = times
+			if len(times) <= r.Threshold {
+				continue
+			}
+			if last, ok := t.fired[key]; ok && now.Sub(last) < r.Cooldown {
+				continue
+			}
+			t.fired[key] = now
+			alerts = append(alerts, Alert{Kind: r.Kind, Name: name, Count: len(times), Window: r.Window, At: now})
+		}
+	}
+	return alerts
+}
+
+// mentions lists what a post mentions for a rule kind, lowercased and once each
+func mentions(kind string, p domain.Post) []string {
+	if kind == KindSubreddit {
+		return []string{normalizeSubreddit(p.Subreddit)}
+	}
+	var names []string
+	for _, k := range p.KeywordsHit {
+		if k = strings.ToLower(k); !slices.Contains(names, k) {
+			names = append(names, k)
+		}
+	}
+	return names
+}
+
+func normalizeSubreddit(name string) string {
+	return strings.TrimPrefix(strings.ToLower(name), "r/")
+}
+
+// prune drops times before cutoff; times are in ascending order
+func prune(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
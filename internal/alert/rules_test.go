@@ -0,0 +1,106 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestTrackerFiresOnceThenCoolsDown(t *testing.T) {
+	tr := NewTracker([]Rule{{Kind: KindKeyword, Name: "crowdstrike", Threshold: 3, Window: time.Hour, Cooldown: 6 * time.Hour}})
+	post := domain.Post{Subreddit: "netsec", KeywordsHit: []string{"CrowdStrike"}}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var fired []Alert
+	for i := range 6 {
+		fired = append(fired, tr.Observe(post, start.Add(time.Duration(i)*time.Minute))...)
+	}
+	if len(fired) != 1 {
+		t.Fatalf("got %d alerts within the cooldown, want 1: %v", len(fired), fired)
+	}
+	if a := fired[0]; a.Name != "crowdstrike" || a.Count != 4 || !a.At.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("alert = %+v, want crowdstrike with 4 mentions at the 4th post", a)
+	}
+
+	// after the cooldown the old mentions have left the window, so it takes
+	// another run past the threshold to fire again
+	later := start.Add(7 * time.Hour)
+	fired = nil
+	for i := range 4 {
+		got := tr.Observe(post, later.Add(time.Duration(i)*time.Minute))
+		if i < 3 && len(got) > 0 {
+			t.Fatalf("mention %d after the cooldown fired %v, want nothing until past the threshold", i+1, got)
+		}
+		fired = append(fired, got...)
+	}
+	if len(fired) != 1 || fired[0].Count != 4 {
+		t.Errorf("got %v after the cooldown, want one alert with 4 mentions", fired)
+	}
+}
+
+func TestTrackerAnyCountsEachNameSeparately(t *testing.T) {
+	tr := NewTracker([]Rule{{Kind: KindSubreddit, Name: Any, Threshold: 1, Window: time.Hour}})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tr.Observe(domain.Post{Subreddit: "netsec"}, now)
+	if got := tr.Observe(domain.Post{Subreddit: "malware"}, now); len(got) != 0 {
+		t.Fatalf("first malware post fired %v; names must be counted separately", got)
+	}
+	got := tr.Observe(domain.Post{Subreddit: "r/NetSec"}, now)
+	if len(got) != 1 || got[0].Name != "netsec" || got[0].Count != 2 {
+		t.Errorf("got %v, want one netsec alert with 2 mentions", got)
+	}
+}
+
+func TestNilTrackerObservesNothing(t *testing.T) {
+	var tr *Tracker
+	if tr = NewTracker(nil); tr != nil {
+		t.Fatal("NewTracker(nil) should return nil")
+	}
+	if got := tr.Observe(domain.Post{KeywordsHit: []string{"x"}}, time.Now()); got != nil {
+		t.Errorf("nil tracker returned %v", got)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.csv")
+	content := "kind,name,threshold,window,cooldown\n" +
+		"# spikes worth a look\n" +
+		"keyword, CrowdStrike ,10,1h,6h\n" +
+		"subreddit,r/NetSec,50,30m\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Rule{
+		{Kind: KindKeyword, Name: "crowdstrike", Threshold: 10, Window: time.Hour, Cooldown: 6 * time.Hour},
+		{Kind: KindSubreddit, Name: "netsec", Threshold: 50, Window: 30 * time.Minute, Cooldown: 30 * time.Minute},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("got %d rules, want %d: %+v", len(rules), len(want), rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+}
+
+func TestLoadRulesReportsLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.csv")
+	content := "kind,name,threshold,window\nkeyword,ransomware,5,1h\nkeyword,phishing,0,1h\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadRules(path)
+	if err == nil || !strings.Contains(err.Error(), "line 3") || !strings.Contains(err.Error(), "threshold") {
+		t.Errorf("err = %v, want an invalid threshold on line 3", err)
+	}
+}
@@ -0,0 +1,61 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Webhook posts alerts as JSON to URL. The body carries a "text" summary,
+// which Slack and Mattermost-style endpoints display as is, plus the alert's
+// fields for anything parsing it.
+type Webhook struct {
+	URL string
+	// Client defaults to one with a 10s timeout
+	Client *http.Client
+}
+
+type webhookBody struct {
+	Text   string    `json:"text"`
+	Kind   string    `json:"kind"`
+	Name   string    `json:"name"`
+	Count  int       `json:"count"`
+	Window string    `json:"window"`
+	At     time.Time `json:"at"`
+}
+
+// Send delivers one alert; any non-2xx answer is an error
+func (w *Webhook) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(webhookBody{
+		Text:   "Mention spike: " + a.String(),
+		Kind:   a.Kind,
+		Name:   a.Name,
+		Count:  a.Count,
+		Window: a.Window.String(),
+		At:     a.At,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
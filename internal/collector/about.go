@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// aboutResponse covers both a subreddit's about document and Reddit's JSON
+// error bodies, which carry a "reason" for 403/404s
+type aboutResponse struct {
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+	Data   struct {
+		Name            string `json:"display_name"`
+		Subscribers     int    `json:"subscribers"`
+		ActiveUserCount int    `json:"active_user_count"`
+		// Older name for active_user_count, still sent by some endpoints
+		AccountsActive int `json:"accounts_active"`
+	} `json:"data"`
+}
+
+// decodeAbout interprets a /r/<sub>/about response for CheckSubreddit
+func decodeAbout(status int, body io.Reader) (exists, accessible bool, err error) {
+	var about aboutResponse
+	// Error bodies aren't always JSON; an empty reason is handled below
+	json.NewDecoder(body).Decode(&about)
+
+	switch status {
+	case http.StatusOK:
+		// Unknown names can redirect to a search listing instead of a t5
+		if about.Kind != "t5" {
+			return false, false, nil
+		}
+		return true, true, nil
+	case http.StatusForbidden:
+		switch about.Reason {
+		case "private", "gold_only":
+			return true, false, ErrPrivate
+		case "quarantined":
+			return true, false, ErrQuarantined
+		}
+		return true, false, ErrForbidden
+	case http.StatusNotFound:
+		if about.Reason == "banned" {
+			return true, false, ErrBanned
+		}
+		return false, false, nil
+	}
+	return false, false, fmt.Errorf("checking subreddit: %w", statusError(status))
+}
+
+// decodeAboutStats reads subscriber and active-user counts from a
+// /r/<sub>/about response for FetchSubredditStats
+func decodeAboutStats(sub string, status int, body io.Reader) (domain.SubredditStats, error) {
+	if status != http.StatusOK {
+		return domain.SubredditStats{}, fmt.Errorf("subreddit about: %w", statusError(status))
+	}
+	var about aboutResponse
+	if err := json.NewDecoder(body).Decode(&about); err != nil {
+		return domain.SubredditStats{}, err
+	}
+	if about.Kind != "t5" {
+		return domain.SubredditStats{}, fmt.Errorf("subreddit about: %w", ErrNotFound)
+	}
+	active := about.Data.ActiveUserCount
+	if active == 0 {
+		active = about.Data.AccountsActive
+	}
+	name := about.Data.Name
+	if name == "" {
+		name = sub
+	}
+	return domain.SubredditStats{
+		Subreddit:   name,
+		Subscribers: about.Data.Subscribers,
+		ActiveUsers: active,
+		FetchedAt:   time.Now().UTC(),
+	}, nil
+}
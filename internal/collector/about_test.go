@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// aboutFixtures are /r/<sub>/about.json responses keyed by subreddit
+var aboutFixtures = map[string]struct {
+	status int
+	body   string
+}{
+	"netsec":     {http.StatusOK, `{"kind":"t5","data":{"display_name":"netsec","subscribers":500000,"active_user_count":1200}}`},
+	"oldactive":  {http.StatusOK, `{"kind":"t5","data":{"display_name":"OldActive","subscribers":10,"accounts_active":3}}`},
+	"nosuchsub":  {http.StatusNotFound, `{"message":"Not Found","error":404}`},
+	"searchpage": {http.StatusOK, `{"kind":"Listing","data":{"children":[]}}`},
+	"secretclub": {http.StatusForbidden, `{"reason":"private","message":"Forbidden","error":403}`},
+	"goldclub":   {http.StatusForbidden, `{"reason":"gold_only","message":"Forbidden","error":403}`},
+	"edgy":       {http.StatusForbidden, `{"reason":"quarantined","quarantine_message":"...","error":403}`},
+	"gone":       {http.StatusNotFound, `{"reason":"banned","message":"Not Found","error":404}`},
+	"blocked":    {http.StatusForbidden, `<html>blocked</html>`},
+	"busy":       {http.StatusTooManyRequests, ``},
+}
+
+func newAboutServer(t *testing.T) *PublicClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/r/"), "/about.json")
+		f, ok := aboutFixtures[sub]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			return
+		}
+		w.WriteHeader(f.status)
+		io.WriteString(w, f.body)
+	}))
+	t.Cleanup(srv.Close)
+	return newTestPublicClient(t, srv)
+}
+
+func TestCheckSubredditOutcomes(t *testing.T) {
+	pc := newAboutServer(t)
+	tests := []struct {
+		sub                string
+		exists, accessible bool
+		err                error
+	}{
+		{"netsec", true, true, nil},
+		{"nosuchsub", false, false, nil},
+		{"searchpage", false, false, nil},
+		{"secretclub", true, false, ErrPrivate},
+		{"goldclub", true, false, ErrPrivate},
+		{"edgy", true, false, ErrQuarantined},
+		{"gone", true, false, ErrBanned},
+		{"blocked", true, false, ErrForbidden},
+		{"busy", false, false, ErrRateLimited},
+	}
+	for _, tt := range tests {
+		exists, accessible, err := pc.CheckSubreddit(context.Background(), tt.sub)
+		if exists != tt.exists || accessible != tt.accessible {
+			t.Errorf("%s: exists, accessible = %v, %v; want %v, %v", tt.sub, exists, accessible, tt.exists, tt.accessible)
+		}
+		if (tt.err == nil && err != nil) || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: err = %v, want %v", tt.sub, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeAboutStats(t *testing.T) {
+	tests := []struct {
+		sub                 string
+		name                string
+		subscribers, active int
+		err                 error
+	}{
+		{"netsec", "netsec", 500000, 1200, nil},
+		// accounts_active stands in when active_user_count is missing
+		{"oldactive", "OldActive", 10, 3, nil},
+		{"searchpage", "", 0, 0, ErrNotFound},
+		{"secretclub", "", 0, 0, ErrForbidden},
+		{"busy", "", 0, 0, ErrRateLimited},
+	}
+	for _, tt := range tests {
+		f := aboutFixtures[tt.sub]
+		stats, err := decodeAboutStats(tt.sub, f.status, strings.NewReader(f.body))
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, want %v", tt.sub, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.sub, err)
+			continue
+		}
+		if stats.Subreddit != tt.name || stats.Subscribers != tt.subscribers || stats.ActiveUsers != tt.active || stats.FetchedAt.IsZero() {
+			t.Errorf("%s: stats = %+v", tt.sub, stats)
+		}
+	}
+
+	// A body without display_name falls back to the requested name
+	stats, err := decodeAboutStats("fallback", http.StatusOK, strings.NewReader(`{"kind":"t5","data":{"subscribers":7}}`))
+	if err != nil || stats.Subreddit != "fallback" || stats.Subscribers != 7 {
+		t.Errorf("fallback: stats = %+v, err = %v", stats, err)
+	}
+}
@@ -2,7 +2,12 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/loganintech/go-reddit/v2/reddit"
@@ -16,9 +21,19 @@ type APIClient struct {
 }
 
 func NewAPIClient(id, secret, user, pass, userAgent string) (*APIClient, error) {
+	return NewAPIClientWithHTTP(id, secret, user, pass, userAgent, nil)
+}
+
+// NewAPIClientWithHTTP hands httpClient (e.g. one with custom TLS settings)
+// to the reddit library; nil keeps the library's default client
+func NewAPIClientWithHTTP(id, secret, user, pass, userAgent string, httpClient *http.Client) (*APIClient, error) {
 	creds := reddit.Credentials{ID: id, Secret: secret, Username: user, Password: pass}
 
-	client, err := reddit.NewClient(creds, reddit.WithUserAgent(userAgent))
+	opts := []reddit.Opt{reddit.WithUserAgent(userAgent)}
+	if httpClient != nil {
+		opts = append(opts, reddit.WithHTTPClient(httpClient))
+	}
+	client, err := reddit.NewClient(creds, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -30,27 +45,219 @@ func NewAPIClient(id, secret, user, pass, userAgent string) (*APIClient, error)
 }
 
 func (ac *APIClient) FetchNewPosts(ctx context.Context, sub string, limit int) ([]domain.Post, error) {
+	return ac.FetchPosts(ctx, sub, "new", limit)
+}
+
+func (ac *APIClient) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	if err := ac.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{"limit": {strconv.Itoa(limit)}}
+	return ac.fetchListing(ctx, fmt.Sprintf("r/%s/%s", sub, listingSort(sort)), q, "r/"+sub)
+}
+
+// listingSort maps sort onto a listing Reddit serves, "new" for anything unknown
+func listingSort(sort string) string {
+	switch sort {
+	case "hot", "rising", "top", "controversial":
+		return sort
+	}
+	return "new"
+}
+
+// FetchNewSince returns /new posts newer than the before fullname (the full
+// limit when before is empty) plus the cursor to pass on the next poll
+func (ac *APIClient) FetchNewSince(ctx context.Context, sub, before string, limit int) ([]domain.Post, string, error) {
+	if err := ac.limiter.Wait(ctx); err != nil {
+		return nil, before, err
+	}
+
+	q := url.Values{"limit": {strconv.Itoa(limit)}}
+	if before != "" {
+		q.Set("before", before)
+	}
+	result, err := ac.fetchListing(ctx, fmt.Sprintf("r/%s/new", sub), q, "r/"+sub)
+	if err != nil {
+		return nil, before, err
+	}
+	return result, nextCursor(result, before), nil
+}
+
+func (ac *APIClient) FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]domain.Post, error) {
 	if err := ac.limiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
-	posts, _, err := ac.client.Subreddit.NewPosts(ctx, sub, &reddit.ListOptions{Limit: limit})
+	q := url.Values{"limit": {strconv.Itoa(limit)}}
+	return ac.fetchListing(ctx, fmt.Sprintf("user/%s/m/%s/%s", owner, multi, listingSort(sort)), q, fmt.Sprintf("user/%s/m/%s", owner, multi))
+}
+
+// apiPost is a go-reddit post plus the listing fields the library doesn't decode
+type apiPost struct {
+	reddit.Post
+	Distinguished string `json:"distinguished"`
+}
+
+// fetchListing requests a post listing directly through the library's
+// authenticated transport. Its listing helpers (and multireddits, which it
+// only exposes for management) would drop fields such as distinguished.
+func (ac *APIClient) fetchListing(ctx context.Context, path string, q url.Values, target string) ([]domain.Post, error) {
+	req, err := ac.client.NewRequest(http.MethodGet, path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var listing struct {
+		Data struct {
+			Children []struct {
+				Data *apiPost `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if _, err := ac.client.Do(ctx, req, &listing); err != nil {
+		return nil, &FetchError{Mode: "api", Target: target, Err: fmt.Errorf("authenticated api error: %w", classifyAPIError(err))}
+	}
+
+	var posts []*apiPost
+	for _, child := range listing.Data.Children {
+		if child.Data != nil {
+			posts = append(posts, child.Data)
+		}
+	}
+	return toDomainPosts(posts), nil
+}
+
+// FetchComments reads a post's comment tree (see domain.CommentFetcher)
+func (ac *APIClient) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	comments, _, err := ac.fetchCommentTree(ctx, postID)
+	return capComments(comments, limit), err
+}
+
+// FetchCommentsByURL reads the whole comment tree behind a thread permalink
+func (ac *APIClient) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	_, postID, err := ParsePermalink(permalink)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ac.fetchCommentTree(ctx, postID)
+}
+
+func (ac *APIClient) fetchCommentTree(ctx context.Context, postID string) ([]domain.Comment, int, error) {
+	if err := ac.limiter.Wait(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	pc, _, err := ac.client.Post.Get(ctx, postID)
+	if err != nil {
+		return nil, 0, &FetchError{Mode: "api", Target: "t3_" + postID, Err: fmt.Errorf("authenticated api error: %w", classifyAPIError(err))}
+	}
+	var comments []domain.Comment
+	more := flattenAPIComments(pc.Comments, 0, &comments)
+	if pc.More != nil {
+		more += pc.More.Count
+	}
+	return comments, more, nil
+}
+
+func flattenAPIComments(tree []*reddit.Comment, depth int, out *[]domain.Comment) (more int) {
+	for _, c := range tree {
+		comment := domain.Comment{
+			ID:       c.ID,
+			PostID:   strings.TrimPrefix(c.PostID, "t3_"),
+			ParentID: c.ParentID,
+			Author:   c.Author,
+			Body:     c.Body,
+			Score:    c.Score,
+			Depth:    depth,
+		}
+		if c.Created != nil {
+			comment.CreatedUTC = float64(c.Created.Unix())
+		}
+		*out = append(*out, comment)
+		more += flattenAPIComments(c.Replies.Comments, depth+1, out)
+		if c.Replies.More != nil {
+			more += c.Replies.More.Count
+		}
+	}
+	return more
+}
+
+// FetchSubredditStats reads a subreddit's size via the library
+func (ac *APIClient) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	if err := ac.limiter.Wait(ctx); err != nil {
+		return domain.SubredditStats{}, err
+	}
+
+	sr, _, err := ac.client.Subreddit.Get(ctx, sub)
 	if err != nil {
-		return nil, fmt.Errorf("authenticated api error: %w", err)
+		return domain.SubredditStats{}, &FetchError{Mode: "api", Target: "r/" + sub, Err: fmt.Errorf("authenticated api error: %w", classifyAPIError(err))}
 	}
+	if sr == nil {
+		return domain.SubredditStats{}, &FetchError{Mode: "api", Target: "r/" + sub, Err: ErrNotFound}
+	}
+	stats := domain.SubredditStats{Subreddit: sr.Name, Subscribers: sr.Subscribers, FetchedAt: time.Now().UTC()}
+	if sr.ActiveUserCount != nil {
+		stats.ActiveUsers = *sr.ActiveUserCount
+	}
+	return stats, nil
+}
 
+// CheckSubreddit looks the subreddit up via the library (see domain.Collector).
+// The library drops Reddit's error body, so a 403 can't be told apart any
+// further than ErrForbidden.
+func (ac *APIClient) CheckSubreddit(ctx context.Context, sub string) (bool, bool, error) {
+	if err := ac.limiter.Wait(ctx); err != nil {
+		return false, false, err
+	}
+
+	sr, _, err := ac.client.Subreddit.Get(ctx, sub)
+	if err != nil {
+		err = classifyAPIError(err)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return false, false, nil
+		case errors.Is(err, ErrForbidden):
+			return true, false, ErrForbidden
+		}
+		return false, false, &FetchError{Mode: "api", Target: "r/" + sub, Err: fmt.Errorf("authenticated api error: %w", err)}
+	}
+	// Unknown names can come back as something other than a subreddit
+	if sr == nil {
+		return false, false, nil
+	}
+	return true, true, nil
+}
+
+// toDomainPosts maps go-reddit posts onto domain.Posts. go-reddit doesn't
+// decode total_awards_received, so Awards stays zero in api mode.
+func toDomainPosts(posts []*apiPost) []domain.Post {
 	var result []domain.Post
 	for _, p := range posts {
-		result = append(result, domain.Post{
-			ID:           p.ID,
-			Title:        p.Title,
-			Subreddit:    p.SubredditNamePrefixed,
-			Author:       p.Author,
-			URL:          p.URL,
-			Score:        p.Score,
-			CommentCount: p.NumberOfComments,
-			CreatedUTC:   float64(p.Created.Time.Unix()),
-		})
-	}
-	return result, nil
+		post := domain.Post{
+			ID:            p.ID,
+			Title:         p.Title,
+			Subreddit:     p.SubredditNamePrefixed,
+			Author:        p.Author,
+			URL:           p.URL,
+			Permalink:     threadURL(p.Permalink),
+			UpvoteRatio:   float64(p.UpvoteRatio),
+			NSFW:          p.NSFW,
+			Score:         p.Score,
+			CommentCount:  p.NumberOfComments,
+			CreatedUTC:    float64(p.Created.Time.Unix()),
+			IsSelf:        p.IsSelfPost,
+			Kind:          classifyKind(p.IsSelfPost, p.IsVideo, false, p.PostHint, p.URL),
+			Removed:       isRemoved(p.Author, p.Body, ""),
+			ContentHash:   contentHash(p.Title, p.Body, p.URL, p.IsSelfPost),
+			Distinguished: p.Distinguished,
+		}
+		// go-reddit keeps only whole seconds and turns false into nil
+		if p.Edited != nil && !p.Edited.IsZero() {
+			post.Edited = true
+			post.EditedUTC = float64(p.Edited.Unix())
+		}
+		result = append(result, post)
+	}
+	return result
 }
@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// MaxCombinedSubreddits caps how many subreddits go into one "a+b+c"
+// listing. Reddit doesn't document a limit, but combined paths beyond about
+// 100 names are rejected or silently truncated.
+const MaxCombinedSubreddits = 100
+
+// combinedPath validates subs and joins them for /r/<a+b+c>
+func combinedPath(subs []string) (string, error) {
+	if len(subs) == 0 {
+		return "", fmt.Errorf("combined fetch needs at least one subreddit")
+	}
+	if len(subs) > MaxCombinedSubreddits {
+		return "", fmt.Errorf("combined fetch of %d subreddits exceeds the limit of %d; use ChunkSubreddits", len(subs), MaxCombinedSubreddits)
+	}
+	names := make([]string, len(subs))
+	for i, sub := range subs {
+		names[i] = strings.TrimPrefix(strings.TrimSpace(sub), "r/")
+		if names[i] == "" || strings.ContainsAny(names[i], "+/") {
+			return "", fmt.Errorf("invalid subreddit %q in combined fetch", sub)
+		}
+	}
+	return strings.Join(names, "+"), nil
+}
+
+// ChunkSubreddits splits subs into groups FetchCombined accepts
+func ChunkSubreddits(subs []string) [][]string {
+	var chunks [][]string
+	for len(subs) > MaxCombinedSubreddits {
+		chunks = append(chunks, subs[:MaxCombinedSubreddits])
+		subs = subs[MaxCombinedSubreddits:]
+	}
+	if len(subs) > 0 {
+		chunks = append(chunks, subs)
+	}
+	return chunks
+}
+
+// FetchCombined reads /r/<a+b+c>/new in one request (see
+// domain.CombinedFetcher). Posts carry their own subreddit from the listing.
+func (pc *PublicClient) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	joined, err := combinedPath(subs)
+	if err != nil {
+		return nil, err
+	}
+	return pc.FetchPosts(ctx, joined, "new", limit)
+}
+
+// FetchCombined reads /r/<a+b+c>/new in one request (see domain.CombinedFetcher)
+func (oc *OAuthJSONClient) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	joined, err := combinedPath(subs)
+	if err != nil {
+		return nil, err
+	}
+	return oc.FetchPosts(ctx, joined, "new", limit)
+}
+
+// FetchCombined reads /r/<a+b+c>/new in one request (see domain.CombinedFetcher).
+// The library passes the joined name through to the same endpoint.
+func (ac *APIClient) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	joined, err := combinedPath(subs)
+	if err != nil {
+		return nil, err
+	}
+	return ac.FetchPosts(ctx, joined, "new", limit)
+}
+
+// FetchCombined merges per-subreddit mock listings newest first, like Reddit
+// does, keeping limit posts in total
+func (mc *MockClient) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	if _, err := combinedPath(subs); err != nil {
+		return nil, err
+	}
+	var all []domain.Post
+	for _, sub := range subs {
+		posts, err := mc.FetchNewPosts(ctx, sub, limit)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, posts...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedUTC > all[j].CreatedUTC })
+	if len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// commentThing is one child of a comment listing: a comment (t1) or a "more"
+// stub standing in for comments Reddit didn't include
+type commentThing struct {
+	Kind string `json:"kind"`
+	Data struct {
+		ID         string  `json:"id"`
+		ParentID   string  `json:"parent_id"`
+		LinkID     string  `json:"link_id"`
+		Author     string  `json:"author"`
+		Body       string  `json:"body"`
+		Score      int     `json:"score"`
+		CreatedUTC float64 `json:"created_utc"`
+		// Replies is "" when there are none, otherwise a listing
+		Replies json.RawMessage `json:"replies"`
+		// Count is set on "more" stubs
+		Count int `json:"count"`
+	} `json:"data"`
+}
+
+type commentListing struct {
+	Data struct {
+		Children []commentThing `json:"children"`
+	} `json:"data"`
+}
+
+// decodeComments flattens a /comments/<id>.json document (a [post, comments]
+// pair of listings) depth-first. more counts the comments left behind
+// "load more" stubs.
+func decodeComments(r io.Reader) (comments []domain.Comment, more int, err error) {
+	var doc []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc) < 2 {
+		return nil, 0, nil
+	}
+	var listing commentListing
+	if err := json.Unmarshal(doc[1], &listing); err != nil {
+		return nil, 0, err
+	}
+	more = flattenComments(listing.Data.Children, 0, &comments)
+	return comments, more, nil
+}
+
+func flattenComments(children []commentThing, depth int, out *[]domain.Comment) (more int) {
+	for _, c := range children {
+		switch c.Kind {
+		case "t1":
+			*out = append(*out, domain.Comment{
+				ID:         c.Data.ID,
+				PostID:     strings.TrimPrefix(c.Data.LinkID, "t3_"),
+				ParentID:   c.Data.ParentID,
+				Author:     c.Data.Author,
+				Body:       c.Data.Body,
+				Score:      c.Data.Score,
+				Depth:      depth,
+				CreatedUTC: c.Data.CreatedUTC,
+			})
+			var replies commentListing
+			if len(c.Data.Replies) > 0 && c.Data.Replies[0] == '{' && json.Unmarshal(c.Data.Replies, &replies) == nil {
+				more += flattenComments(replies.Data.Children, depth+1, out)
+			}
+		case "more":
+			more += c.Data.Count
+		}
+	}
+	return more
+}
+
+// commentsQuery asks for the top comments first, so a small limit keeps the
+// most visible discussion
+func commentsQuery(limit int) url.Values {
+	return url.Values{"limit": {strconv.Itoa(limit)}, "sort": {"top"}}
+}
+
+// capComments trims a flattened tree to limit; Reddit treats limit as a hint
+func capComments(comments []domain.Comment, limit int) []domain.Comment {
+	if limit > 0 && len(comments) > limit {
+		return comments[:limit]
+	}
+	return comments
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// Reddit app IDs and secrets are URL-safe base64-ish tokens
+	credTokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	credUserRegex  = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+)
+
+// credentialVars lists the env vars each mode needs; oauth-json's username
+// and password are optional (client_credentials) but go together
+var credentialVars = map[string][]string{
+	"api":        {"REDDIT_CLIENT_ID", "REDDIT_CLIENT_SECRET", "REDDIT_USERNAME", "REDDIT_PASSWORD"},
+	"oauth-json": {"REDDIT_CLIENT_ID", "REDDIT_CLIENT_SECRET"},
+	"public":     {"REDDIT_USER_AGENT"},
+}
+
+// optionalCredentialVars are checked like credentialVars when set. The
+// authenticated modes fall back to a default user agent (see newCollector).
+var optionalCredentialVars = map[string][]string{
+	"api":        {"REDDIT_USER_AGENT"},
+	"oauth-json": {"REDDIT_USER_AGENT"},
+}
+
+// checkCredentials verifies that the env vars mode depends on are set and
+// plausible, so a bad .env fails at startup with the names to fix instead of
+// as auth errors part way through a cycle
+func checkCredentials(mode string) error {
+	var missing, malformed []string
+	required := len(credentialVars[mode])
+	for i, name := range append(slices.Clone(credentialVars[mode]), optionalCredentialVars[mode]...) {
+		val := os.Getenv(name)
+		switch {
+		case val == "":
+			if i < required {
+				missing = append(missing, name)
+			}
+		case name != "REDDIT_PASSWORD" && (strings.TrimSpace(val) != val || strings.ContainsAny(val, "\"'")):
+			// Usually copy-paste debris or quotes the .env loader kept;
+			// passwords may legitimately contain either
+			malformed = append(malformed, name+" (stray whitespace or quotes)")
+		case (name == "REDDIT_CLIENT_ID" || name == "REDDIT_CLIENT_SECRET") && !credTokenRegex.MatchString(val):
+			malformed = append(malformed, name+" (letters, digits, - and _ only)")
+		case name == "REDDIT_USERNAME" && !credUserRegex.MatchString(strings.TrimPrefix(val, "u/")):
+			malformed = append(malformed, name+" (3-20 letters, digits, - or _)")
+		}
+	}
+	if mode == "oauth-json" && (os.Getenv("REDDIT_USERNAME") == "") != (os.Getenv("REDDIT_PASSWORD") == "") {
+		malformed = append(malformed, "REDDIT_USERNAME/REDDIT_PASSWORD (set both for the password grant, or neither for app-only access)")
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, ", "))
+	}
+	if len(malformed) > 0 {
+		problems = append(problems, "malformed "+strings.Join(malformed, ", "))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("COLLECTOR_MODE=%s: %s (see env-example.txt)", mode, strings.Join(problems, "; "))
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+var credentialEnv = []string{"REDDIT_CLIENT_ID", "REDDIT_CLIENT_SECRET", "REDDIT_USERNAME", "REDDIT_PASSWORD", "REDDIT_USER_AGENT"}
+
+// setCredentials clears every credential var, then sets the given ones
+func setCredentials(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, name := range credentialEnv {
+		t.Setenv(name, env[name])
+	}
+}
+
+func TestCheckCredentialsMissing(t *testing.T) {
+	full := map[string]string{
+		"REDDIT_CLIENT_ID":     "abc123",
+		"REDDIT_CLIENT_SECRET": "s3cr3t-_",
+		"REDDIT_USERNAME":      "someone",
+		"REDDIT_PASSWORD":      `p a's"w`, // passwords may hold spaces and quotes
+		"REDDIT_USER_AGENT":    "desktop:intel-monitor:v1.0 (by /u/someone)",
+	}
+	tests := []struct {
+		mode   string
+		unset  []string
+		wantIn string
+	}{
+		{"api", []string{"REDDIT_CLIENT_ID"}, "missing REDDIT_CLIENT_ID"},
+		{"api", []string{"REDDIT_CLIENT_SECRET", "REDDIT_PASSWORD"}, "missing REDDIT_CLIENT_SECRET, REDDIT_PASSWORD"},
+		{"api", []string{"REDDIT_USERNAME"}, "missing REDDIT_USERNAME"},
+		{"oauth-json", []string{"REDDIT_CLIENT_ID"}, "missing REDDIT_CLIENT_ID"},
+		{"oauth-json", []string{"REDDIT_CLIENT_SECRET"}, "missing REDDIT_CLIENT_SECRET"},
+		{"oauth-json", []string{"REDDIT_PASSWORD"}, "REDDIT_USERNAME/REDDIT_PASSWORD"},
+		{"public", []string{"REDDIT_USER_AGENT"}, "missing REDDIT_USER_AGENT"},
+	}
+	for _, tt := range tests {
+		env := make(map[string]string, len(full))
+		for k, v := range full {
+			env[k] = v
+		}
+		for _, name := range tt.unset {
+			delete(env, name)
+		}
+		setCredentials(t, env)
+
+		err := checkCredentials(tt.mode)
+		if err == nil || !strings.Contains(err.Error(), tt.wantIn) {
+			t.Errorf("%s without %v: err = %v, want it to mention %q", tt.mode, tt.unset, err, tt.wantIn)
+		}
+	}
+
+	for _, mode := range []string{"api", "oauth-json", "public"} {
+		setCredentials(t, full)
+		if err := checkCredentials(mode); err != nil {
+			t.Errorf("%s with every credential: %v", mode, err)
+		}
+	}
+}
+
+func TestCheckCredentialsUserAgentOptionalWhenAuthenticated(t *testing.T) {
+	setCredentials(t, map[string]string{
+		"REDDIT_CLIENT_ID":     "abc123",
+		"REDDIT_CLIENT_SECRET": "secret",
+		"REDDIT_USERNAME":      "someone",
+		"REDDIT_PASSWORD":      "pw",
+	})
+	for _, mode := range []string{"api", "oauth-json"} {
+		if err := checkCredentials(mode); err != nil {
+			t.Errorf("%s without REDDIT_USER_AGENT: %v, want the default user agent", mode, err)
+		}
+	}
+
+	// Still checked when set
+	t.Setenv("REDDIT_USER_AGENT", `"quoted agent"`)
+	if err := checkCredentials("api"); err == nil || !strings.Contains(err.Error(), "malformed REDDIT_USER_AGENT") {
+		t.Errorf("quoted user agent: err = %v, want malformed", err)
+	}
+}
+
+func TestCheckCredentialsMalformed(t *testing.T) {
+	setCredentials(t, map[string]string{
+		"REDDIT_CLIENT_ID":     "abc 123",
+		"REDDIT_CLIENT_SECRET": "secret ",
+		"REDDIT_USERNAME":      "u/x",
+		"REDDIT_PASSWORD":      "pw",
+	})
+	err := checkCredentials("api")
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	for _, want := range []string{"REDDIT_CLIENT_ID (letters", "REDDIT_CLIENT_SECRET (stray whitespace", "REDDIT_USERNAME (3-20"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestCheckCredentialsModesWithoutCredentials(t *testing.T) {
+	setCredentials(t, nil)
+	for _, mode := range []string{"mock", "archive", "file"} {
+		if err := checkCredentials(mode); err != nil {
+			t.Errorf("%s: %v, want no credentials needed", mode, err)
+		}
+	}
+}
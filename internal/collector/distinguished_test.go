@@ -0,0 +1,21 @@
+package collector
+
+import "testing"
+
+func TestDecodeListingDistinguished(t *testing.T) {
+	want := map[string]string{
+		"organic": "", // null
+		"mod":     "moderator",
+		"admin":   "admin",
+		"missing": "",
+	}
+	posts := loadListing(t, "distinguished.json")
+	if len(posts) != len(want) {
+		t.Fatalf("decoded %d posts, want %d", len(posts), len(want))
+	}
+	for _, p := range posts {
+		if p.Distinguished != want[p.ID] {
+			t.Errorf("post %s Distinguished = %q, want %q", p.ID, p.Distinguished, want[p.ID])
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// editedStamp decodes a listing's "edited" field, which Reddit sends as
+// false for unedited posts and as the edit's epoch seconds otherwise. Some
+// very old posts carry a bare true with no time. Anything else is ignored
+// rather than failing the whole listing over one field.
+type editedStamp struct {
+	Edited bool
+	UTC    float64
+}
+
+func (e *editedStamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch string(data) {
+	case "null", "false":
+		*e = editedStamp{}
+		return nil
+	case "true":
+		*e = editedStamp{Edited: true}
+		return nil
+	}
+	var utc float64
+	if err := json.Unmarshal(data, &utc); err != nil || utc <= 0 {
+		*e = editedStamp{}
+		return nil
+	}
+	*e = editedStamp{Edited: true, UTC: utc}
+	return nil
+}
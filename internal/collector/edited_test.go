@@ -0,0 +1,34 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeListingEdited(t *testing.T) {
+	type edit struct {
+		edited bool
+		utc    float64
+	}
+	want := map[string]edit{
+		"unedited": {false, 0},
+		"edited":   {true, 1700003700.5},
+		"legacy":   {true, 0},  // a bare true with no time
+		"odd":      {false, 0}, // unexpected values don't fail the listing
+	}
+	posts := loadListing(t, "edited.json")
+	if len(posts) != len(want) {
+		t.Fatalf("decoded %d posts, want %d", len(posts), len(want))
+	}
+	for _, p := range posts {
+		if got := (edit{p.Edited, p.EditedUTC}); got != want[p.ID] {
+			t.Errorf("post %s edited = %+v, want %+v", p.ID, got, want[p.ID])
+		}
+	}
+	if got := posts[1].EditedTime(); !got.Equal(time.Unix(1700003700, 5e8)) {
+		t.Errorf("EditedTime = %v, want the epoch with its fraction", got)
+	}
+	if got := posts[0].EditedTime(); !got.IsZero() {
+		t.Errorf("unedited EditedTime = %v, want zero", got)
+	}
+}
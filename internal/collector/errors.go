@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/loganintech/go-reddit/v2/reddit"
+)
+
+// Sentinel causes for collector failures. Callers match them with errors.Is
+// to decide whether to retry, skip the target, or abort the cycle.
+var (
+	ErrRateLimited  = errors.New("rate limited")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrNetwork      = errors.New("network error")
+	// ErrRedirected means Reddit sent a public request to a non-JSON page,
+	// usually a login or consent wall when anonymous access is blocked
+	ErrRedirected = errors.New("redirected away from JSON (blocked or login required)")
+)
+
+// Reasons Reddit gives for an existing subreddit being inaccessible, reported
+// by CheckSubreddit when the response reveals them
+var (
+	ErrPrivate     = errors.New("subreddit is private")
+	ErrBanned      = errors.New("subreddit is banned")
+	ErrQuarantined = errors.New("subreddit is quarantined")
+)
+
+// FetchError adds the collector mode and target to an underlying failure
+type FetchError struct {
+	Mode   string
+	Target string
+	Err    error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s fetch %s: %v", e.Mode, e.Target, e.Err)
+}
+
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// Cause returns a short metric label for err ("rate_limited", "not_found", ...)
+func Cause(err error) string {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrNetwork):
+		return "network"
+	case errors.Is(err, ErrRedirected):
+		return "redirected"
+	default:
+		return "other"
+	}
+}
+
+// statusError maps a non-200 HTTP status onto the sentinel errors
+func statusError(code int) error {
+	if sentinel := statusSentinel(code); sentinel != nil {
+		return fmt.Errorf("%w (status %d)", sentinel, code)
+	}
+	return fmt.Errorf("unexpected status: %d", code)
+}
+
+func statusSentinel(code int) error {
+	switch code {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// classifyAPIError attaches a sentinel cause to errors from the reddit library
+func classifyAPIError(err error) error {
+	var rateErr *reddit.RateLimitError
+	if errors.As(err, &rateErr) {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	var respErr *reddit.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		if sentinel := statusSentinel(respErr.Response.StatusCode); sentinel != nil {
+			return fmt.Errorf("%w: %w", sentinel, err)
+		}
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/loganintech/go-reddit/v2/reddit"
+)
+
+func TestFetchErrorsMatchThroughWrapping(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+		cause  string
+	}{
+		{http.StatusTooManyRequests, ErrRateLimited, "rate_limited"},
+		{http.StatusUnauthorized, ErrUnauthorized, "unauthorized"},
+		{http.StatusForbidden, ErrForbidden, "forbidden"},
+		{http.StatusNotFound, ErrNotFound, "not_found"},
+		{http.StatusBadGateway, nil, "other"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		_, err := newTestPublicClient(t, srv).FetchPosts(context.Background(), "netsec", "new", 5)
+		srv.Close()
+
+		// The worker loop adds its own context on top of the collector's
+		err = fmt.Errorf("cycle: %w", err)
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("status %d: %v doesn't match %v", tt.status, err, tt.want)
+		}
+		if got := Cause(err); got != tt.cause {
+			t.Errorf("status %d: Cause = %q, want %q", tt.status, got, tt.cause)
+		}
+		var fe *FetchError
+		if !errors.As(err, &fe) || fe.Mode != "public" || fe.Target != "r/netsec" {
+			t.Errorf("status %d: FetchError = %+v, want public r/netsec", tt.status, fe)
+		}
+	}
+}
+
+func TestFetchErrorNetwork(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	pc := newTestPublicClient(t, srv)
+	srv.Close()
+
+	_, err := pc.FetchMultiPosts(context.Background(), "someone", "security", "new", 5)
+	if !errors.Is(err, ErrNetwork) || Cause(err) != "network" {
+		t.Errorf("err = %v, want ErrNetwork", err)
+	}
+	var fe *FetchError
+	if !errors.As(err, &fe) || fe.Target != "user/someone/m/security" {
+		t.Errorf("FetchError = %+v, want the multireddit target", fe)
+	}
+}
+
+func TestClassifyAPIError(t *testing.T) {
+	notFound := &reddit.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound, Request: &http.Request{Method: "GET", URL: &url.URL{}}}}
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{&reddit.RateLimitError{}, ErrRateLimited},
+		{notFound, ErrNotFound},
+		{&url.Error{Op: "Get", URL: "https://oauth.reddit.com", Err: errors.New("connection reset")}, ErrNetwork},
+	}
+	for _, tt := range tests {
+		err := classifyAPIError(tt.err)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("classifyAPIError(%T) = %v, want %v", tt.err, err, tt.want)
+		}
+		// The library's error stays reachable for callers that want its details
+		if !errors.Is(err, tt.err) {
+			t.Errorf("classifyAPIError(%T) lost the original error", tt.err)
+		}
+	}
+	if plain := errors.New("boom"); classifyAPIError(plain) != plain {
+		t.Error("unclassified errors should pass through unchanged")
+	}
+}
@@ -2,33 +2,169 @@ package collector
 
 import (
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/qepting91/reddit-scraper/internal/domain"
 )
 
-// NewCollector selects the correct implementation based on the MODE
+// NewCollector selects the correct implementation based on the MODE and
+// applies the shared limiter settings
 func NewCollector() (domain.Collector, error) {
+	c, err := newCollector()
+	if err != nil {
+		return nil, err
+	}
+	if env := os.Getenv("LIMITER_BURST"); env != "" {
+		n, err := strconv.Atoi(env)
+		if err != nil || n < 1 {
+			slog.Warn("Invalid LIMITER_BURST (must be > 0), defaulting to 1", "val", env)
+			n = DefaultBurst
+		}
+		if bs, ok := c.(burstSetter); ok {
+			bs.SetBurst(n)
+		}
+	}
+	return c, nil
+}
+
+func newCollector() (domain.Collector, error) {
 	mode := os.Getenv("COLLECTOR_MODE")
 	userAgent := os.Getenv("REDDIT_USER_AGENT")
+	if err := checkCredentials(mode); err != nil {
+		return nil, err
+	}
+	if userAgent == "" && (mode == "api" || mode == "oauth-json") {
+		slog.Warn("REDDIT_USER_AGENT is unset, using a generic user agent; Reddit asks for one naming your app and username", "mode", mode)
+	}
+
+	// Only the real clients use the network; a bad CA file is a startup error
+	var httpClient *http.Client
+	if mode == "api" || mode == "oauth-json" || mode == "public" || mode == "archive" {
+		hc, err := NewHTTPClient(10*time.Second, poolOptionsFromEnv(), tlsOptionsFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		httpClient = hc
+	}
 
 	switch mode {
 	case "api":
-		return NewAPIClient(
+		return NewAPIClientWithHTTP(
+			os.Getenv("REDDIT_CLIENT_ID"),
+			os.Getenv("REDDIT_CLIENT_SECRET"),
+			os.Getenv("REDDIT_USERNAME"),
+			os.Getenv("REDDIT_PASSWORD"),
+			userAgent,
+			httpClient,
+		)
+	case "oauth-json":
+		oc, err := NewOAuthJSONClient(
 			os.Getenv("REDDIT_CLIENT_ID"),
 			os.Getenv("REDDIT_CLIENT_SECRET"),
 			os.Getenv("REDDIT_USERNAME"),
 			os.Getenv("REDDIT_PASSWORD"),
 			userAgent,
 		)
+		if err != nil {
+			return nil, err
+		}
+		oc.httpClient = httpClient
+		return oc, nil
 	case "public":
+		baseURL, err := BaseURLFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return newPublicCollector(userAgent, httpClient, baseURL)
+	case "archive":
+		// A mirror or archive serving Reddit-shaped JSON, e.g. for backfill
+		baseURL, err := BaseURLFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if baseURL == "" {
+			return nil, fmt.Errorf("REDDIT_BASE_URL is required for archive mode")
+		}
 		if userAgent == "" {
-			return nil, fmt.Errorf("REDDIT_USER_AGENT is required for public mode")
+			userAgent = defaultArchiveUserAgent
 		}
-		return NewPublicClient(userAgent)
+		return newPublicCollector(userAgent, httpClient, baseURL)
 	case "mock":
 		return NewMockClient(), nil
+	case "file":
+		// Replays what a run with RECORD_DIR saved
+		dir := os.Getenv("REPLAY_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("REPLAY_DIR is required for file mode")
+		}
+		return NewFileCollector(dir)
 	default:
-		return nil, fmt.Errorf("unknown COLLECTOR_MODE: %s (use 'api', 'public', or 'mock')", mode)
+		return nil, fmt.Errorf("unknown COLLECTOR_MODE: %s (use 'api', 'oauth-json', 'public', 'archive', 'file', or 'mock')", mode)
+	}
+}
+
+// defaultArchiveUserAgent identifies archive-mode requests when
+// REDDIT_USER_AGENT is unset; archives rarely police it like reddit.com
+const defaultArchiveUserAgent = "reddit-scraper (archive mode)"
+
+// BaseURLFromEnv returns REDDIT_BASE_URL, validated as an absolute http(s)
+// URL, or "" when unset (the public client then uses www.reddit.com)
+func BaseURLFromEnv() (string, error) {
+	raw := strings.TrimSpace(os.Getenv("REDDIT_BASE_URL"))
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid REDDIT_BASE_URL %q: want an absolute http(s) URL", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid REDDIT_BASE_URL %q: must not have a query or fragment", raw)
+	}
+	return raw, nil
+}
+
+func newPublicCollector(userAgent string, httpClient *http.Client, baseURL string) (*PublicClient, error) {
+	pc, err := NewPublicClientWithHTTP(userAgent, httpClient, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	pc.captureRaw = os.Getenv("RAW_CAPTURE") == "true"
+	// Only ask for NSFW listings when they would be kept
+	if policy := strings.ToLower(os.Getenv("FILTER_NSFW")); policy == "include" || policy == "only" {
+		pc.over18 = true
+	}
+	if env := os.Getenv("PUBLIC_MAX_INFLIGHT"); env != "" {
+		if n, err := strconv.Atoi(env); err == nil && n > 0 {
+			pc.SetMaxInFlight(n)
+		} else {
+			slog.Warn("Invalid PUBLIC_MAX_INFLIGHT (must be > 0), defaulting to 1", "val", env)
+		}
+	}
+	if os.Getenv("ADAPTIVE_TIMEOUT") == "true" {
+		a := NewAdaptiveTimeout(httpClient.Timeout, durationFromEnv("HTTP_TIMEOUT_MIN", DefaultTimeoutMin), durationFromEnv("HTTP_TIMEOUT_MAX", DefaultTimeoutMax))
+		pc.SetAdaptiveTimeout(a)
+		slog.Info("Adaptive request timeout enabled", "base", a.Base, "min", a.Min, "max", a.Max)
+	}
+	return pc, nil
+}
+
+// durationFromEnv reads a positive Go duration, warning and using def when invalid
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	env := os.Getenv(name)
+	if env == "" {
+		return def
+	}
+	d, err := time.ParseDuration(env)
+	if err != nil || d <= 0 {
+		slog.Warn("Invalid "+name+" (e.g. 5s), using default", "val", env, "default", def)
+		return def
 	}
+	return d
 }
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestArchiveModeFetchesFromBaseURL(t *testing.T) {
+	stub := &listingStub{body: `{"kind":"Listing","data":{"children":[
+{"kind":"t3","data":{"id":"old1","title":"Splunk from 2019","subreddit":"netsec","subreddit_name_prefixed":"r/netsec","author":"a","score":12,"permalink":"/r/netsec/comments/old1/splunk/","created_utc":1550000000,"is_self":true}}
+]}}`}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	t.Setenv("COLLECTOR_MODE", "archive")
+	t.Setenv("REDDIT_USER_AGENT", "")
+	// The archive lives under a path prefix, given with a trailing slash
+	t.Setenv("REDDIT_BASE_URL", srv.URL+"/mirror/")
+	c, err := NewCollector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := c.FetchNewPosts(context.Background(), "netsec", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(stub.uris, []string{"/mirror/r/netsec/new.json?limit=10"}) {
+		t.Errorf("requested %v, want the listing under the base URL", stub.uris)
+	}
+	if stub.agents[0] != defaultArchiveUserAgent {
+		t.Errorf("user agent = %q, want the archive default", stub.agents[0])
+	}
+	if len(posts) != 1 || posts[0].ID != "old1" || posts[0].Score != 12 || posts[0].CreatedUTC != 1550000000 {
+		t.Errorf("posts = %+v", posts)
+	}
+}
+
+func TestArchiveModeNeedsBaseURL(t *testing.T) {
+	t.Setenv("COLLECTOR_MODE", "archive")
+	t.Setenv("REDDIT_BASE_URL", "")
+	if _, err := NewCollector(); err == nil || !strings.Contains(err.Error(), "REDDIT_BASE_URL is required") {
+		t.Errorf("err = %v, want REDDIT_BASE_URL required", err)
+	}
+}
+
+func TestBaseURLFromEnv(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"":                               true,
+		"https://archive.example/reddit": true,
+		"http://127.0.0.1:8080":          true,
+		"archive.example":                false,
+		"ftp://archive.example":          false,
+		"https://archive.example/?x=1":   false,
+		"https://archive.example/#top":   false,
+	} {
+		t.Setenv("REDDIT_BASE_URL", raw)
+		if _, err := BaseURLFromEnv(); (err == nil) != ok {
+			t.Errorf("REDDIT_BASE_URL=%q: err = %v, want ok %v", raw, err, ok)
+		}
+	}
+}
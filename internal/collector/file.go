@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// FileCollector replays listings saved by a RecordingCollector
+// (COLLECTOR_MODE=file, REPLAY_DIR) without touching the network, so a cycle
+// over the same targets gives the same posts every run. Recorded comments and
+// subreddit stats are replayed too. Anything that was never recorded fails
+// with ErrNotFound.
+type FileCollector struct {
+	Dir string
+}
+
+func NewFileCollector(dir string) (*FileCollector, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("replay directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("replay directory %s is not a directory", dir)
+	}
+	return &FileCollector{Dir: dir}, nil
+}
+
+func (fc *FileCollector) FetchNewPosts(ctx context.Context, sub string, limit int) ([]domain.Post, error) {
+	return fc.FetchPosts(ctx, sub, "new", limit)
+}
+
+func (fc *FileCollector) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	return fc.load("r/"+sub, subredditRecording(sub, sort), limit)
+}
+
+func (fc *FileCollector) FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]domain.Post, error) {
+	return fc.load(fmt.Sprintf("user/%s/m/%s", owner, multi), multiRecording(owner, multi, sort), limit)
+}
+
+// FetchNewSince replays the recorded /new page up to the before cursor, so
+// once a poll has seen the page later polls come back empty
+func (fc *FileCollector) FetchNewSince(ctx context.Context, sub, before string, limit int) ([]domain.Post, string, error) {
+	posts, err := fc.FetchNewPosts(ctx, sub, limit)
+	if err != nil {
+		return nil, before, err
+	}
+	if before != "" {
+		for i, p := range posts {
+			if p.Fullname() == before {
+				posts = posts[:i]
+				break
+			}
+		}
+	}
+	return posts, nextCursor(posts, before), nil
+}
+
+// CheckSubreddit treats a subreddit as existing and readable when any of its
+// listings was recorded
+func (fc *FileCollector) CheckSubreddit(ctx context.Context, sub string) (bool, bool, error) {
+	for _, sort := range []string{"new", "hot", "rising", "top", "controversial"} {
+		if _, err := os.Stat(filepath.Join(fc.Dir, subredditRecording(sub, sort))); err == nil {
+			return true, true, nil
+		}
+	}
+	return false, false, nil
+}
+
+// FetchCombined merges the subreddits' recorded /new listings newest first,
+// like Reddit's combined listing. Subreddits without a recording are left
+// out; it fails only when none of them has one.
+func (fc *FileCollector) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	var posts []domain.Post
+	var missing error
+	for _, sub := range subs {
+		share, err := fc.load("r/"+sub, subredditRecording(sub, "new"), 0)
+		if errors.Is(err, ErrNotFound) {
+			missing = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, share...)
+	}
+	if posts == nil && missing != nil {
+		return nil, missing
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].CreatedUTC > posts[j].CreatedUTC })
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+// FetchComments replays a post's recorded comments (see domain.CommentFetcher)
+func (fc *FileCollector) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	var rec recordedComments
+	if err := fc.read("t3_"+postID, commentsRecording(postID), &rec); err != nil {
+		return nil, err
+	}
+	return capComments(rec.Comments, limit), nil
+}
+
+// FetchCommentsByURL replays the recorded comments of the post behind permalink
+func (fc *FileCollector) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	_, postID, err := ParsePermalink(permalink)
+	if err != nil {
+		return nil, 0, err
+	}
+	var rec recordedComments
+	if err := fc.read("t3_"+postID, commentsRecording(postID), &rec); err != nil {
+		return nil, 0, err
+	}
+	return rec.Comments, rec.Truncated, nil
+}
+
+// FetchSubredditStats replays a subreddit's recorded about stats
+func (fc *FileCollector) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	var stats domain.SubredditStats
+	err := fc.read("r/"+sub, statsRecording(sub), &stats)
+	return stats, err
+}
+
+// load reads one listing recording, keeping at most limit posts
+func (fc *FileCollector) load(target, name string, limit int) ([]domain.Post, error) {
+	var posts []domain.Post
+	if err := fc.read(target, name, &posts); err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+// read decodes one recording into v
+func (fc *FileCollector) read(target, name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(fc.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return &FetchError{Mode: "file", Target: target, Err: fmt.Errorf("%w: no recording %s", ErrNotFound, name)}
+	}
+	if err != nil {
+		return &FetchError{Mode: "file", Target: target, Err: err}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &FetchError{Mode: "file", Target: target, Err: fmt.Errorf("recording %s: %w", name, err)}
+	}
+	return nil
+}
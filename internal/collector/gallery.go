@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"html"
+	"sort"
+)
+
+// galleryMedia is the slice of a gallery post we need: gallery_data gives the
+// display order, media_metadata the actual sources keyed by media ID
+type galleryMedia struct {
+	IsGallery   bool `json:"is_gallery"`
+	GalleryData *struct {
+		Items []struct {
+			MediaID string `json:"media_id"`
+		} `json:"items"`
+	} `json:"gallery_data"`
+	MediaMetadata map[string]struct {
+		Status string `json:"status"`
+		S      struct {
+			U   string `json:"u"`
+			GIF string `json:"gif"`
+			MP4 string `json:"mp4"`
+		} `json:"s"`
+	} `json:"media_metadata"`
+}
+
+// urls returns the gallery's full-size media URLs in display order. Items that
+// are missing from media_metadata or still processing are skipped; without
+// gallery_data the metadata keys are used in sorted order.
+func (g galleryMedia) urls() []string {
+	if !g.IsGallery || len(g.MediaMetadata) == 0 {
+		return nil
+	}
+
+	var ids []string
+	if g.GalleryData != nil {
+		for _, item := range g.GalleryData.Items {
+			ids = append(ids, item.MediaID)
+		}
+	} else {
+		for id := range g.MediaMetadata {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+
+	var urls []string
+	for _, id := range ids {
+		m, ok := g.MediaMetadata[id]
+		if !ok || (m.Status != "" && m.Status != "valid") {
+			continue
+		}
+		src := m.S.U
+		if src == "" {
+			src = m.S.GIF
+		}
+		if src == "" {
+			src = m.S.MP4
+		}
+		if src != "" {
+			// Listings without raw_json=1 HTML-escape these ("&amp;")
+			urls = append(urls, html.UnescapeString(src))
+		}
+	}
+	return urls
+}
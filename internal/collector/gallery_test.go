@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// loadListing decodes a listing fixture from testdata
+func loadListing(t *testing.T, name string) []domain.Post {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	posts, err := decodeListing(f, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return posts
+}
+
+func TestGalleryMediaURLs(t *testing.T) {
+	posts := loadListing(t, "gallery.json")
+	want := map[string][]string{
+		// Display order; the missing and unprocessed items are skipped and
+		// animated items fall back to their gif, then mp4
+		"g1": {"https://i.redd.it/b2.gif", "https://preview.redd.it/a1.png?width=800&format=png&s=abc", "https://preview.redd.it/d4.mp4"},
+		"g2": {"https://i.redd.it/m5.jpg", "https://i.redd.it/z9.jpg"},
+		"g3": nil,
+		"i1": nil,
+	}
+	for _, p := range posts {
+		if !slices.Equal(p.MediaURLs, want[p.ID]) {
+			t.Errorf("%s MediaURLs = %q, want %q", p.ID, p.MediaURLs, want[p.ID])
+		}
+	}
+	if posts[0].Kind != domain.PostGallery {
+		t.Errorf("g1 kind = %q, want gallery", posts[0].Kind)
+	}
+}
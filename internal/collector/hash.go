@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// contentHash fingerprints what a post says, for spotting crossposts and
+// edits: 64-bit FNV-1a, as 16 hex digits, over the title, the self text and,
+// for link posts only, the URL. Self posts' URL is their own thread, which
+// would make every crosspost differ. Each field is lowercased with runs of
+// whitespace collapsed to one space; the URL is trimmed and loses a trailing
+// slash. Score, author, subreddit and timestamps are deliberately left out.
+func contentHash(title, body, url string, isSelf bool) string {
+	if isSelf {
+		url = ""
+	}
+	h := fnv.New64a()
+	h.Write([]byte(normalizeHashText(title)))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeHashText(body)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.TrimSuffix(strings.TrimSpace(url), "/")))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func normalizeHashText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
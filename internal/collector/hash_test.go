@@ -0,0 +1,48 @@
+package collector
+
+import "testing"
+
+func TestContentHash(t *testing.T) {
+	base := contentHash("Splunk outage", "Search heads are down", "https://example.com/status", false)
+	if len(base) != 16 {
+		t.Errorf("hash %q isn't 16 hex digits", base)
+	}
+
+	same := []struct {
+		name             string
+		title, body, url string
+		isSelf           bool
+	}{
+		{"identical", "Splunk outage", "Search heads are down", "https://example.com/status", false},
+		{"case and spacing", "  SPLUNK   Outage ", "search heads\n\nare down", "https://example.com/status", false},
+		{"trailing slash", "Splunk outage", "Search heads are down", "https://example.com/status/", false},
+	}
+	for _, tt := range same {
+		if got := contentHash(tt.title, tt.body, tt.url, tt.isSelf); got != base {
+			t.Errorf("%s: hash %s, want %s", tt.name, got, base)
+		}
+	}
+
+	changed := []struct {
+		name             string
+		title, body, url string
+	}{
+		{"edited body", "Splunk outage", "Search heads are back up", "https://example.com/status"},
+		{"edited title", "Splunk outage resolved", "Search heads are down", "https://example.com/status"},
+		{"other link", "Splunk outage", "Search heads are down", "https://example.com/other"},
+		// The separator keeps text from sliding between fields
+		{"shifted text", "Splunk outage Search", "heads are down", "https://example.com/status"},
+	}
+	for _, tt := range changed {
+		if got := contentHash(tt.title, tt.body, tt.url, false); got == base {
+			t.Errorf("%s: hash unchanged", tt.name)
+		}
+	}
+
+	// Crossposted self posts differ only in their own thread URL
+	a := contentHash("Splunk tips", "Use tstats", "https://www.reddit.com/r/netsec/comments/a/", true)
+	b := contentHash("Splunk tips", "Use tstats", "https://www.reddit.com/r/splunk/comments/b/", true)
+	if a != b {
+		t.Errorf("self post crossposts hash %s and %s, want the same", a, b)
+	}
+}
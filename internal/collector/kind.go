@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// classifyKind works out a post's kind from the listing fields. Reddit's
+// post_hint is the best signal but is often missing, so the link's host and
+// file extension are used as a fallback.
+func classifyKind(isSelf, isVideo, isGallery bool, postHint, link string) domain.PostKind {
+	switch {
+	case isSelf:
+		return domain.PostSelf
+	case isGallery:
+		return domain.PostGallery
+	case isVideo || postHint == "hosted:video" || postHint == "rich:video":
+		return domain.PostVideo
+	case postHint == "image":
+		return domain.PostImage
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return domain.PostLink
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch path.Ext(strings.ToLower(u.Path)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return domain.PostImage
+	case ".mp4", ".webm", ".gifv":
+		return domain.PostVideo
+	}
+	switch host {
+	case "i.redd.it":
+		return domain.PostImage
+	case "v.redd.it":
+		return domain.PostVideo
+	}
+	return domain.PostLink
+}
@@ -0,0 +1,34 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestClassifyKind(t *testing.T) {
+	tests := []struct {
+		name            string
+		isSelf, isVideo bool
+		isGallery       bool
+		hint, link      string
+		want            domain.PostKind
+	}{
+		{"self post", true, false, false, "self", "https://www.reddit.com/r/netsec/comments/x/", domain.PostSelf},
+		{"external link", false, false, false, "link", "https://example.com/writeup", domain.PostLink},
+		{"image hint", false, false, false, "image", "https://example.com/render?id=1", domain.PostImage},
+		{"image by host", false, false, false, "", "https://i.redd.it/abc", domain.PostImage},
+		{"image by extension", false, false, false, "", "https://imgur.com/a.JPG", domain.PostImage},
+		{"reddit video", false, true, false, "hosted:video", "https://v.redd.it/abc", domain.PostVideo},
+		{"embedded video", false, false, false, "rich:video", "https://youtube.com/watch?v=1", domain.PostVideo},
+		{"video by host", false, false, false, "", "https://v.redd.it/abc", domain.PostVideo},
+		{"gifv", false, false, false, "", "https://i.imgur.com/a.gifv", domain.PostVideo},
+		{"gallery", false, false, true, "", "https://www.reddit.com/gallery/abc", domain.PostGallery},
+		{"unparseable link", false, false, false, "", "http://[::1", domain.PostLink},
+	}
+	for _, tt := range tests {
+		if got := classifyKind(tt.isSelf, tt.isVideo, tt.isGallery, tt.hint, tt.link); got != tt.want {
+			t.Errorf("%s: classifyKind = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package collector
+
+import "golang.org/x/time/rate"
+
+// DefaultBurst is how many requests may go out back to back before the
+// limiter's steady pace applies.
+//
+// Reddit judges clients by request spikes as much as by their average rate:
+// a burst of N lets N workers fire together at cycle start, which is exactly
+// the pattern that earns 429s and, on the public endpoints, temporary blocks.
+// Keep it small; raise it only when you have headroom under Reddit's limits.
+const DefaultBurst = 1
+
+// burstSetter is implemented by clients whose limiter burst can be changed
+// (LIMITER_BURST)
+type burstSetter interface {
+	SetBurst(n int)
+}
+
+// setBurst clamps n to at least 1 so a misconfiguration can't stall the limiter
+func setBurst(l *rate.Limiter, n int) {
+	if n < 1 {
+		n = 1
+	}
+	l.SetBurst(n)
+}
+
+// SetBurst changes how many requests may be sent back to back; the average
+// rate is unchanged. Call it before the client is shared between goroutines.
+func (pc *PublicClient) SetBurst(n int) { setBurst(pc.limiter, n) }
+
+// SetBurst changes how many requests may be sent back to back; the average
+// rate is unchanged.
+func (oc *OAuthJSONClient) SetBurst(n int) { setBurst(oc.limiter, n) }
+
+// SetBurst changes how many requests may be sent back to back; the average
+// rate is unchanged.
+func (ac *APIClient) SetBurst(n int) { setBurst(ac.limiter, n) }
@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestSetBurst(t *testing.T) {
+	pc, err := NewPublicClient("test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pc.limiter.Burst(); got != DefaultBurst {
+		t.Errorf("default burst = %d, want %d", got, DefaultBurst)
+	}
+	pc.SetBurst(4)
+	if got := pc.limiter.Burst(); got != 4 {
+		t.Errorf("burst = %d, want 4", got)
+	}
+	// A non-positive burst would block every request
+	pc.SetBurst(0)
+	if got := pc.limiter.Burst(); got != 1 {
+		t.Errorf("burst after SetBurst(0) = %d, want 1", got)
+	}
+}
+
+func TestBurstKeepsAverageRate(t *testing.T) {
+	const (
+		every    = 20 * time.Millisecond
+		burst    = 3
+		requests = 8
+	)
+	stub := &listingStub{body: emptyListing}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	pc := newTestPublicClient(t, srv)
+	pc.limiter = rate.NewLimiter(rate.Every(every), 1)
+	pc.SetBurst(burst)
+	// The extra tokens build up while the client is idle, e.g. between cycles
+	time.Sleep(burst * every)
+
+	start := time.Now()
+	var sent []time.Duration
+	for range requests {
+		if _, err := pc.FetchNewPosts(context.Background(), "netsec", 1); err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, time.Since(start))
+	}
+
+	// The burst goes out back to back...
+	if sent[burst-1] > every {
+		t.Errorf("first %d requests took %v, want them sent without waiting", burst, sent[burst-1])
+	}
+	// ...and the rest are paced at the limiter's rate, so the burst only
+	// front-loads requests rather than raising the average
+	if min := (requests - burst) * every; sent[requests-1] < min-every/2 {
+		t.Errorf("%d requests took %v, want at least about %v", requests, sent[requests-1], min)
+	}
+}
@@ -28,16 +28,87 @@ func (mc *MockClient) FetchNewPosts(ctx context.Context, sub string, limit int)
 		// Randomly select a keyword to inject
 		kw := fakeKeywords[rand.Intn(len(fakeKeywords))]
 
+		title := fmt.Sprintf("[%s] New analysis regarding %s detected in sector", sub, kw)
 		posts = append(posts, domain.Post{
 			ID:           fmt.Sprintf("mock_%s_%d", sub, i),
-			Title:        fmt.Sprintf("[%s] New analysis regarding %s detected in sector", sub, kw),
+			Title:        title,
 			Subreddit:    sub, // Note: Removed "r/" prefix here to match typical API return or keep consistency
 			Author:       "simulated_user",
 			URL:          "http://localhost/mock-url",
+			Permalink:    fmt.Sprintf("https://reddit.com/r/%s/comments/mock%d/", sub, i),
 			Score:        rand.Intn(500) + 5, // Ensure it meets min_score (usually 5 or 10)
 			CommentCount: rand.Intn(50),
 			CreatedUTC:   float64(time.Now().Unix()),
+			Kind:         domain.PostLink,
+			ContentHash:  contentHash(title, "", "http://localhost/mock-url", false),
 		})
 	}
 	return posts, nil
 }
+
+// FetchPosts ignores the sort; IDs are stable per subreddit so multiple sorts overlap like they do on Reddit
+func (mc *MockClient) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	return mc.FetchNewPosts(ctx, sub, limit)
+}
+
+// FetchNewSince behaves like a quiet subreddit: the first poll returns a full
+// page and later polls (with a cursor) return nothing new
+func (mc *MockClient) FetchNewSince(ctx context.Context, sub, before string, limit int) ([]domain.Post, string, error) {
+	if before != "" {
+		return nil, before, nil
+	}
+	posts, err := mc.FetchNewPosts(ctx, sub, limit)
+	if err != nil {
+		return nil, before, err
+	}
+	return posts, nextCursor(posts, before), nil
+}
+
+func (mc *MockClient) FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]domain.Post, error) {
+	// Attribute the fake posts to the multi's name so they're easy to spot in the dashboard
+	return mc.FetchNewPosts(ctx, multi, limit)
+}
+
+// CheckSubreddit treats every subreddit as existing and readable
+func (mc *MockClient) CheckSubreddit(ctx context.Context, sub string) (bool, bool, error) {
+	return true, true, nil
+}
+
+// FetchComments returns a short fake thread for any post
+func (mc *MockClient) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	time.Sleep(100 * time.Millisecond)
+
+	var comments []domain.Comment
+	for i := 0; i < min(limit, 3); i++ {
+		comments = append(comments, domain.Comment{
+			ID:         fmt.Sprintf("%s_c%d", postID, i),
+			PostID:     postID,
+			ParentID:   "t3_" + postID,
+			Author:     "simulated_commenter",
+			Body:       "Has anyone compared this with OpenCTI?",
+			Score:      rand.Intn(20),
+			CreatedUTC: float64(time.Now().Unix()),
+		})
+	}
+	return comments, nil
+}
+
+// FetchCommentsByURL returns the fake thread for the permalink's post
+func (mc *MockClient) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	_, postID, err := ParsePermalink(permalink)
+	if err != nil {
+		return nil, 0, err
+	}
+	comments, err := mc.FetchComments(ctx, postID, maxCommentLimit)
+	return comments, 0, err
+}
+
+// FetchSubredditStats makes up a plausible subreddit size
+func (mc *MockClient) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	return domain.SubredditStats{
+		Subreddit:   sub,
+		Subscribers: rand.Intn(500000) + 1000,
+		ActiveUsers: rand.Intn(2000),
+		FetchedAt:   time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,275 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultOAuthBaseURL  = "https://oauth.reddit.com"
+	defaultOAuthTokenURL = "https://www.reddit.com/api/v1/access_token"
+	// defaultTokenLifetime is assumed when a token response has no expires_in
+	defaultTokenLifetime = time.Hour
+)
+
+// OAuthJSONClient fetches the same .json listings as PublicClient but over
+// oauth.reddit.com with a bearer token, which earns the authenticated rate
+// limit without pulling in the full reddit library.
+type OAuthJSONClient struct {
+	httpClient *http.Client
+	limiter    *rate.Limiter
+	userAgent  string
+	baseURL    string
+	tokenURL   string
+
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	Error       string `json:"error"`
+}
+
+// NewOAuthJSONClient uses the password grant when a username is given and
+// falls back to client_credentials (app-only) otherwise.
+func NewOAuthJSONClient(id, secret, user, pass, userAgent string) (*OAuthJSONClient, error) {
+	return &OAuthJSONClient{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		// OAuth Rate Limit: ~60 reqs/min (safe buffer)
+		limiter:      rate.NewLimiter(rate.Every(1*time.Second), 1),
+		userAgent:    userAgent,
+		baseURL:      defaultOAuthBaseURL,
+		tokenURL:     defaultOAuthTokenURL,
+		clientID:     id,
+		clientSecret: secret,
+		username:     user,
+		password:     pass,
+	}, nil
+}
+
+func (oc *OAuthJSONClient) FetchNewPosts(ctx context.Context, sub string, limit int) ([]domain.Post, error) {
+	return oc.FetchPosts(ctx, sub, "new", limit)
+}
+
+func (oc *OAuthJSONClient) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	return oc.fetchListing(ctx, "r/"+sub, fmt.Sprintf("/r/%s/%s.json", sub, listingSort(sort)), listingQuery(limit))
+}
+
+// FetchNewSince returns /new posts newer than the before fullname (the full
+// limit when before is empty) plus the cursor to pass on the next poll
+func (oc *OAuthJSONClient) FetchNewSince(ctx context.Context, sub, before string, limit int) ([]domain.Post, string, error) {
+	query := listingQuery(limit)
+	if before != "" {
+		query.Set("before", before)
+	}
+	posts, err := oc.fetchListing(ctx, "r/"+sub, fmt.Sprintf("/r/%s/new.json", sub), query)
+	if err != nil {
+		return nil, before, err
+	}
+	return posts, nextCursor(posts, before), nil
+}
+
+func (oc *OAuthJSONClient) FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]domain.Post, error) {
+	return oc.fetchListing(ctx, fmt.Sprintf("user/%s/m/%s", owner, multi), fmt.Sprintf("/user/%s/m/%s/%s.json", owner, multi, listingSort(sort)), listingQuery(limit))
+}
+
+// fetchListing GETs a listing with the current token, refreshing it and
+// retrying once if Reddit answers 401 (expired or revoked token)
+func (oc *OAuthJSONClient) fetchListing(ctx context.Context, target, path string, query url.Values) ([]domain.Post, error) {
+	fail := func(err error) error { return &FetchError{Mode: "oauth-json", Target: target, Err: err} }
+
+	resp, err := oc.get(ctx, path, query)
+	if err != nil {
+		return nil, fail(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fail(fmt.Errorf("reddit oauth access: %w", statusError(resp.StatusCode)))
+	}
+
+	posts, err := decodeListing(resp.Body, false)
+	if err != nil {
+		return nil, fail(err)
+	}
+	return posts, nil
+}
+
+// FetchComments reads a post's comment tree (see domain.CommentFetcher)
+func (oc *OAuthJSONClient) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	comments, _, err := oc.fetchCommentTree(ctx, postID, limit)
+	return capComments(comments, limit), err
+}
+
+// FetchCommentsByURL reads the whole comment tree behind a thread permalink
+func (oc *OAuthJSONClient) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	_, postID, err := ParsePermalink(permalink)
+	if err != nil {
+		return nil, 0, err
+	}
+	return oc.fetchCommentTree(ctx, postID, maxCommentLimit)
+}
+
+func (oc *OAuthJSONClient) fetchCommentTree(ctx context.Context, postID string, limit int) ([]domain.Comment, int, error) {
+	fail := func(err error) error { return &FetchError{Mode: "oauth-json", Target: "t3_" + postID, Err: err} }
+
+	resp, err := oc.get(ctx, fmt.Sprintf("/comments/%s", postID), commentsQuery(limit))
+	if err != nil {
+		return nil, 0, fail(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, 0, fail(fmt.Errorf("reddit oauth access: %w", statusError(resp.StatusCode)))
+	}
+
+	comments, more, err := decodeComments(resp.Body)
+	if err != nil {
+		return nil, 0, fail(err)
+	}
+	return comments, more, nil
+}
+
+// FetchSubredditStats reads a subreddit's size from /r/<sub>/about
+func (oc *OAuthJSONClient) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	fail := func(err error) error { return &FetchError{Mode: "oauth-json", Target: "r/" + sub, Err: err} }
+
+	resp, err := oc.get(ctx, fmt.Sprintf("/r/%s/about", sub), nil)
+	if err != nil {
+		return domain.SubredditStats{}, fail(err)
+	}
+	defer resp.Body.Close()
+	stats, err := decodeAboutStats(sub, resp.StatusCode, resp.Body)
+	if err != nil {
+		return stats, fail(err)
+	}
+	return stats, nil
+}
+
+// CheckSubreddit probes /r/<sub>/about (see domain.Collector)
+func (oc *OAuthJSONClient) CheckSubreddit(ctx context.Context, sub string) (bool, bool, error) {
+	resp, err := oc.get(ctx, fmt.Sprintf("/r/%s/about", sub), nil)
+	if err != nil {
+		return false, false, &FetchError{Mode: "oauth-json", Target: "r/" + sub, Err: err}
+	}
+	defer resp.Body.Close()
+	return decodeAbout(resp.StatusCode, resp.Body)
+}
+
+// get issues an authenticated GET, refreshing the token and retrying once if
+// Reddit rejects it. Callers own the response body.
+func (oc *OAuthJSONClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := oc.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		token, err := oc.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		reqURL := oc.baseURL + path
+		if len(query) > 0 {
+			reqURL += "?" + query.Encode()
+		}
+		slog.Debug("Requesting oauth listing", "url", reqURL)
+		req, _ := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+		req.Header.Set("User-Agent", oc.userAgent)
+		req.Header.Set("Authorization", "bearer "+token)
+
+		resp, err := oc.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			oc.invalidate(token)
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// accessToken returns a cached token, requesting a new one when it's missing
+// or about to expire
+func (oc *OAuthJSONClient) accessToken(ctx context.Context) (string, error) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	if oc.token != "" && time.Now().Before(oc.expiry) {
+		return oc.token, nil
+	}
+
+	form := url.Values{}
+	if oc.username != "" {
+		form.Set("grant_type", "password")
+		form.Set("username", oc.username)
+		form.Set("password", oc.password)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", oc.tokenURL, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", oc.userAgent)
+	req.SetBasicAuth(oc.clientID, oc.clientSecret)
+
+	resp, err := oc.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: token request: %w", ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("token request: %w", statusError(resp.StatusCode))
+	}
+
+	var tok oauthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	// Reddit reports bad credentials as a 200 with an "error" field
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("%w: token request rejected: %s", ErrUnauthorized, tok.Error)
+	}
+
+	oc.token = tok.AccessToken
+	lifetime := time.Duration(tok.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	// Refresh early so a token never expires mid-request: a minute, or half
+	// the lifetime for short-lived tokens
+	oc.expiry = time.Now().Add(lifetime - min(time.Minute, lifetime/2))
+	return oc.token, nil
+}
+
+// invalidate drops token so the next call fetches a fresh one. It's a no-op
+// if another worker already replaced it.
+func (oc *OAuthJSONClient) invalidate(token string) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	if oc.token == token {
+		oc.token = ""
+	}
+}
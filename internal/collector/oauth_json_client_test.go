@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const emptyListing = `{"kind":"Listing","data":{"children":[]}}`
+
+// oauthStub is a token endpoint plus a listing endpoint that records the
+// paths it serves. Tokens are numbered tok1, tok2, ... and listing requests
+// bearing a rejected one get a 401.
+type oauthStub struct {
+	mu        sync.Mutex
+	paths     []string
+	tokens    int
+	expiresIn int
+	rejected  map[string]bool
+}
+
+func (st *oauthStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if r.URL.Path == "/token" {
+		st.tokens++
+		fmt.Fprintf(w, `{"access_token":"tok%d","expires_in":%d}`, st.tokens, st.expiresIn)
+		return
+	}
+	st.paths = append(st.paths, r.URL.Path)
+	if st.rejected[strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	io.WriteString(w, emptyListing)
+}
+
+func newTestOAuthClient(srv *httptest.Server) *OAuthJSONClient {
+	oc, _ := NewOAuthJSONClient("id", "secret", "", "", "test-agent")
+	oc.httpClient = srv.Client()
+	oc.limiter = rate.NewLimiter(rate.Inf, 1)
+	oc.baseURL = srv.URL
+	oc.tokenURL = srv.URL + "/token"
+	return oc
+}
+
+func TestOAuthJSONClientValidatesSort(t *testing.T) {
+	stub := &oauthStub{expiresIn: 3600}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	oc := newTestOAuthClient(srv)
+
+	ctx := context.Background()
+	for _, sort := range []string{"hot", "bogus", "../about"} {
+		if _, err := oc.FetchPosts(ctx, "netsec", sort, 5); err != nil {
+			t.Fatalf("FetchPosts(%q): %v", sort, err)
+		}
+	}
+	if _, err := oc.FetchMultiPosts(ctx, "owner", "sec", "bogus", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/r/netsec/hot.json", "/r/netsec/new.json", "/r/netsec/new.json", "/user/owner/m/sec/new.json"}
+	if !slices.Equal(stub.paths, want) {
+		t.Errorf("paths = %v, want %v", stub.paths, want)
+	}
+}
+
+func TestOAuthJSONClientRefreshesTokenOn401(t *testing.T) {
+	stub := &oauthStub{expiresIn: 3600, rejected: map[string]bool{"tok1": true}}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	oc := newTestOAuthClient(srv)
+
+	if _, err := oc.FetchPosts(context.Background(), "netsec", "new", 5); err != nil {
+		t.Fatalf("fetch after refresh: %v", err)
+	}
+	if stub.tokens != 2 || len(stub.paths) != 2 {
+		t.Errorf("got %d tokens and %d listing requests, want 2 and 2", stub.tokens, len(stub.paths))
+	}
+
+	// The refreshed token is reused
+	if _, err := oc.FetchPosts(context.Background(), "netsec", "new", 5); err != nil {
+		t.Fatal(err)
+	}
+	if stub.tokens != 2 {
+		t.Errorf("got %d tokens, want the refreshed one reused", stub.tokens)
+	}
+}
+
+func TestOAuthJSONClientGivesUpAfterSecond401(t *testing.T) {
+	stub := &oauthStub{expiresIn: 3600, rejected: map[string]bool{"tok1": true, "tok2": true}}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	oc := newTestOAuthClient(srv)
+
+	_, err := oc.FetchPosts(context.Background(), "netsec", "new", 5)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if stub.tokens != 2 {
+		t.Errorf("got %d tokens, want one refresh then give up", stub.tokens)
+	}
+}
+
+func TestOAuthJSONClientTokenExpiry(t *testing.T) {
+	tests := []struct {
+		expiresIn int
+		want      time.Duration
+	}{
+		{3600, time.Hour - time.Minute},
+		// Short-lived tokens keep half their lifetime rather than expiring on arrival
+		{60, 30 * time.Second},
+		{10, 5 * time.Second},
+		// A missing expires_in falls back to the default lifetime
+		{0, defaultTokenLifetime - time.Minute},
+	}
+	for _, tt := range tests {
+		stub := &oauthStub{expiresIn: tt.expiresIn}
+		srv := httptest.NewServer(stub)
+		oc := newTestOAuthClient(srv)
+
+		start := time.Now()
+		for range 2 {
+			if _, err := oc.FetchPosts(context.Background(), "netsec", "new", 5); err != nil {
+				t.Fatal(err)
+			}
+		}
+		srv.Close()
+
+		if stub.tokens != 1 {
+			t.Errorf("expires_in %d: got %d tokens for two fetches, want 1", tt.expiresIn, stub.tokens)
+		}
+		if got := oc.expiry.Sub(start); got < tt.want || got > tt.want+time.Second {
+			t.Errorf("expires_in %d: token valid for %v, want %v", tt.expiresIn, got, tt.want)
+		}
+	}
+}
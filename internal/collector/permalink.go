@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxCommentLimit is the most comments Reddit returns for one thread request
+const maxCommentLimit = 500
+
+var postIDRegex = regexp.MustCompile(`^[a-z0-9]{1,12}$`)
+
+// threadURL turns a listing's site-relative permalink ("/r/sub/comments/id/slug/")
+// into an absolute https://reddit.com URL
+func threadURL(permalink string) string {
+	if permalink == "" || strings.Contains(permalink, "://") {
+		return permalink
+	}
+	return "https://reddit.com/" + strings.TrimPrefix(permalink, "/")
+}
+
+// ParsePermalink extracts the subreddit and post ID from a thread URL. It
+// accepts the usual forms: full permalinks on any reddit.com host (www, old,
+// np, ...), with or without scheme, slug, trailing comment ID or query, bare
+// "/r/<sub>/comments/<id>" paths, and redd.it short links (no subreddit).
+func ParsePermalink(raw string) (subreddit, postID string, err error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") && !strings.HasPrefix(raw, "/") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid permalink %q: %w", raw, err)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case host == "redd.it":
+		if len(parts) == 1 && postIDRegex.MatchString(strings.ToLower(parts[0])) {
+			return "", strings.ToLower(parts[0]), nil
+		}
+	case host == "" || host == "reddit.com" || strings.HasSuffix(host, ".reddit.com"):
+		// r/<sub>/comments/<id>[/<slug>[/<comment>]] or comments/<id>
+		if len(parts) >= 4 && parts[0] == "r" && parts[2] == "comments" && postIDRegex.MatchString(strings.ToLower(parts[3])) {
+			return parts[1], strings.ToLower(parts[3]), nil
+		}
+		if len(parts) >= 2 && parts[0] == "comments" && postIDRegex.MatchString(strings.ToLower(parts[1])) {
+			return "", strings.ToLower(parts[1]), nil
+		}
+	}
+	return "", "", fmt.Errorf("not a reddit thread permalink: %q", raw)
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParsePermalink(t *testing.T) {
+	tests := []struct {
+		in, sub, id string
+	}{
+		{"https://www.reddit.com/r/netsec/comments/abc123/some_title/", "netsec", "abc123"},
+		{"https://old.reddit.com/r/netsec/comments/ABC123/some_title/def456/?context=3", "netsec", "abc123"},
+		{"reddit.com/r/Malware/comments/xyz9", "Malware", "xyz9"},
+		{"  np.reddit.com/r/netsec/comments/abc123/t  ", "netsec", "abc123"},
+		{"/r/netsec/comments/abc123/", "netsec", "abc123"},
+		{"https://www.reddit.com/comments/abc123", "", "abc123"},
+		{"https://redd.it/abc123", "", "abc123"},
+	}
+	for _, tt := range tests {
+		sub, id, err := ParsePermalink(tt.in)
+		if err != nil || sub != tt.sub || id != tt.id {
+			t.Errorf("ParsePermalink(%q) = %q, %q, %v; want %q, %q", tt.in, sub, id, err, tt.sub, tt.id)
+		}
+	}
+
+	for _, in := range []string{
+		"https://www.reddit.com/r/netsec/",
+		"https://www.reddit.com/r/netsec/comments/",
+		"https://www.reddit.com/r/netsec/comments/not-an-id!/",
+		"https://evilreddit.com/r/netsec/comments/abc123/",
+		"https://example.com/r/netsec/comments/abc123/",
+		"https://redd.it/abc/def",
+		"",
+	} {
+		if sub, id, err := ParsePermalink(in); err == nil {
+			t.Errorf("ParsePermalink(%q) = %q, %q; want an error", in, sub, id)
+		}
+	}
+}
+
+func TestDecodeCommentsFlattensTree(t *testing.T) {
+	f, err := os.Open("testdata/comments.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	comments, more, err := decodeComments(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		id    string
+		depth int
+	}{{"c1", 0}, {"c2", 1}, {"c3", 2}, {"c4", 0}}
+	if len(comments) != len(want) {
+		t.Fatalf("got %d comments, want %d", len(comments), len(want))
+	}
+	for i, w := range want {
+		c := comments[i]
+		if c.ID != w.id || c.Depth != w.depth || c.PostID != "abc123" {
+			t.Errorf("comment %d = %+v, want %s at depth %d", i, c, w.id, w.depth)
+		}
+	}
+	// Comments hidden behind "load more" stubs are counted, not fetched
+	if more != 32 {
+		t.Errorf("more = %d, want 32", more)
+	}
+	if got := capComments(comments, 2); len(got) != 2 || got[1].ID != "c2" {
+		t.Errorf("capComments(2) = %+v", got)
+	}
+}
+
+func TestDecodeListingPermalinkAndURL(t *testing.T) {
+	posts := loadListing(t, "permalinks.json")
+	if len(posts) != 2 {
+		t.Fatalf("decoded %d posts, want 2", len(posts))
+	}
+	// A link post keeps its external source in URL; Permalink is the thread
+	link := posts[0]
+	if link.Permalink != "https://reddit.com/r/netsec/comments/lnk1/new_splunk_detection_rules/" || link.URL != "https://research.example.com/splunk-rules" {
+		t.Errorf("link post Permalink %q URL %q", link.Permalink, link.URL)
+	}
+	// A self post's URL is its own thread, but Permalink is still set
+	self := posts[1]
+	if self.Permalink != "https://reddit.com/r/netsec/comments/slf1/ask_misp_feeds/" || self.URL != "https://www.reddit.com/r/netsec/comments/slf1/ask_misp_feeds/" {
+		t.Errorf("self post Permalink %q URL %q", self.Permalink, self.URL)
+	}
+}
+
+func TestThreadURL(t *testing.T) {
+	tests := map[string]string{
+		"/r/netsec/comments/abc/x/": "https://reddit.com/r/netsec/comments/abc/x/",
+		"r/netsec/comments/abc/x/":  "https://reddit.com/r/netsec/comments/abc/x/",
+		// Already absolute (e.g. from an archive) or missing: left alone
+		"https://old.reddit.com/r/netsec/comments/abc/": "https://old.reddit.com/r/netsec/comments/abc/",
+		"": "",
+	}
+	for in, want := range tests {
+		if got := threadURL(in); got != want {
+			t.Errorf("threadURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -3,83 +3,381 @@ package collector
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/qepting91/reddit-scraper/internal/domain"
 	"golang.org/x/time/rate"
 )
 
+// defaultBaseURL is where public listings are fetched from unless overridden
+const defaultBaseURL = "https://www.reddit.com"
+
 type PublicClient struct {
 	httpClient *http.Client
 	limiter    *rate.Limiter
 	userAgent  string
+	baseURL    string
+	// captureRaw keeps each post's source JSON on Post.Raw (RAW_CAPTURE)
+	captureRaw bool
+	// inflight holds a slot per request whose response body is still open.
+	// The limiter spaces request starts; this stops slow responses from
+	// piling up concurrently behind it (PUBLIC_MAX_INFLIGHT).
+	inflight chan struct{}
+	// timeout, when set, replaces the http.Client's fixed timeout with one
+	// that follows observed latencies (ADAPTIVE_TIMEOUT)
+	timeout *AdaptiveTimeout
+	// over18 sends the age-gate cookie so NSFW posts are listed where
+	// anonymous access allows it (FILTER_NSFW include/only)
+	over18 bool
 }
 
+// DefaultMaxInFlight is how many public requests may be open at once
+const DefaultMaxInFlight = 1
+
 type redditJSONResponse struct {
 	Data struct {
 		Children []struct {
-			Data struct {
-				ID          string  `json:"id"`
-				Title       string  `json:"title"`
-				Subreddit   string  `json:"subreddit_name_prefixed"`
-				Author      string  `json:"author"`
-				URL         string  `json:"url"`
-				Score       int     `json:"score"`
-				NumComments int     `json:"num_comments"`
-				CreatedUTC  float64 `json:"created_utc"`
-			} `json:"data"`
+			// Decoded in a second step so the raw bytes are available for RAW_CAPTURE
+			Data json.RawMessage `json:"data"`
 		} `json:"children"`
 	} `json:"data"`
 }
 
+// redditPostData is the subset of a listing child's fields we map onto domain.Post
+type redditPostData struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Subreddit   string  `json:"subreddit_name_prefixed"`
+	Author      string  `json:"author"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	CreatedUTC  float64 `json:"created_utc"`
+	Awards      int     `json:"total_awards_received"`
+	UpvoteRatio float64 `json:"upvote_ratio"`
+	Over18      bool    `json:"over_18"`
+	IsSelf      bool    `json:"is_self"`
+	IsVideo     bool    `json:"is_video"`
+	PostHint    string  `json:"post_hint"`
+	Selftext    string  `json:"selftext"`
+	RemovedBy   string  `json:"removed_by_category"`
+
+	Edited        editedStamp `json:"edited"`
+	Distinguished string      `json:"distinguished"`
+
+	galleryMedia
+}
+
 func NewPublicClient(userAgent string) (*PublicClient, error) {
+	hc, err := NewHTTPClient(10*time.Second, DefaultPoolOptions(), TLSOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicClientWithHTTP(userAgent, hc, "")
+}
+
+// NewPublicClientWithHTTP lets callers (mostly tests) supply their own
+// http.Client and base URL, e.g. an httptest server. An empty baseURL means
+// https://www.reddit.com.
+func NewPublicClientWithHTTP(userAgent string, httpClient *http.Client, baseURL string) (*PublicClient, error) {
+	if httpClient == nil {
+		return nil, fmt.Errorf("public client requires an http.Client")
+	}
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	// Work on a copy so the caller's client keeps following redirects
+	hc := *httpClient
+	hc.CheckRedirect = jsonRedirectsOnly
 	return &PublicClient{
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &hc,
 		// Public JSON Limit: 1 req / 2 seconds (Stricter)
 		limiter:   rate.NewLimiter(rate.Every(2*time.Second), 1),
 		userAgent: userAgent,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		inflight:  make(chan struct{}, DefaultMaxInFlight),
 	}, nil
 }
 
+// SetAdaptiveTimeout bounds each request by a, which learns from the
+// client's own latencies, instead of the http.Client's fixed Timeout. Call it
+// before the client is shared between goroutines.
+func (pc *PublicClient) SetAdaptiveTimeout(a *AdaptiveTimeout) {
+	pc.timeout = a
+	pc.httpClient.Timeout = 0
+}
+
+// SetMaxInFlight changes how many requests may be open at once. Call it
+// before the client is shared between goroutines.
+func (pc *PublicClient) SetMaxInFlight(n int) {
+	if n < 1 {
+		n = 1
+	}
+	pc.inflight = make(chan struct{}, n)
+}
+
 func (pc *PublicClient) FetchNewPosts(ctx context.Context, sub string, limit int) ([]domain.Post, error) {
+	return pc.FetchPosts(ctx, sub, "new", limit)
+}
+
+func (pc *PublicClient) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	return pc.fetchListing(ctx, "r/"+sub, fmt.Sprintf("/r/%s/%s.json", sub, listingSort(sort)), listingQuery(limit))
+}
+
+// FetchNewSince returns /new posts newer than the before fullname (the full
+// limit when before is empty) plus the cursor to pass on the next poll
+func (pc *PublicClient) FetchNewSince(ctx context.Context, sub, before string, limit int) ([]domain.Post, string, error) {
+	query := listingQuery(limit)
+	if before != "" {
+		query.Set("before", before)
+	}
+	posts, err := pc.fetchListing(ctx, "r/"+sub, fmt.Sprintf("/r/%s/new.json", sub), query)
+	if err != nil {
+		return nil, before, err
+	}
+	return posts, nextCursor(posts, before), nil
+}
+
+func (pc *PublicClient) FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]domain.Post, error) {
+	return pc.fetchListing(ctx, fmt.Sprintf("user/%s/m/%s", owner, multi), fmt.Sprintf("/user/%s/m/%s/%s.json", owner, multi, listingSort(sort)), listingQuery(limit))
+}
+
+// fetchListing GETs a listing path (e.g. "/r/netsec/new.json") and maps its children
+func (pc *PublicClient) fetchListing(ctx context.Context, target, path string, query url.Values) ([]domain.Post, error) {
 	if err := pc.limiter.Wait(ctx); err != nil {
 		return nil, err
 	}
+	fail := func(err error) error { return &FetchError{Mode: "public", Target: target, Err: err} }
+
+	resp, err := pc.get(ctx, path, query)
+	if err != nil {
+		return nil, fail(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fail(fmt.Errorf("reddit public access: %w", statusError(resp.StatusCode)))
+	}
+
+	posts, err := decodeListing(resp.Body, pc.captureRaw)
+	if err != nil {
+		return nil, fail(err)
+	}
+	return posts, nil
+}
+
+// FetchComments reads a post's comment tree (see domain.CommentFetcher)
+func (pc *PublicClient) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	comments, _, err := pc.fetchCommentTree(ctx, postID, limit)
+	return capComments(comments, limit), err
+}
+
+// FetchCommentsByURL reads the whole comment tree behind a thread permalink
+func (pc *PublicClient) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	_, postID, err := ParsePermalink(permalink)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pc.fetchCommentTree(ctx, postID, maxCommentLimit)
+}
+
+func (pc *PublicClient) fetchCommentTree(ctx context.Context, postID string, limit int) ([]domain.Comment, int, error) {
+	if err := pc.limiter.Wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	fail := func(err error) error { return &FetchError{Mode: "public", Target: "t3_" + postID, Err: err} }
 
-	url := fmt.Sprintf("https://www.reddit.com/r/%s/new.json?limit=%d", sub, limit)
-	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	resp, err := pc.get(ctx, fmt.Sprintf("/comments/%s.json", postID), commentsQuery(limit))
+	if err != nil {
+		return nil, 0, fail(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, 0, fail(fmt.Errorf("reddit public access: %w", statusError(resp.StatusCode)))
+	}
+
+	comments, more, err := decodeComments(resp.Body)
+	if err != nil {
+		return nil, 0, fail(err)
+	}
+	return comments, more, nil
+}
+
+// FetchSubredditStats reads a subreddit's size from /r/<sub>/about.json
+func (pc *PublicClient) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	if err := pc.limiter.Wait(ctx); err != nil {
+		return domain.SubredditStats{}, err
+	}
+	fail := func(err error) error { return &FetchError{Mode: "public", Target: "r/" + sub, Err: err} }
+
+	resp, err := pc.get(ctx, fmt.Sprintf("/r/%s/about.json", sub), nil)
+	if err != nil {
+		return domain.SubredditStats{}, fail(err)
+	}
+	defer resp.Body.Close()
+	stats, err := decodeAboutStats(sub, resp.StatusCode, resp.Body)
+	if err != nil {
+		return stats, fail(err)
+	}
+	return stats, nil
+}
+
+// CheckSubreddit probes /r/<sub>/about.json (see domain.Collector)
+func (pc *PublicClient) CheckSubreddit(ctx context.Context, sub string) (bool, bool, error) {
+	if err := pc.limiter.Wait(ctx); err != nil {
+		return false, false, err
+	}
+	resp, err := pc.get(ctx, fmt.Sprintf("/r/%s/about.json", sub), nil)
+	if err != nil {
+		return false, false, &FetchError{Mode: "public", Target: "r/" + sub, Err: err}
+	}
+	defer resp.Body.Close()
+	return decodeAbout(resp.StatusCode, resp.Body)
+}
+
+// get issues a GET for path on the public site; callers own the response body
+func (pc *PublicClient) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	reqURL := pc.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	select {
+	case pc.inflight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-pc.inflight }
+
+	reqCtx, cancel := ctx, context.CancelFunc(func() {})
+	var timeout time.Duration
+	if pc.timeout != nil {
+		timeout = pc.timeout.Timeout()
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	// The request's context must outlive Do until the body has been read
+	done := func() {
+		cancel()
+		release()
+	}
+
+	slog.Debug("Requesting public listing", "url", reqURL, "timeout", timeout)
+	req, _ := http.NewRequestWithContext(reqCtx, "GET", reqURL, nil)
 	req.Header.Set("User-Agent", pc.userAgent)
+	if pc.over18 {
+		req.AddCookie(&http.Cookie{Name: "over18", Value: "1"})
+	}
 
+	start := time.Now()
 	resp, err := pc.httpClient.Do(req)
+	if pc.timeout != nil && ctx.Err() == nil {
+		// A timed-out request counts as taking the whole budget
+		pc.timeout.Observe(time.Since(start))
+	}
 	if err != nil {
-		return nil, err
+		done()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrRedirected) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
 	}
-	defer resp.Body.Close()
+	// The slot is held until the caller has finished reading the body
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: done}
+	return resp, nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("reddit public access status: %d", resp.StatusCode)
+// jsonRedirectsOnly follows redirects between JSON endpoints (e.g. a
+// renamed subreddit) but stops at anything else, such as the login or
+// consent page Reddit sends blocked anonymous clients to, which would
+// otherwise come back as a 200 full of HTML
+func jsonRedirectsOnly(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !strings.HasSuffix(req.URL.Path, ".json") {
+		return fmt.Errorf("%w: %s", ErrRedirected, req.URL.Redacted())
+	}
+	return nil
+}
+
+// releasingBody frees an in-flight slot when the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+func listingQuery(limit int) url.Values {
+	return url.Values{"limit": {strconv.Itoa(limit)}}
+}
+
+// nextCursor is the fullname of the newest post in a /new listing, or the
+// previous cursor when nothing new came back
+func nextCursor(posts []domain.Post, prev string) string {
+	if len(posts) == 0 {
+		return prev
 	}
+	return posts[0].Fullname()
+}
 
+// decodeListing maps a Reddit listing JSON document onto domain.Posts. It's
+// shared by every collector that talks to the .json listing endpoints.
+func decodeListing(r io.Reader, captureRaw bool) ([]domain.Post, error) {
 	var rResp redditJSONResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rResp); err != nil {
+	if err := json.NewDecoder(r).Decode(&rResp); err != nil {
 		return nil, err
 	}
 
 	var posts []domain.Post
 	for _, child := range rResp.Data.Children {
-		d := child.Data
-		posts = append(posts, domain.Post{
-			ID:           d.ID,
-			Title:        d.Title,
-			Subreddit:    d.Subreddit,
-			Author:       d.Author,
-			URL:          d.URL,
-			Score:        d.Score,
-			CommentCount: d.NumComments,
-			CreatedUTC:   d.CreatedUTC,
-		})
+		var d redditPostData
+		if err := json.Unmarshal(child.Data, &d); err != nil {
+			return nil, err
+		}
+		post := domain.Post{
+			ID:            d.ID,
+			Title:         d.Title,
+			Subreddit:     d.Subreddit,
+			Author:        d.Author,
+			URL:           d.URL,
+			Permalink:     threadURL(d.Permalink),
+			UpvoteRatio:   d.UpvoteRatio,
+			NSFW:          d.Over18,
+			Score:         d.Score,
+			CommentCount:  d.NumComments,
+			CreatedUTC:    d.CreatedUTC,
+			Awards:        d.Awards,
+			IsSelf:        d.IsSelf,
+			Kind:          classifyKind(d.IsSelf, d.IsVideo, d.IsGallery, d.PostHint, d.URL),
+			Removed:       isRemoved(d.Author, d.Selftext, d.RemovedBy),
+			MediaURLs:     d.urls(),
+			Edited:        d.Edited.Edited,
+			EditedUTC:     d.Edited.UTC,
+			ContentHash:   contentHash(d.Title, d.Selftext, d.URL, d.IsSelf),
+			Distinguished: d.Distinguished,
+		}
+		if captureRaw {
+			post.Raw = child.Data
+		}
+		posts = append(posts, post)
 	}
 	return posts, nil
 }
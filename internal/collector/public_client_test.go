@@ -0,0 +1,284 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"golang.org/x/time/rate"
+)
+
+// listingStub serves body for every request and records the request URIs
+// and user agents
+type listingStub struct {
+	mu     sync.Mutex
+	uris   []string
+	agents []string
+	body   string
+}
+
+func (st *listingStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st.mu.Lock()
+	st.uris = append(st.uris, r.URL.RequestURI())
+	st.agents = append(st.agents, r.UserAgent())
+	st.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, st.body)
+}
+
+// newTestPublicClient points a public client at srv without rate limiting
+func newTestPublicClient(t *testing.T, srv *httptest.Server) *PublicClient {
+	t.Helper()
+	pc, err := NewPublicClientWithHTTP("test-agent", srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc.limiter = rate.NewLimiter(rate.Inf, 1)
+	return pc
+}
+
+func TestPublicClientFetch(t *testing.T) {
+	stub := &listingStub{body: `{"kind":"Listing","data":{"children":[
+{"kind":"t3","data":{"id":"p1","title":"Splunk query tips","subreddit_name_prefixed":"r/netsec","author":"a","score":120,"num_comments":4,"permalink":"/r/netsec/comments/p1/splunk/","url":"https://example.com/1","created_utc":1700000000,"is_self":false}},
+{"kind":"t3","data":{"id":"p2","title":"Ask: MISP feeds?","subreddit_name_prefixed":"r/netsec","author":"b","score":3,"permalink":"/r/netsec/comments/p2/ask/","url":"https://www.reddit.com/r/netsec/comments/p2/ask/","created_utc":1700000100,"is_self":true}}
+]}}`}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	// A trailing slash on the base URL is tolerated
+	pc, err := NewPublicClientWithHTTP("test-agent/1.0", srv.Client(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc.limiter = rate.NewLimiter(rate.Inf, 1)
+	posts, err := pc.FetchNewPosts(context.Background(), "netsec", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(stub.uris, []string{"/r/netsec/new.json?limit=25"}) || stub.agents[0] != "test-agent/1.0" {
+		t.Errorf("requested %v with agents %v", stub.uris, stub.agents)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(posts))
+	}
+	p := posts[0]
+	if p.ID != "p1" || p.Title != "Splunk query tips" || p.Subreddit != "r/netsec" || p.Author != "a" ||
+		p.Score != 120 || p.CommentCount != 4 || p.CreatedUTC != 1700000000 || p.URL != "https://example.com/1" {
+		t.Errorf("post = %+v", p)
+	}
+	if !posts[1].IsSelf || posts[1].Kind != domain.PostSelf {
+		t.Errorf("self post = %+v, want IsSelf and kind self", posts[1])
+	}
+}
+
+func TestNewPublicClientWithHTTPRequiresClient(t *testing.T) {
+	if _, err := NewPublicClientWithHTTP("agent", nil, ""); err == nil {
+		t.Error("want an error for a nil http.Client")
+	}
+	pc, err := NewPublicClientWithHTTP("agent", &http.Client{}, "")
+	if err != nil || pc.baseURL != defaultBaseURL {
+		t.Errorf("baseURL = %q (%v), want %s", pc.baseURL, err, defaultBaseURL)
+	}
+}
+
+func TestPublicClientMultiredditURL(t *testing.T) {
+	stub := &listingStub{body: emptyListing}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+	pc := newTestPublicClient(t, srv)
+
+	ctx := context.Background()
+	if _, err := pc.FetchMultiPosts(ctx, "someone", "security", "hot", 50); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pc.FetchMultiPosts(ctx, "someone", "security", "", 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pc.FetchPosts(ctx, "netsec", "new", 25); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"/user/someone/m/security/hot.json?limit=50",
+		"/user/someone/m/security/new.json?limit=10",
+		"/r/netsec/new.json?limit=25",
+	}
+	if !slices.Equal(stub.uris, want) {
+		t.Errorf("requested %v, want %v", stub.uris, want)
+	}
+}
+
+func TestPublicClientCapturesRawSourceBytes(t *testing.T) {
+	// Unusual spacing, escapes and unmapped fields must all survive untouched
+	child := `{"id":"r1","title":"Tom & Jerry <3","subreddit":"netsec","score":7,  "unmapped":{"nested":[1,2.50,"é"]}}`
+	stub := &listingStub{body: `{"kind":"Listing","data":{"children":[{"kind":"t3","data":` + child + `}]}}`}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	pc := newTestPublicClient(t, srv)
+	posts, err := pc.FetchPosts(context.Background(), "netsec", "new", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts[0].Raw != nil {
+		t.Errorf("Raw = %s with RAW_CAPTURE off, want nil", posts[0].Raw)
+	}
+
+	pc.captureRaw = true
+	posts, err = pc.FetchPosts(context.Background(), "netsec", "new", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(posts[0].Raw) != child {
+		t.Errorf("Raw = %s, want the source bytes %s", posts[0].Raw, child)
+	}
+	if posts[0].Title != "Tom & Jerry <3" {
+		t.Errorf("Title = %q, want the decoded title", posts[0].Title)
+	}
+}
+
+func TestPublicClientFetchNewSinceAdvancesCursor(t *testing.T) {
+	// Newest first, like /new: p3 arrives after the first poll
+	var mu sync.Mutex
+	var befores []string
+	listing := map[string]string{
+		"":      `{"data":{"children":[{"data":{"id":"p2"}},{"data":{"id":"p1"}}]}}`,
+		"t3_p2": `{"data":{"children":[{"data":{"id":"p3"}}]}}`,
+		"t3_p3": emptyListing,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		before := r.URL.Query().Get("before")
+		mu.Lock()
+		befores = append(befores, before)
+		mu.Unlock()
+		io.WriteString(w, listing[before])
+	}))
+	defer srv.Close()
+	pc := newTestPublicClient(t, srv)
+	ctx := context.Background()
+
+	// The first poll has no cursor and gets the full limit
+	posts, cursor, err := pc.FetchNewSince(ctx, "netsec", "", 25)
+	if err != nil || len(posts) != 2 || cursor != "t3_p2" {
+		t.Fatalf("first poll: %d posts, cursor %q, %v; want 2 and t3_p2", len(posts), cursor, err)
+	}
+	posts, cursor, err = pc.FetchNewSince(ctx, "netsec", cursor, 25)
+	if err != nil || len(posts) != 1 || posts[0].ID != "p3" || cursor != "t3_p3" {
+		t.Fatalf("second poll: %v, cursor %q, %v; want p3 and t3_p3", posts, cursor, err)
+	}
+	// Nothing new keeps the cursor where it was
+	posts, cursor, err = pc.FetchNewSince(ctx, "netsec", cursor, 25)
+	if err != nil || len(posts) != 0 || cursor != "t3_p3" {
+		t.Errorf("empty poll: %d posts, cursor %q, %v; want none and t3_p3", len(posts), cursor, err)
+	}
+	if want := []string{"", "t3_p2", "t3_p3"}; !slices.Equal(befores, want) {
+		t.Errorf("before params %q, want %q", befores, want)
+	}
+
+	// A failed poll hands back the cursor it was given
+	srv.Close()
+	if _, cursor, err := pc.FetchNewSince(ctx, "netsec", "t3_p3", 25); err == nil || cursor != "t3_p3" {
+		t.Errorf("failed poll: cursor %q, %v; want t3_p3 and an error", cursor, err)
+	}
+}
+
+func TestDecodeAwards(t *testing.T) {
+	posts := loadListing(t, "awards.json")
+	want := map[string]int{"a1": 14, "a2": 0, "a3": 0}
+	for _, p := range posts {
+		if p.Awards != want[p.ID] {
+			t.Errorf("%s Awards = %d, want %d", p.ID, p.Awards, want[p.ID])
+		}
+	}
+
+	// Stored records only carry the field when there were awards
+	for _, p := range posts {
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has := strings.Contains(string(data), `"awards":`); has != (p.Awards > 0) {
+			t.Errorf("%s encoded %s", p.ID, data)
+		}
+	}
+}
+
+func TestPublicClientMaxInFlight(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		var cur, peak atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := cur.Add(1)
+			defer cur.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			io.WriteString(w, emptyListing)
+		}))
+		pc := newTestPublicClient(t, srv)
+		pc.SetMaxInFlight(limit)
+
+		var wg sync.WaitGroup
+		for range 12 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := pc.FetchNewPosts(context.Background(), "netsec", 5); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		srv.Close()
+
+		if got := peak.Load(); got > int32(limit) || got == 0 {
+			t.Errorf("limit %d: peak in-flight = %d", limit, got)
+		}
+	}
+}
+
+func TestPublicClientRedirectToLogin(t *testing.T) {
+	mux := http.NewServeMux()
+	// Blocked anonymous clients are bounced to a login page
+	mux.HandleFunc("/r/netsec/new.json", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login/?dest=%2Fr%2Fnetsec%2Fnew.json", http.StatusFound)
+	})
+	mux.HandleFunc("/login/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html>log in</html>")
+	})
+	// A renamed subreddit redirects to another listing, which is followed
+	mux.HandleFunc("/r/oldname/new.json", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/r/newname/new.json?limit=5", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/r/newname/new.json", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, emptyListing)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	pc := newTestPublicClient(t, srv)
+
+	_, err := pc.FetchNewPosts(context.Background(), "netsec", 5)
+	if !errors.Is(err, ErrRedirected) || errors.Is(err, ErrNetwork) {
+		t.Fatalf("err = %v, want ErrRedirected", err)
+	}
+	if cause := Cause(err); cause != "redirected" {
+		t.Errorf("Cause = %q, want redirected", cause)
+	}
+	if _, err := pc.FetchNewPosts(context.Background(), "oldname", 5); err != nil {
+		t.Errorf("JSON to JSON redirect: %v", err)
+	}
+}
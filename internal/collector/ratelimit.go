@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// rateRemaining holds the float64 bits of the x-ratelimit-remaining header
+// on the latest Reddit response, shared by every client in the process;
+// NaN until one has been seen
+var rateRemaining atomic.Uint64
+
+func init() { rateRemaining.Store(math.Float64bits(math.NaN())) }
+
+// RateLimitRemaining is how many requests Reddit last said were left in its
+// current window. ok is false until a response carried the header (mock and
+// file modes never do).
+func RateLimitRemaining() (remaining float64, ok bool) {
+	v := math.Float64frombits(rateRemaining.Load())
+	return v, !math.IsNaN(v)
+}
+
+// rateLimitTransport notes the x-ratelimit-remaining header of each response
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+func (t rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		if v, perr := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Remaining"), 64); perr == nil && !math.IsNaN(v) {
+			rateRemaining.Store(math.Float64bits(v))
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// RecordingCollector passes every call through to Collector and saves each
+// listing it returns under Dir (RECORD_DIR), one file per subreddit or multi
+// and sort, so a FileCollector can replay them later. Combined listings,
+// comments and subreddit stats are forwarded and recorded too, so wrapping
+// doesn't switch off COMBINE_SUBREDDITS, COMMENT_ENRICH or SUBREDDIT_STATS.
+// A newer response for the same listing replaces the older one. Saving is
+// best effort: a failed write is logged and the posts are still returned.
+type RecordingCollector struct {
+	domain.Collector
+	Dir string
+}
+
+// NewRecordingCollector wraps c, creating dir if needed
+func NewRecordingCollector(c domain.Collector, dir string) (*RecordingCollector, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &RecordingCollector{Collector: c, Dir: dir}, nil
+}
+
+func (rc *RecordingCollector) FetchNewPosts(ctx context.Context, sub string, limit int) ([]domain.Post, error) {
+	posts, err := rc.Collector.FetchNewPosts(ctx, sub, limit)
+	if err == nil {
+		rc.save(subredditRecording(sub, "new"), posts)
+	}
+	return posts, err
+}
+
+func (rc *RecordingCollector) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	posts, err := rc.Collector.FetchPosts(ctx, sub, sort, limit)
+	if err == nil {
+		rc.save(subredditRecording(sub, sort), posts)
+	}
+	return posts, err
+}
+
+func (rc *RecordingCollector) FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]domain.Post, error) {
+	posts, err := rc.Collector.FetchMultiPosts(ctx, owner, multi, sort, limit)
+	if err == nil {
+		rc.save(multiRecording(owner, multi, sort), posts)
+	}
+	return posts, err
+}
+
+// FetchNewSince records only polls that returned something, so a quiet
+// poll doesn't replace the last page with an empty one
+func (rc *RecordingCollector) FetchNewSince(ctx context.Context, sub, before string, limit int) ([]domain.Post, string, error) {
+	posts, cursor, err := rc.Collector.FetchNewSince(ctx, sub, before, limit)
+	if err == nil && len(posts) > 0 {
+		rc.save(subredditRecording(sub, "new"), posts)
+	}
+	return posts, cursor, err
+}
+
+// FetchCombined records each subreddit's share of the combined listing as
+// its /new listing, which a FileCollector replays per subreddit. A subreddit
+// crowded out of the listing keeps its last recording.
+func (rc *RecordingCollector) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	cf, ok := rc.Collector.(domain.CombinedFetcher)
+	if !ok {
+		return nil, fmt.Errorf("combined listings: %w", errors.ErrUnsupported)
+	}
+	posts, err := cf.FetchCombined(ctx, subs, limit)
+	if err != nil {
+		return posts, err
+	}
+	bySub := make(map[string][]domain.Post, len(subs))
+	for _, p := range posts {
+		key := domain.SubredditKey(p.Subreddit)
+		bySub[key] = append(bySub[key], p)
+	}
+	for _, sub := range subs {
+		if share := bySub[domain.SubredditKey(sub)]; len(share) > 0 {
+			rc.save(subredditRecording(sub, "new"), share)
+		}
+	}
+	return posts, nil
+}
+
+// recordedComments is a comment tree as commentsRecording stores it
+type recordedComments struct {
+	Comments  []domain.Comment `json:"comments"`
+	Truncated int              `json:"truncated,omitempty"`
+}
+
+func (rc *RecordingCollector) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	cf, ok := rc.Collector.(domain.CommentFetcher)
+	if !ok {
+		return nil, fmt.Errorf("comments: %w", errors.ErrUnsupported)
+	}
+	comments, err := cf.FetchComments(ctx, postID, limit)
+	if err == nil {
+		rc.write(commentsRecording(postID), recordedComments{Comments: comments})
+	}
+	return comments, err
+}
+
+func (rc *RecordingCollector) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	cf, ok := rc.Collector.(domain.CommentFetcher)
+	if !ok {
+		return nil, 0, fmt.Errorf("comments: %w", errors.ErrUnsupported)
+	}
+	comments, truncated, err := cf.FetchCommentsByURL(ctx, permalink)
+	if err == nil {
+		if _, postID, perr := ParsePermalink(permalink); perr == nil {
+			rc.write(commentsRecording(postID), recordedComments{Comments: comments, Truncated: truncated})
+		}
+	}
+	return comments, truncated, err
+}
+
+func (rc *RecordingCollector) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	sf, ok := rc.Collector.(domain.StatsFetcher)
+	if !ok {
+		return domain.SubredditStats{}, fmt.Errorf("subreddit stats: %w", errors.ErrUnsupported)
+	}
+	stats, err := sf.FetchSubredditStats(ctx, sub)
+	if err == nil {
+		rc.write(statsRecording(sub), stats)
+	}
+	return stats, err
+}
+
+// save writes posts as a JSON array (see write). Raw is kept byte for byte.
+func (rc *RecordingCollector) save(name string, posts []domain.Post) {
+	if posts == nil {
+		posts = []domain.Post{}
+	}
+	rc.write(name, posts)
+}
+
+// write saves v as JSON through a temp file and a rename, so a replay never
+// reads half a recording
+func (rc *RecordingCollector) write(name string, v any) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		slog.Warn("Recording response failed", "file", name, "err", err)
+		return
+	}
+	path := filepath.Join(rc.Dir, name)
+	tmp, err := os.CreateTemp(rc.Dir, name+".tmp-*")
+	if err != nil {
+		slog.Warn("Recording response failed", "file", name, "err", err)
+		return
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	_, err = tmp.Write(buf.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		slog.Warn("Recording response failed", "file", name, "err", err)
+	}
+}
+
+// subredditRecording is the file a subreddit listing is recorded in,
+// e.g. r_netsec_new.json
+func subredditRecording(sub, sort string) string {
+	return "r_" + recordingName(domain.SubredditKey(sub)) + "_" + recordingName(sort) + ".json"
+}
+
+// multiRecording is the file a multireddit listing is recorded in,
+// e.g. m_someuser_security_hot.json
+func multiRecording(owner, multi, sort string) string {
+	return "m_" + recordingName(strings.ToLower(owner)) + "_" + recordingName(strings.ToLower(multi)) + "_" + recordingName(sort) + ".json"
+}
+
+// commentsRecording is the file a post's comments are recorded in,
+// e.g. c_abc123.json
+func commentsRecording(postID string) string {
+	return "c_" + recordingName(postID) + ".json"
+}
+
+// statsRecording is the file a subreddit's about stats are recorded in,
+// e.g. about_netsec.json
+func statsRecording(sub string) string {
+	return "about_" + recordingName(domain.SubredditKey(sub)) + ".json"
+}
+
+// recordingName keeps a name part safe for a file name. Reddit names are
+// already letters, digits, - and _, so this only matters for odd input.
+func recordingName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// cannedCollector returns fixed listings, comments and stats
+type cannedCollector struct {
+	MockClient
+	posts []domain.Post
+}
+
+func (c *cannedCollector) FetchPosts(ctx context.Context, sub, sort string, limit int) ([]domain.Post, error) {
+	return c.posts, nil
+}
+
+func (c *cannedCollector) FetchCombined(ctx context.Context, subs []string, limit int) ([]domain.Post, error) {
+	return c.posts, nil
+}
+
+func (c *cannedCollector) FetchComments(ctx context.Context, postID string, limit int) ([]domain.Comment, error) {
+	return []domain.Comment{{ID: "c1", Body: "first <b>"}, {ID: "c2", Body: "second"}}, nil
+}
+
+func (c *cannedCollector) FetchCommentsByURL(ctx context.Context, permalink string) ([]domain.Comment, int, error) {
+	comments, _ := c.FetchComments(ctx, "", 0)
+	return comments, 3, nil
+}
+
+func (c *cannedCollector) FetchSubredditStats(ctx context.Context, sub string) (domain.SubredditStats, error) {
+	return domain.SubredditStats{Subreddit: sub, Subscribers: 1200, ActiveUsers: 40}, nil
+}
+
+func TestRecordingReplaysIdenticalPosts(t *testing.T) {
+	dir := t.TempDir()
+	live := []domain.Post{
+		{ID: "a", Title: "Splunk & <ELK>", Subreddit: "netsec", Score: 12, CreatedUTC: 200, Raw: json.RawMessage(`{"id":"a","title":"Splunk & <ELK>"}`)},
+		{ID: "b", Title: "MISP feeds", Subreddit: "netsec", Score: 3, CreatedUTC: 100},
+	}
+	rc, err := NewRecordingCollector(&cannedCollector{posts: live}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rc.FetchPosts(context.Background(), "netsec", "hot", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := NewFileCollector(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := fc.FetchPosts(context.Background(), "netsec", "hot", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed, got) {
+		t.Errorf("replayed %+v, want %+v", replayed, got)
+	}
+	if _, err := fc.FetchPosts(context.Background(), "netsec", "new", 25); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unrecorded listing: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRecordingKeepsOptionalCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	live := []domain.Post{
+		{ID: "a", Subreddit: "netsec", CreatedUTC: 300},
+		{ID: "b", Subreddit: "Malware", CreatedUTC: 200},
+		{ID: "c", Subreddit: "netsec", CreatedUTC: 100},
+	}
+	rc, err := NewRecordingCollector(&cannedCollector{posts: live}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var client domain.Collector = rc
+	cf, ok := client.(domain.CombinedFetcher)
+	if !ok {
+		t.Fatal("recording collector hides CombinedFetcher")
+	}
+	comments, ok := client.(domain.CommentFetcher)
+	if !ok {
+		t.Fatal("recording collector hides CommentFetcher")
+	}
+	sf, ok := client.(domain.StatsFetcher)
+	if !ok {
+		t.Fatal("recording collector hides StatsFetcher")
+	}
+
+	ctx := context.Background()
+	if _, err := cf.FetchCombined(ctx, []string{"netsec", "malware", "blueteam"}, 100); err != nil {
+		t.Fatal(err)
+	}
+	wantComments, err := comments.FetchComments(ctx, "abc123", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStats, err := sf.FetchSubredditStats(ctx, "netsec")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := NewFileCollector(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The combined listing is saved per subreddit and replays either way
+	netsec, err := fc.FetchPosts(ctx, "netsec", "new", 25)
+	if err != nil || len(netsec) != 2 {
+		t.Errorf("netsec share = %v (%v), want a and c", netsec, err)
+	}
+	merged, err := fc.FetchCombined(ctx, []string{"netsec", "malware", "blueteam"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range merged {
+		ids = append(ids, p.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+		t.Errorf("replayed combined listing = %v, want a b c", ids)
+	}
+
+	gotComments, err := fc.FetchComments(ctx, "abc123", 1)
+	if err != nil || !reflect.DeepEqual(gotComments, wantComments[:1]) {
+		t.Errorf("replayed comments = %+v (%v), want %+v", gotComments, err, wantComments[:1])
+	}
+	gotStats, err := fc.FetchSubredditStats(ctx, "NetSec")
+	if err != nil || gotStats != wantStats {
+		t.Errorf("replayed stats = %+v (%v), want %+v", gotStats, err, wantStats)
+	}
+}
+
+func TestRecordingReportsUnsupportedCalls(t *testing.T) {
+	// Embedding the interface hides every optional method of the mock
+	bare := struct{ domain.Collector }{&MockClient{}}
+	rc, err := NewRecordingCollector(bare, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.FetchCombined(context.Background(), []string{"a", "b"}, 10); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("FetchCombined err = %v, want ErrUnsupported", err)
+	}
+	if _, err := rc.FetchSubredditStats(context.Background(), "a"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("FetchSubredditStats err = %v, want ErrUnsupported", err)
+	}
+}
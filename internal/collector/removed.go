@@ -0,0 +1,16 @@
+package collector
+
+// isRemoved spots posts Reddit still lists after a moderator removed them or
+// the author deleted them. removedBy is removed_by_category, which only the
+// JSON listings expose.
+func isRemoved(author, selftext, removedBy string) bool {
+	switch {
+	case removedBy != "":
+		return true
+	case author == "[deleted]":
+		return true
+	case selftext == "[removed]" || selftext == "[deleted]":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package collector
+
+import "testing"
+
+func TestDecodeListingMarksRemovedPosts(t *testing.T) {
+	want := map[string]bool{
+		"live":     false,
+		"modded":   true, // "[removed]" selftext
+		"gone":     true, // "[deleted]" author and selftext
+		"link":     true, // "[deleted]" author on a link post
+		"category": true, // removed_by_category set, text already blanked
+	}
+	posts := loadListing(t, "removed.json")
+	if len(posts) != len(want) {
+		t.Fatalf("decoded %d posts, want %d", len(posts), len(want))
+	}
+	for _, p := range posts {
+		if p.Removed != want[p.ID] {
+			t.Errorf("post %s Removed = %v, want %v", p.ID, p.Removed, want[p.ID])
+		}
+	}
+}
+
+func TestIsRemoved(t *testing.T) {
+	tests := []struct {
+		author, selftext, removedBy string
+		want                        bool
+	}{
+		{"alice", "text", "", false},
+		{"alice", "", "", false},
+		{"[deleted]", "", "", true},
+		{"alice", "[removed]", "", true},
+		{"alice", "[deleted]", "", true},
+		{"alice", "", "deleted", true},
+		// Only the exact markers count
+		{"alice", "This was [removed] by the mods", "", false},
+	}
+	for _, tt := range tests {
+		if got := isRemoved(tt.author, tt.selftext, tt.removedBy); got != tt.want {
+			t.Errorf("isRemoved(%q, %q, %q) = %v, want %v", tt.author, tt.selftext, tt.removedBy, got, tt.want)
+		}
+	}
+}
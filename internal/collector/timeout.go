@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Adaptive timeout defaults (ADAPTIVE_TIMEOUT)
+const (
+	DefaultTimeoutMin = 2 * time.Second
+	DefaultTimeoutMax = 30 * time.Second
+	// latencyWindow is how many recent requests the p95 is taken over
+	latencyWindow = 50
+	// minLatencySamples is how many requests are needed before adapting
+	minLatencySamples = 5
+	// timeoutHeadroom multiplies the p95 so ordinary variance still fits
+	timeoutHeadroom = 2
+)
+
+// AdaptiveTimeout derives a request timeout from recent latencies: twice the
+// p95 of the last requests, clamped to [Min, Max]. Until enough requests have
+// been seen it uses Base. A request that timed out counts as taking the full
+// timeout, so a slow spell widens the budget instead of failing every request.
+type AdaptiveTimeout struct {
+	Base, Min, Max time.Duration
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// NewAdaptiveTimeout returns an AdaptiveTimeout starting at base, clamped to [min, max]
+func NewAdaptiveTimeout(base, min, max time.Duration) *AdaptiveTimeout {
+	if max < min {
+		max = min
+	}
+	return &AdaptiveTimeout{Base: base, Min: min, Max: max}
+}
+
+// Timeout is the budget for the next request
+func (a *AdaptiveTimeout) Timeout() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.samples) < minLatencySamples {
+		return a.clamp(a.Base)
+	}
+	sorted := slices.Clone(a.samples)
+	slices.Sort(sorted)
+	p95 := sorted[(len(sorted)*95+99)/100-1]
+	return a.clamp(p95 * timeoutHeadroom)
+}
+
+// Observe records how long a request took to answer
+func (a *AdaptiveTimeout) Observe(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.samples) < latencyWindow {
+		a.samples = append(a.samples, d)
+		return
+	}
+	a.samples[a.next] = d
+	a.next = (a.next + 1) % latencyWindow
+}
+
+func (a *AdaptiveTimeout) clamp(d time.Duration) time.Duration {
+	return min(max(d, a.Min), a.Max)
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTimeoutStaysWithinBounds(t *testing.T) {
+	a := NewAdaptiveTimeout(10*time.Second, 2*time.Second, 30*time.Second)
+	// Base until enough requests have been seen
+	if got := a.Timeout(); got != 10*time.Second {
+		t.Errorf("initial timeout = %v, want the base", got)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	phases := []struct {
+		name     string
+		min, max time.Duration
+	}{
+		{"fast", 10 * time.Millisecond, 200 * time.Millisecond},
+		{"slow", 5 * time.Second, 25 * time.Second},
+		{"timeouts", 30 * time.Second, 60 * time.Second},
+		{"recovered", 100 * time.Millisecond, 800 * time.Millisecond},
+	}
+	for _, ph := range phases {
+		for range 2 * latencyWindow {
+			a.Observe(ph.min + time.Duration(rng.Int64N(int64(ph.max-ph.min))))
+			if got := a.Timeout(); got < a.Min || got > a.Max {
+				t.Fatalf("%s: timeout %v outside [%v, %v]", ph.name, got, a.Min, a.Max)
+			}
+		}
+	}
+
+	// Once the window holds only fast requests the budget shrinks to the floor
+	if got := a.Timeout(); got != 2*time.Second {
+		t.Errorf("after recovering timeout = %v, want the 2s floor", got)
+	}
+	// A slow spell widens it, capped at Max
+	for range latencyWindow {
+		a.Observe(8 * time.Second)
+	}
+	if got := a.Timeout(); got != 16*time.Second {
+		t.Errorf("after 8s requests timeout = %v, want twice the p95", got)
+	}
+	for range latencyWindow {
+		a.Observe(time.Minute)
+	}
+	if got := a.Timeout(); got != 30*time.Second {
+		t.Errorf("after timeouts timeout = %v, want the 30s cap", got)
+	}
+}
+
+func TestNewAdaptiveTimeoutFixesInvertedBounds(t *testing.T) {
+	a := NewAdaptiveTimeout(time.Second, 5*time.Second, time.Second)
+	if a.Max != 5*time.Second || a.Timeout() != 5*time.Second {
+		t.Errorf("bounds = [%v, %v], timeout %v; want max raised to min", a.Min, a.Max, a.Timeout())
+	}
+}
+
+func TestPublicClientAdaptiveTimeoutCutsSlowRequests(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	pc := newTestPublicClient(t, srv)
+	a := NewAdaptiveTimeout(50*time.Millisecond, 10*time.Millisecond, 100*time.Millisecond)
+	pc.SetAdaptiveTimeout(a)
+
+	_, err := pc.FetchNewPosts(context.Background(), "netsec", 5)
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("err = %v, want a network timeout", err)
+	}
+	if len(a.samples) != 1 || a.samples[0] < 50*time.Millisecond {
+		t.Errorf("samples = %v, want the timed-out request recorded at its full budget", a.samples)
+	}
+}
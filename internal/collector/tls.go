@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// TLSOptions adjusts certificate verification for networks whose proxy
+// re-signs TLS traffic. CAFile (TLS_CA_FILE) trusts an extra CA bundle on top
+// of the system pool and is the safe choice; InsecureSkipVerify
+// (INSECURE_SKIP_VERIFY) turns verification off entirely and exposes
+// credentials to anyone on the path. Neither is ever on by default.
+type TLSOptions struct {
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+func tlsOptionsFromEnv() TLSOptions {
+	return TLSOptions{
+		CAFile:             os.Getenv("TLS_CA_FILE"),
+		InsecureSkipVerify: os.Getenv("INSECURE_SKIP_VERIFY") == "true",
+	}
+}
+
+// config builds the tls.Config for o, or nil when o changes nothing
+func (o TLSOptions) config() (*tls.Config, error) {
+	if o.CAFile == "" && !o.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading TLS_CA_FILE: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS_CA_FILE %s contains no PEM certificates", o.CAFile)
+		}
+		cfg.RootCAs = pool
+		if o.InsecureSkipVerify {
+			slog.Warn("Both TLS_CA_FILE and INSECURE_SKIP_VERIFY are set; verifying against the CA file and ignoring INSECURE_SKIP_VERIFY")
+		}
+	} else {
+		slog.Warn("INSECURE_SKIP_VERIFY=true: TLS certificates are NOT verified. Anyone between you and Reddit can read and alter traffic, including credentials. Prefer TLS_CA_FILE")
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to name in a temp dir and returns its path
+func writeFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTLSOptionsAgainstSelfSignedServer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caFile := writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	tests := []struct {
+		name string
+		opts TLSOptions
+		ok   bool
+	}{
+		// Never verified-off by default: the unknown CA is rejected
+		{"default", TLSOptions{}, false},
+		{"custom CA", TLSOptions{CAFile: caFile}, true},
+		{"skip verify", TLSOptions{InsecureSkipVerify: true}, true},
+		// The CA file wins over skipping verification
+		{"both", TLSOptions{CAFile: caFile, InsecureSkipVerify: true}, true},
+	}
+	for _, tt := range tests {
+		hc, err := NewHTTPClient(5*time.Second, DefaultPoolOptions(), tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp, err := hc.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestTLSOptionsRejectBadCAFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	empty := writeFile(t, "empty.pem", []byte("not a certificate\n"))
+	for _, path := range []string{missing, empty} {
+		_, err := NewHTTPClient(time.Second, DefaultPoolOptions(), TLSOptions{CAFile: path})
+		if err == nil || !strings.Contains(err.Error(), "TLS_CA_FILE") {
+			t.Errorf("CAFile %s: err = %v, want a TLS_CA_FILE error", path, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Connection pool defaults. Every request goes to one host (reddit.com or
+// oauth.reddit.com), so the per-host idle limit is what matters: Go's default
+// of 2 makes a larger worker pool close and redial connections constantly.
+const (
+	DefaultMaxIdleConns        = 32
+	DefaultMaxIdleConnsPerHost = 16
+	// Long enough to bridge the limiter's gaps between requests, short
+	// enough that sockets don't sit open across idle stretches between cycles
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// PoolOptions tunes connection reuse (HTTP_MAX_IDLE_CONNS,
+// HTTP_MAX_IDLE_CONNS_PER_HOST, HTTP_IDLE_CONN_TIMEOUT)
+type PoolOptions struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// DefaultPoolOptions returns the pool defaults above
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+	}
+}
+
+// poolOptionsFromEnv overrides the defaults, warning about and ignoring
+// invalid values
+func poolOptionsFromEnv() PoolOptions {
+	o := DefaultPoolOptions()
+	envInt := func(key string, dst *int) {
+		if env := os.Getenv(key); env != "" {
+			if n, err := strconv.Atoi(env); err == nil && n > 0 {
+				*dst = n
+			} else {
+				slog.Warn("Invalid "+key+" (must be > 0), using default", "val", env, "default", *dst)
+			}
+		}
+	}
+	envInt("HTTP_MAX_IDLE_CONNS", &o.MaxIdleConns)
+	envInt("HTTP_MAX_IDLE_CONNS_PER_HOST", &o.MaxIdleConnsPerHost)
+	if env := os.Getenv("HTTP_IDLE_CONN_TIMEOUT"); env != "" {
+		if d, err := time.ParseDuration(env); err == nil && d > 0 {
+			o.IdleConnTimeout = d
+		} else {
+			slog.Warn("Invalid HTTP_IDLE_CONN_TIMEOUT (e.g. 90s), using default", "val", env, "default", o.IdleConnTimeout)
+		}
+	}
+	return o
+}
+
+// NewHTTPClient returns a client with its own transport, cloned from
+// http.DefaultTransport (proxy settings, dial timeouts) and adjusted by pool
+// and tlsOpts. Responses' rate-limit headers feed RateLimitRemaining.
+func NewHTTPClient(timeout time.Duration, pool PoolOptions, tlsOpts TLSOptions) (*http.Client, error) {
+	tlsConfig, err := tlsOpts.config()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = pool.MaxIdleConns
+	transport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = pool.IdleConnTimeout
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Timeout: timeout, Transport: rateLimitTransport{next: transport}}, nil
+}
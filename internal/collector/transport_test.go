@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// transportOf unwraps the *http.Transport under a client from NewHTTPClient
+func transportOf(t *testing.T, hc *http.Client) *http.Transport {
+	t.Helper()
+	rl, ok := hc.Transport.(rateLimitTransport)
+	if !ok {
+		t.Fatalf("transport is %T, want rateLimitTransport", hc.Transport)
+	}
+	tr, ok := rl.next.(*http.Transport)
+	if !ok {
+		t.Fatalf("wrapped transport is %T, want *http.Transport", rl.next)
+	}
+	return tr
+}
+
+func TestNewHTTPClientPoolOptions(t *testing.T) {
+	pool := PoolOptions{MaxIdleConns: 64, MaxIdleConnsPerHost: 24, IdleConnTimeout: 45 * time.Second}
+	hc, err := NewHTTPClient(7*time.Second, pool, TLSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := transportOf(t, hc)
+	if tr.MaxIdleConns != 64 || tr.MaxIdleConnsPerHost != 24 || tr.IdleConnTimeout != 45*time.Second {
+		t.Errorf("transport pool = %d/%d/%v, want 64/24/45s", tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+	}
+	if hc.Timeout != 7*time.Second {
+		t.Errorf("client timeout = %v, want 7s", hc.Timeout)
+	}
+	// Cloned, so the process-wide default transport is untouched
+	if tr == http.DefaultTransport || http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost == 24 {
+		t.Error("NewHTTPClient modified http.DefaultTransport")
+	}
+	if tr.Proxy == nil {
+		t.Error("proxy settings from the default transport were lost")
+	}
+}
+
+func TestPoolOptionsFromEnv(t *testing.T) {
+	t.Setenv("HTTP_MAX_IDLE_CONNS", "100")
+	t.Setenv("HTTP_MAX_IDLE_CONNS_PER_HOST", "0")
+	t.Setenv("HTTP_IDLE_CONN_TIMEOUT", "2m")
+	o := poolOptionsFromEnv()
+	// Invalid values fall back to the defaults
+	want := PoolOptions{MaxIdleConns: 100, MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost, IdleConnTimeout: 2 * time.Minute}
+	if o != want {
+		t.Errorf("poolOptionsFromEnv = %+v, want %+v", o, want)
+	}
+
+	t.Setenv("HTTP_IDLE_CONN_TIMEOUT", "soon")
+	if o := poolOptionsFromEnv(); o.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %v, want the default", o.IdleConnTimeout)
+	}
+}
@@ -0,0 +1,42 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// The chart scripts are vendored into assets/ from the go-echarts CDN so the
+// dashboard works without internet access (LOCAL_ASSETS). Re-run this after
+// upgrading go-echarts.
+//go:generate sh -c "mkdir -p assets/themes && curl -sfL -o assets/echarts.min.js https://go-echarts.github.io/go-echarts-assets/assets/echarts.min.js && for t in chalk essos infographic macarons purple-passion roma romantic shine vintage walden westeros wonderland; do curl -sfL -o assets/themes/$t.js https://go-echarts.github.io/go-echarts-assets/assets/themes/$t.js || exit 1; done"
+
+//go:embed assets
+var embedded embed.FS
+
+// cdnAssets is where the chart scripts are loaded from without LocalAssets
+const cdnAssets = "https://go-echarts.github.io/go-echarts-assets/assets/"
+
+// assetFS is the embedded assets directory, served under /assets/
+func assetFS() fs.FS {
+	sub, _ := fs.Sub(embedded, "assets")
+	return sub
+}
+
+// HasLocalAssets reports whether the embedded assets include echarts and the
+// given theme's script, i.e. whether LocalAssets can render charts offline
+func HasLocalAssets(theme string) bool {
+	if theme == "" {
+		theme = defaultTheme
+	}
+	for _, name := range []string{"echarts.min.js", "themes/" + theme + ".js"} {
+		if info, err := fs.Stat(assetFS(), name); err != nil || info.Size() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func assetHandler() http.Handler {
+	return http.StripPrefix("/assets/", http.FileServerFS(assetFS()))
+}
@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is used when Server.CacheTTL is zero
+const DefaultCacheTTL = 5 * time.Second
+
+// postCache keeps the parsed, score-sorted data file so busy dashboards don't
+// re-read it on every request. Within ttl the cached posts are served as is;
+// after that the file is stat'ed and only re-read if its mtime or size changed.
+// Concurrent requests that need a read share one, cached or not.
+type postCache struct {
+	mu      sync.Mutex
+	path    string
+	posts   []domain.Post
+	mod     time.Time
+	size    int64
+	checked time.Time
+
+	// reads collapses concurrent loads of the same file version into one
+	reads singleflight.Group
+	// loader reads and sorts the file (nil = loadData)
+	loader func(path string) []domain.Post
+}
+
+// load returns the posts in path, sorted by score. Callers get their own
+// copy of the slice and may reorder it.
+func (c *postCache) load(path string, ttl time.Duration) []domain.Post {
+	if ttl < 0 {
+		mod, size := fileVersion(path)
+		return slices.Clone(c.read(path, mod, size))
+	}
+	c.mu.Lock()
+	now := time.Now()
+	if c.path == path && !c.checked.IsZero() && now.Sub(c.checked) < ttl {
+		defer c.mu.Unlock()
+		return slices.Clone(c.posts)
+	}
+	mod, size := fileVersion(path)
+	if c.path == path && !c.checked.IsZero() && mod.Equal(c.mod) && size == c.size {
+		defer c.mu.Unlock()
+		c.checked = now
+		return slices.Clone(c.posts)
+	}
+	// Read without holding mu so requests served from the cache don't queue
+	// behind the file; concurrent misses wait on the same read instead
+	c.mu.Unlock()
+	posts := c.read(path, mod, size)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.path, c.mod, c.size, c.posts, c.checked = path, mod, size, posts, now
+	return slices.Clone(posts)
+}
+
+// read loads path once for every concurrent caller asking for the same
+// version of it. The result is shared, so callers must not modify it.
+func (c *postCache) read(path string, mod time.Time, size int64) []domain.Post {
+	key := fmt.Sprintf("%s|%d|%d", path, mod.UnixNano(), size)
+	v, _, _ := c.reads.Do(key, func() (any, error) {
+		if c.loader != nil {
+			return c.loader(path), nil
+		}
+		return loadData(path), nil
+	})
+	return v.([]domain.Post)
+}
+
+// fileVersion is path's mtime and size, zero when it can't be stat'ed
+func fileVersion(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
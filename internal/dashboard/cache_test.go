@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// swapDataFile overwrites path with a file holding post id, keeping its
+// mtime and size so only a real read could notice the change
+func swapDataFile(t *testing.T, path, id string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(writeDataFile(t, mention(id, time.Unix(1700000000, 0), "splunk")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostCacheReadsOnceWithinTTL(t *testing.T) {
+	path := writeDataFile(t, mention("aaaa", time.Unix(1700000000, 0), "splunk"))
+	var c postCache
+
+	if posts := c.load(path, time.Hour); len(posts) != 1 || posts[0].ID != "aaaa" {
+		t.Fatalf("first load = %+v", posts)
+	}
+	swapDataFile(t, path, "bbbb")
+	// Rapid requests within the TTL are served from the first read
+	for range 20 {
+		if posts := c.load(path, time.Hour); posts[0].ID != "aaaa" {
+			t.Fatalf("load within the TTL read the file again: %+v", posts)
+		}
+	}
+
+	// Past the TTL an unchanged mtime and size still skip the read...
+	c.checked = time.Now().Add(-2 * time.Hour)
+	if posts := c.load(path, time.Hour); posts[0].ID != "aaaa" {
+		t.Errorf("unchanged file was re-read: %+v", posts)
+	}
+	// ...while a changed file is picked up
+	c.checked = time.Now().Add(-2 * time.Hour)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if posts := c.load(path, time.Hour); posts[0].ID != "bbbb" {
+		t.Errorf("changed file not re-read: %+v", posts)
+	}
+}
+
+func TestPostCacheDisabled(t *testing.T) {
+	path := writeDataFile(t, mention("aaaa", time.Unix(1700000000, 0), "splunk"))
+	var c postCache
+	c.load(path, -1)
+	swapDataFile(t, path, "bbbb")
+	if posts := c.load(path, -1); posts[0].ID != "bbbb" {
+		t.Errorf("a negative TTL served cached posts: %+v", posts)
+	}
+}
+
+func TestPostCacheReturnsCopies(t *testing.T) {
+	path := writeDataFile(t, mention("a", time.Unix(1700000000, 0)), mention("b", time.Unix(1700000000, 0)))
+	var c postCache
+	first := c.load(path, time.Hour)
+	first[0], first[1] = first[1], first[0]
+	if second := c.load(path, time.Hour); second[0].ID != first[1].ID {
+		t.Error("reordering a caller's slice changed the cache")
+	}
+}
+
+func TestConcurrentRequestsShareOneLoad(t *testing.T) {
+	s := &Server{DataFile: writeDataFile(t, mention("aaaa", time.Now(), "splunk"))}
+	var loads atomic.Int32
+	release := make(chan struct{})
+	s.cache.loader = func(path string) []domain.Post {
+		loads.Add(1)
+		<-release // hold the read open while the other requests arrive
+		return loadData(path)
+	}
+	h := s.Handler()
+
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes[i] = rec.Code
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loaded the data file %d times for %d concurrent requests, want 1", n, len(codes))
+	}
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d = %d, want 200", i, code)
+		}
+	}
+}
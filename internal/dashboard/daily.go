@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// maxDayRange bounds how many daily files one page load may read
+const maxDayRange = 366
+
+// dayRange selects daily files (DAILY_OUTPUT) to show instead of the data
+// file: From through To inclusive, as YYYY-MM-DD. The zero value means the
+// data file.
+type dayRange struct {
+	From, To string
+}
+
+// parseDayRange reads ?day=2024-01-15 for one day or ?from=...&to=... for a
+// range; either end of a range alone means just that day
+func parseDayRange(q url.Values) (dayRange, error) {
+	from, to := q.Get("from"), q.Get("to")
+	if day := q.Get("day"); day != "" {
+		from, to = day, day
+	}
+	if from == "" && to == "" {
+		return dayRange{}, nil
+	}
+	if from == "" {
+		from = to
+	} else if to == "" {
+		to = from
+	}
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return dayRange{}, fmt.Errorf("invalid day %q, want YYYY-MM-DD", from)
+	}
+	end, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return dayRange{}, fmt.Errorf("invalid day %q, want YYYY-MM-DD", to)
+	}
+	if end.Before(start) {
+		start, end = end, start
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxDayRange {
+		return dayRange{}, fmt.Errorf("range spans %d days, at most %d allowed", days, maxDayRange)
+	}
+	return dayRange{From: start.Format(time.DateOnly), To: end.Format(time.DateOnly)}, nil
+}
+
+// dayPosts reads the daily files in r, sorted by score like loadData. Days
+// without a file contribute nothing.
+func (s *Server) dayPosts(r dayRange) []domain.Post {
+	start, _ := time.Parse(time.DateOnly, r.From)
+	end, _ := time.Parse(time.DateOnly, r.To)
+	posts := []domain.Post{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		posts = append(posts, loadData(storage.DailyFile(s.DailyDir, day))...)
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].Score > posts[j].Score })
+	return posts
+}
+
+// label is how the page names the range, "" for the data file
+func (r dayRange) label() string {
+	if r.From == r.To {
+		return r.From
+	}
+	return r.From + " to " + r.To
+}
@@ -0,0 +1,32 @@
+package dashboard
+
+import (
+	"errors"
+	"net/http"
+	"os"
+)
+
+// handleDownload streams the raw NDJSON data file for backups. It goes
+// through http.ServeContent, so range requests and If-Modified-Since work
+// for resuming large downloads.
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	f, err := os.Open(s.DataFile)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "no data file yet", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "cannot open data file", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.Error(w, "cannot read data file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="current.ndjson"`)
+	http.ServeContent(w, r, "current.ndjson", info.ModTime(), f)
+}
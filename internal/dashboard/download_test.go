@@ -0,0 +1,73 @@
+package dashboard
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func downloadRequest(h http.Handler, token, rangeHeader string) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, "/download/current.ndjson", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func TestDownloadStreamsDataFile(t *testing.T) {
+	path := writeDataFile(t, mention("a", time.Now(), "splunk"), mention("b", time.Now(), "misp"))
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := (&Server{DataFile: path, AuthToken: "secret"}).Handler()
+
+	resp := downloadRequest(h, "secret", "")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != string(want) {
+		t.Fatalf("status %d, body %q; want 200 with the file", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		t.Error("range requests not advertised")
+	}
+
+	// Resuming a download fetches just the tail
+	resp = downloadRequest(h, "secret", "bytes=10-")
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != string(want[10:]) {
+		t.Errorf("range request: status %d, body %q; want 206 with bytes 10-", resp.StatusCode, body)
+	}
+	resp = downloadRequest(h, "secret", "bytes=0-4")
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent || string(body) != string(want[:5]) {
+		t.Errorf("range 0-4: status %d, body %q", resp.StatusCode, body)
+	}
+}
+
+func TestDownloadRequiresToken(t *testing.T) {
+	path := writeDataFile(t, mention("a", time.Now(), "splunk"))
+	if resp := downloadRequest((&Server{DataFile: path}).Handler(), "", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("without DASHBOARD_TOKEN: status %d, want 403", resp.StatusCode)
+	}
+	h := (&Server{DataFile: path, AuthToken: "secret"}).Handler()
+	if resp := downloadRequest(h, "wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d, want 401", resp.StatusCode)
+	}
+
+	missing := (&Server{DataFile: filepath.Join(t.TempDir(), "none.ndjson"), AuthToken: "secret"}).Handler()
+	if resp := downloadRequest(missing, "secret", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing file: status %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,96 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// ageBucketLabels are the histogram buckets, in order
+var ageBucketLabels = []string{"<1h", "1-6h", "6-24h", ">24h"}
+
+// freshness summarises how old the loaded posts are
+type freshness struct {
+	Buckets [4]int
+	Newest  time.Duration
+	Median  time.Duration
+	// Dated is how many posts had a usable CreatedUTC
+	Dated int
+}
+
+// computeFreshness buckets post ages relative to now. Posts without a
+// CreatedUTC are skipped since their age is unknown.
+func computeFreshness(posts []domain.Post, now time.Time) freshness {
+	var f freshness
+	var ages []time.Duration
+	for _, p := range posts {
+		if p.CreatedUTC <= 0 {
+			continue
+		}
+		age := now.Sub(time.Unix(int64(p.CreatedUTC), 0))
+		if age < 0 {
+			age = 0
+		}
+		ages = append(ages, age)
+
+		switch {
+		case age < time.Hour:
+			f.Buckets[0]++
+		case age < 6*time.Hour:
+			f.Buckets[1]++
+		case age < 24*time.Hour:
+			f.Buckets[2]++
+		default:
+			f.Buckets[3]++
+		}
+	}
+
+	f.Dated = len(ages)
+	if len(ages) == 0 {
+		return f
+	}
+	sort.Slice(ages, func(i, j int) bool { return ages[i] < ages[j] })
+	f.Newest = ages[0]
+	if len(ages)%2 == 1 {
+		f.Median = ages[len(ages)/2]
+	} else {
+		f.Median = (ages[len(ages)/2-1] + ages[len(ages)/2]) / 2
+	}
+	return f
+}
+
+// formatAge renders a duration the way analysts read it: 45m, 3h, 2d
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func ageHistogram(f freshness, theme string) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{
+			Theme:  theme,
+			Height: "300px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: boolPtr(true), Trigger: "axis", AxisPointer: &opts.AxisPointer{Type: "shadow"}}),
+		charts.WithGridOpts(opts.Grid{ContainLabel: boolPtr(true)}),
+	)
+	bar.SetXAxis(ageBucketLabels)
+
+	var data []opts.BarData
+	for _, n := range f.Buckets {
+		data = append(data, opts.BarData{Value: n})
+	}
+	bar.AddSeries("Posts", data)
+	return bar
+}
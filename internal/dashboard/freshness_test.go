@@ -0,0 +1,65 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestComputeFreshnessBuckets(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) domain.Post {
+		return domain.Post{CreatedUTC: float64(now.Add(-d).Unix())}
+	}
+	posts := []domain.Post{
+		ago(10 * time.Minute),
+		ago(59 * time.Minute),
+		ago(time.Hour), // bucket edges belong to the older bucket
+		ago(5 * time.Hour),
+		ago(6 * time.Hour),
+		ago(30 * time.Hour),
+		ago(-5 * time.Minute), // clock skew counts as brand new
+		{},                    // no CreatedUTC: not bucketed at all
+	}
+	f := computeFreshness(posts, now)
+	if want := [4]int{3, 2, 1, 1}; f.Buckets != want {
+		t.Errorf("Buckets = %v, want %v", f.Buckets, want)
+	}
+	if f.Dated != 7 {
+		t.Errorf("Dated = %d, want 7", f.Dated)
+	}
+	if f.Newest != 0 {
+		t.Errorf("Newest = %v, want 0", f.Newest)
+	}
+	if f.Median != time.Hour {
+		t.Errorf("Median = %v, want 1h", f.Median)
+	}
+}
+
+func TestComputeFreshnessEvenMedianAndEmpty(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	posts := []domain.Post{
+		{CreatedUTC: float64(now.Add(-2 * time.Hour).Unix())},
+		{CreatedUTC: float64(now.Add(-4 * time.Hour).Unix())},
+	}
+	if f := computeFreshness(posts, now); f.Median != 3*time.Hour || f.Newest != 2*time.Hour {
+		t.Errorf("Median, Newest = %v, %v; want 3h, 2h", f.Median, f.Newest)
+	}
+	if f := computeFreshness([]domain.Post{{}, {CreatedUTC: -1}}, now); f.Dated != 0 || f.Buckets != [4]int{} {
+		t.Errorf("undated posts gave %+v, want nothing bucketed", f)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		45 * time.Minute: "45m",
+		3 * time.Hour:    "3h",
+		47 * time.Hour:   "47h",
+		72 * time.Hour:   "3d",
+	} {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
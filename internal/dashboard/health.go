@@ -0,0 +1,51 @@
+package dashboard
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// DefaultStaleAfter is how long a target may go without a successful fetch
+// before the dashboard flags it
+const DefaultStaleAfter = 24 * time.Hour
+
+// HealthRow is one target in the health table
+type HealthRow struct {
+	Target string
+	// LastSuccess is empty when the target has never been fetched successfully
+	LastSuccess string
+	LastError   string
+	Stale       bool
+}
+
+// targetHealth reads the health file and flags targets that haven't
+// succeeded within staleAfter as of now. Stale targets sort first.
+func targetHealth(path string, staleAfter time.Duration, now time.Time) []HealthRow {
+	if path == "" {
+		return nil
+	}
+	health, err := storage.LoadHealth(path)
+	if err != nil {
+		slog.Warn("Could not read target health", "path", path, "err", err)
+		return nil
+	}
+
+	rows := make([]HealthRow, 0, len(health))
+	for _, h := range health {
+		row := HealthRow{Target: h.Target, LastError: h.LastError, Stale: h.Stale(now, staleAfter)}
+		if !h.LastSuccess.IsZero() {
+			row.LastSuccess = formatAge(now.Sub(h.LastSuccess)) + " ago"
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Stale != rows[j].Stale {
+			return rows[i].Stale
+		}
+		return rows[i].Target < rows[j].Target
+	})
+	return rows
+}
@@ -0,0 +1,25 @@
+package dashboard
+
+import (
+	"html/template"
+	"strings"
+
+	"github.com/qepting91/reddit-scraper/internal/filter"
+)
+
+// highlightTitle HTML-escapes title and wraps each stretch matched by hits
+// in <mark>. Spans come from the raw title and are escaped piecewise, so a
+// keyword can never split or inject markup.
+func highlightTitle(title string, hits []string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, sp := range filter.HighlightSpans(title, hits) {
+		b.WriteString(template.HTMLEscapeString(title[last:sp[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(title[sp[0]:sp[1]]))
+		b.WriteString("</mark>")
+		last = sp[1]
+	}
+	b.WriteString(template.HTMLEscapeString(title[last:]))
+	return template.HTML(b.String())
+}
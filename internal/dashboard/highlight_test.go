@@ -0,0 +1,49 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHighlightTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		hits  []string
+		want  string
+	}{
+		{"plain", "Splunk tips", []string{"splunk"}, "<mark>Splunk</mark> tips"},
+		{"no hits escapes", `<script>alert("x")</script>`, nil, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+		{"hit next to markup", "<script>splunk</script>", []string{"splunk"}, "&lt;script&gt;<mark>splunk</mark>&lt;/script&gt;"},
+		{"keyword containing markup", "a <b> tag", []string{"<b>"}, "a <mark>&lt;b&gt;</mark> tag"},
+		{"ampersand", "AT&T & Splunk", []string{"at&t"}, "<mark>AT&amp;T</mark> &amp; Splunk"},
+		{"overlapping hits", "crowdstrike", []string{"crowd", "strike", "dst"}, "<mark>crowdstrike</mark>"},
+		{"touching hits", "foobar!", []string{"foo", "bar"}, "<mark>foobar</mark>!"},
+		{"length mismatch only escapes", "İstanbul & splunk", []string{"splunk"}, "İstanbul &amp; splunk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(highlightTitle(tt.title, tt.hits)); got != tt.want {
+				t.Errorf("highlightTitle(%q, %q) = %q, want %q", tt.title, tt.hits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDashboardTruncatesTitleWithFullTitleOnHover(t *testing.T) {
+	p := mention("a", time.Now(), "splunk")
+	p.Title = "Splunk detections for 日本語 phishing kits"
+	rec := httptest.NewRecorder()
+	(&Server{DataFile: writeDataFile(t, p), TitleLen: 20}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	page := rec.Body.String()
+	if !strings.Contains(page, `title="Splunk detections for 日本語 phishing kits"`) {
+		t.Error("the full title isn't on the link's hover text")
+	}
+	if !strings.Contains(page, ">Splunk detections f…</a>") {
+		t.Error("the table doesn't show the truncated title")
+	}
+}
@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Reddit front ends dashboard links can point at (REDDIT_LINK_HOST)
+const (
+	LinkHostWWW = "www" // the current site
+	LinkHostOld = "old" // old.reddit.com
+	LinkHostNP  = "np"  // "no participation" links, read-only by convention
+)
+
+// ValidLinkHost reports whether host is a known REDDIT_LINK_HOST value
+func ValidLinkHost(host string) bool {
+	switch host {
+	case LinkHostWWW, LinkHostOld, LinkHostNP:
+		return true
+	}
+	return false
+}
+
+// redditLink moves a reddit.com URL (any subdomain) onto host's subdomain,
+// e.g. https://reddit.com/r/netsec/comments/abc/ to old.reddit.com. Other
+// URLs, and every URL when host is empty, are returned unchanged.
+func redditLink(raw, host string) string {
+	if host == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return raw
+	}
+	h := strings.ToLower(u.Hostname())
+	if h != "reddit.com" && !strings.HasSuffix(h, ".reddit.com") {
+		return raw
+	}
+	u.Scheme, u.Host = "https", host+".reddit.com"
+	return u.String()
+}
+
+// displaySub shows a subreddit name as "r/netsec" or "netsec", whichever
+// form the collector stored it in
+func displaySub(name string, prefix bool) string {
+	name = strings.TrimSpace(name)
+	if len(name) >= 2 && strings.EqualFold(name[:2], "r/") {
+		name = name[2:]
+	}
+	if prefix {
+		return "r/" + name
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+package dashboard
+
+import "testing"
+
+func TestRedditLinkHosts(t *testing.T) {
+	const thread = "https://www.reddit.com/r/netsec/comments/abc/title/"
+	tests := []struct {
+		raw, host, want string
+	}{
+		{thread, LinkHostWWW, "https://www.reddit.com/r/netsec/comments/abc/title/"},
+		{thread, LinkHostOld, "https://old.reddit.com/r/netsec/comments/abc/title/"},
+		{thread, LinkHostNP, "https://np.reddit.com/r/netsec/comments/abc/title/"},
+		// Unset keeps the stored link
+		{thread, "", thread},
+		// Bare and other subdomains move too, upgraded to https
+		{"http://reddit.com/r/netsec/comments/abc/?context=3", LinkHostOld, "https://old.reddit.com/r/netsec/comments/abc/?context=3"},
+		{"https://old.reddit.com/r/netsec/", LinkHostWWW, "https://www.reddit.com/r/netsec/"},
+		// Non-Reddit links and lookalike hosts are left alone
+		{"https://example.com/writeup", LinkHostOld, "https://example.com/writeup"},
+		{"https://notreddit.com/r/netsec/", LinkHostOld, "https://notreddit.com/r/netsec/"},
+		{"/r/netsec/comments/abc/", LinkHostOld, "/r/netsec/comments/abc/"},
+	}
+	for _, tt := range tests {
+		if got := redditLink(tt.raw, tt.host); got != tt.want {
+			t.Errorf("redditLink(%q, %q) = %q, want %q", tt.raw, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestValidLinkHost(t *testing.T) {
+	for _, host := range []string{LinkHostWWW, LinkHostOld, LinkHostNP} {
+		if !ValidLinkHost(host) {
+			t.Errorf("ValidLinkHost(%q) = false", host)
+		}
+	}
+	for _, host := range []string{"", "new", "old.reddit.com"} {
+		if ValidLinkHost(host) {
+			t.Errorf("ValidLinkHost(%q) = true", host)
+		}
+	}
+}
+
+func TestDisplaySub(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix bool
+		want   string
+	}{
+		{"netsec", false, "netsec"},
+		{"r/netsec", false, "netsec"},
+		{"netsec", true, "r/netsec"},
+		{"R/netsec", true, "r/netsec"},
+		{" r/netsec ", true, "r/netsec"},
+	}
+	for _, tt := range tests {
+		if got := displaySub(tt.name, tt.prefix); got != tt.want {
+			t.Errorf("displaySub(%q, %v) = %q, want %q", tt.name, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package dashboard
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultLiveRefresh is how often the page polls /api/live when
+// Server.LiveRefresh is zero
+const DefaultLiveRefresh = 5 * time.Second
+
+// LiveStats is a snapshot of the scraper's in-memory cycle counters, kept by
+// the workers as they go rather than read back from the data file
+type LiveStats struct {
+	// Running is set while a cycle is in progress; between cycles the
+	// counts are the last cycle's
+	Running      bool      `json:"running"`
+	CycleStarted time.Time `json:"cycle_started,omitzero"`
+	TargetsDone  int64     `json:"targets_done"`
+	TargetsTotal int64     `json:"targets_total"`
+	PostsMatched int64     `json:"posts_matched"`
+	Errors       int64     `json:"errors"`
+	// RateLimitRemaining is the request allowance Reddit last reported,
+	// null until a response carried one
+	RateLimitRemaining *float64 `json:"rate_limit_remaining"`
+}
+
+// handleLive serves the current LiveStats as JSON
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Live())
+}
+
+// liveRefresh is the page's polling interval in milliseconds, 0 when the
+// live cards are off
+func (s *Server) liveRefresh() int64 {
+	if s.Live == nil || s.LiveRefresh < 0 {
+		return 0
+	}
+	if s.LiveRefresh == 0 {
+		return DefaultLiveRefresh.Milliseconds()
+	}
+	return s.LiveRefresh.Milliseconds()
+}
@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"log/slog"
+	"sort"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/storage"
+)
+
+// ReachRow puts a subreddit's mention count next to its size
+type ReachRow struct {
+	Subreddit   string
+	Subscribers int
+	ActiveUsers int
+	Mentions    int
+	// Per100k is mentions per 100k subscribers (0 when the size is unknown)
+	Per100k float64
+}
+
+// subredditReach joins per-subreddit mention counts with the stats file.
+// Subreddits without stats are left out; rows are ordered by mentions.
+func subredditReach(path string, subCounts map[string]int) []ReachRow {
+	if path == "" {
+		return nil
+	}
+	stats, err := storage.LoadStats(path)
+	if err != nil {
+		slog.Warn("Could not read subreddit stats", "path", path, "err", err)
+		return nil
+	}
+
+	// Post subreddits come as "r/netsec" or "netsec" depending on the collector
+	mentions := make(map[string]int, len(subCounts))
+	for sub, n := range subCounts {
+		mentions[domain.SubredditKey(sub)] += n
+	}
+
+	var rows []ReachRow
+	for key, st := range stats {
+		row := ReachRow{Subreddit: st.Subreddit, Subscribers: st.Subscribers, ActiveUsers: st.ActiveUsers, Mentions: mentions[key]}
+		if st.Subscribers > 0 {
+			row.Per100k = float64(row.Mentions) * 100000 / float64(st.Subscribers)
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Mentions != rows[j].Mentions {
+			return rows[i].Mentions > rows[j].Mentions
+		}
+		return rows[i].Subreddit < rows[j].Subreddit
+	})
+	return rows
+}
@@ -0,0 +1,46 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"time"
+)
+
+// RenderReport writes the unfiltered dashboard page as one standalone HTML
+// file, for mailing or archiving without the server. With the chart scripts
+// embedded (see assets/README.md) they are inlined and the file works
+// offline; otherwise it loads them from the CDN when opened.
+func (s *Server) RenderReport(w io.Writer, now time.Time) error {
+	view := s.buildView("", "", dayRange{})
+	view.Report = true
+	view.GeneratedAt = now.UTC().Format("2006-01-02 15:04 UTC")
+	if scripts, err := inlineScripts(view.Theme); err == nil {
+		view.InlineScripts = scripts
+	} else {
+		slog.Warn("Chart scripts aren't embedded, the report will load them from the CDN", "err", err)
+	}
+	return s.pageTemplate().Execute(w, view)
+}
+
+// inlineScripts returns echarts and the theme's script from the embedded
+// assets, ready to drop into a <script> element
+func inlineScripts(theme string) (template.JS, error) {
+	if !HasLocalAssets(theme) {
+		return "", fmt.Errorf("no embedded echarts.min.js or %s theme", theme)
+	}
+	var buf bytes.Buffer
+	for _, name := range []string{"echarts.min.js", "themes/" + theme + ".js"} {
+		data, err := fs.ReadFile(assetFS(), name)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(data)
+		buf.WriteString(";\n")
+	}
+	// A literal "</script" in the source would end the element early
+	return template.JS(bytes.ReplaceAll(buf.Bytes(), []byte("</script"), []byte(`<\/script`))), nil
+}
@@ -0,0 +1,188 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrapeRequest(h http.Handler) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestScrapeWhileRunningConflicts(t *testing.T) {
+	trigger := make(chan ScrapeRequest)
+	s := &Server{AuthToken: "secret", Trigger: trigger, ScrapeTimeout: 5 * time.Second}
+	h := s.Handler()
+
+	// A scrape loop like main's: it receives only while idle, then runs the
+	// cycle until released
+	running := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		req := <-trigger
+		close(running)
+		<-release
+		req.Done <- ScrapeResult{PostsAdded: 3}
+	}()
+
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		// Until the loop goroutine is parked on trigger this is refused too
+		for {
+			if rec := scrapeRequest(h); rec.Code != http.StatusConflict {
+				first <- rec
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	<-running
+
+	if rec := scrapeRequest(h); rec.Code != http.StatusConflict {
+		t.Errorf("second scrape while running: status %d, want %d (%s)", rec.Code, http.StatusConflict, rec.Body)
+	}
+
+	close(release)
+	if rec := <-first; rec.Code != http.StatusOK {
+		t.Errorf("first scrape: status %d, want %d (%s)", rec.Code, http.StatusOK, rec.Body)
+	}
+}
+
+func TestScrapeRequiresToken(t *testing.T) {
+	s := &Server{AuthToken: "secret", Trigger: make(chan ScrapeRequest)}
+	req := httptest.NewRequest(http.MethodPost, "/api/scrape", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+// idleLoop receives one scrape request like main's loop and hands it to run
+func idleLoop(trigger <-chan ScrapeRequest, run func(ScrapeRequest)) <-chan struct{} {
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		run(<-trigger)
+	}()
+	return finished
+}
+
+// waitForLoop retries a scrape until the loop goroutine is parked on trigger
+func waitForLoop(h http.Handler) *httptest.ResponseRecorder {
+	for {
+		if rec := scrapeRequest(h); rec.Code != http.StatusConflict {
+			return rec
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScrapeReturnsOutcome(t *testing.T) {
+	trigger := make(chan ScrapeRequest)
+	h := (&Server{AuthToken: "secret", Trigger: trigger}).Handler()
+
+	idleLoop(trigger, func(req ScrapeRequest) { req.Done <- ScrapeResult{PostsAdded: 7} })
+	rec := waitForLoop(h)
+	var body struct {
+		Status     string `json:"status"`
+		PostsAdded int    `json:"posts_added"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || body.PostsAdded != 7 {
+		t.Errorf("status %d, body %s; want 200 with posts_added 7", rec.Code, rec.Body)
+	}
+
+	idleLoop(trigger, func(req ScrapeRequest) { req.Done <- ScrapeResult{PostsAdded: 2, Err: errors.New("cycle aborted")} })
+	if rec := waitForLoop(h); rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "cycle aborted") {
+		t.Errorf("failed cycle: status %d, body %s; want 500 with the error", rec.Code, rec.Body)
+	}
+}
+
+func TestScrapeTimeoutLeavesCycleRunning(t *testing.T) {
+	trigger := make(chan ScrapeRequest)
+	h := (&Server{AuthToken: "secret", Trigger: trigger, ScrapeTimeout: 20 * time.Millisecond}).Handler()
+
+	release := make(chan struct{})
+	var cycleErr error
+	finished := idleLoop(trigger, func(req ScrapeRequest) {
+		<-release
+		cycleErr = req.Ctx.Err()
+		req.Done <- ScrapeResult{PostsAdded: 1} // buffered: nobody is listening any more
+	})
+
+	rec := waitForLoop(h)
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), "still running") {
+		t.Errorf("status %d, body %s; want 202 still running", rec.Code, rec.Body)
+	}
+	close(release)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("the background cycle blocked reporting its outcome")
+	}
+	if cycleErr != nil {
+		t.Errorf("the request timing out cancelled the cycle: %v", cycleErr)
+	}
+}
+
+func TestScrapeCancelledByShutdown(t *testing.T) {
+	base, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+	trigger := make(chan ScrapeRequest)
+	h := (&Server{AuthToken: "secret", Trigger: trigger, BaseContext: base, ScrapeTimeout: time.Minute}).Handler()
+
+	started := make(chan struct{})
+	finished := idleLoop(trigger, func(req ScrapeRequest) {
+		close(started)
+		<-req.Ctx.Done() // the cycle stops once the server shuts down
+		req.Done <- ScrapeResult{Err: req.Ctx.Err()}
+	})
+	go func() {
+		<-started
+		shutdown()
+	}()
+
+	rec := waitForLoop(h)
+	if rec.Code != http.StatusServiceUnavailable && rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, body %s; want the scrape reported as cancelled", rec.Code, rec.Body)
+	}
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown didn't cancel the triggered cycle")
+	}
+}
+
+func TestScrapeRefusedWhilePaused(t *testing.T) {
+	trigger := make(chan ScrapeRequest)
+	reason := "quiet hours"
+	h := (&Server{AuthToken: "secret", Trigger: trigger, Paused: func() string { return reason }}).Handler()
+
+	// Inside the window nothing reaches the scrape loop
+	rec := scrapeRequest(h)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "paused (quiet hours)") {
+		t.Errorf("paused scrape: status %d %s, want 503 naming the reason", rec.Code, rec.Body)
+	}
+	select {
+	case <-trigger:
+		t.Fatal("a paused scrape was handed to the loop")
+	default:
+	}
+
+	// Once the window closes scrapes run again
+	reason = ""
+	idleLoop(trigger, func(req ScrapeRequest) { req.Done <- ScrapeResult{PostsAdded: 1} })
+	if rec := waitForLoop(h); rec.Code != http.StatusOK {
+		t.Errorf("scrape outside the window: status %d %s, want 200", rec.Code, rec.Body)
+	}
+}
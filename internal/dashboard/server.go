@@ -1,45 +1,242 @@
 package dashboard
 
 import (
-	"bufio"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"html/template"
+	"log/slog"
 	"net/http"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 	"github.com/go-echarts/go-echarts/v2/render"
 	"github.com/go-echarts/go-echarts/v2/types"
 	"github.com/qepting91/reddit-scraper/internal/domain"
+	"github.com/qepting91/reddit-scraper/internal/filter"
+	"github.com/qepting91/reddit-scraper/internal/storage"
 )
 
 // DashboardView holds data for the HTML template
 type DashboardView struct {
 	StackedBarSnippet template.HTML
+	AgeHistSnippet    template.HTML
 	Posts             []domain.Post
 	TotalMentions     int
 	TopTool           string
 	TopSub            string
 	HighestScore      int
+	NewestAge         string
+	MedianAge         string
 	ActiveFilter      string
+	Theme             string
+	// Reach lists subreddit sizes next to mention counts (SUBREDDIT_STATS)
+	Reach []ReachRow
+	// ShowAwards adds the Awards column once any post has awards
+	ShowAwards bool
+	SortKey    string
+	// Paused is why scraping is paused right now, "" when it isn't
+	Paused string
+	// Health lists each target's last successful fetch, stale ones first
+	Health []HealthRow
+	// ShowTargets adds the Matched By column once a post was surfaced by a
+	// multireddit or by more than one target
+	ShowTargets bool
+	// Report marks a standalone export (-render-report): no search form, a
+	// generation time, and the chart scripts inlined when embedded
+	Report        bool
+	GeneratedAt   string
+	InlineScripts template.JS
+	// LiveRefreshMS is how often the live cards poll /api/live; 0 hides them
+	LiveRefreshMS int64
+	// Daily offers the day pickers (DAILY_OUTPUT); From/To is the range
+	// shown, both empty for the data file, and Days its label
+	Daily    bool
+	From, To string
+	Days     string
+}
+
+// Server serves the dashboard and its small control API
+type Server struct {
+	DataFile string
+	Port     string
+
+	// BasePath mounts every route under a prefix (e.g. "/reddit") for hosting
+	// behind a reverse proxy path; empty serves from the root
+	BasePath string
+
+	// StatsFile holds subreddit sizes saved by the scraper (optional)
+	StatsFile string
+
+	// HealthFile holds per-target last-success times saved by the scraper
+	// (optional); targets without a success within StaleAfter are flagged
+	HealthFile string
+	StaleAfter time.Duration
+
+	// AuthToken gates the control endpoints (e.g. /api/scrape) and
+	// /download/current.ndjson. When empty those endpoints are disabled.
+	AuthToken string
+
+	// TitleLen caps titles shown in the table (full title on hover, 0 = no cap)
+	TitleLen int
+	// Highlight marks the matched keywords inside each title
+	Highlight bool
+	// LinkHost sends Reddit links to www, old or np.reddit.com ("" = as stored)
+	LinkHost string
+	// SubredditPrefix shows subreddits as "r/netsec" rather than "netsec"
+	SubredditPrefix bool
+
+	// Theme is the go-echarts theme for all charts (default westeros)
+	Theme string
+	// LocalAssets serves the chart scripts from the binary under /assets/
+	// instead of the CDN, for networks without internet access
+	LocalAssets bool
+
+	// Trigger is an unbuffered channel the scrape loop receives on while it
+	// is idle, so a failed non-blocking send means a cycle is already running.
+	Trigger chan<- ScrapeRequest
+
+	// BaseContext is the scraper's lifetime context. Waits on triggered
+	// scrapes derive from it, so a shutdown ends them too (nil = Background).
+	BaseContext context.Context
+
+	// ScrapeTimeout bounds how long /api/scrape waits for the cycle's outcome
+	// before answering 202 and leaving it to finish in the background.
+	ScrapeTimeout time.Duration
+
+	// Paused, when set, returns why scraping is paused (e.g. "quiet hours")
+	// or "" when it isn't; paused scrapes are refused and the page says so
+	Paused func() string
+
+	// CacheTTL is how long parsed posts are reused before the data file is
+	// checked for changes (DefaultCacheTTL when 0, negative disables caching)
+	CacheTTL time.Duration
+
+	// Live, when set, reports the running cycle's counters for /api/live and
+	// the page's live cards, which poll it every LiveRefresh
+	// (DefaultLiveRefresh when 0, negative hides the cards)
+	Live        func() LiveStats
+	LiveRefresh time.Duration
+
+	// DailyDir holds the per-day files (DAILY_OUTPUT); when set, ?day= or
+	// ?from=&to= show those days instead of the data file
+	DailyDir string
+
+	cache postCache
+}
+
+// posts returns the data file's posts through the cache
+func (s *Server) posts() []domain.Post {
+	ttl := s.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	return s.cache.load(s.DataFile, ttl)
+}
+
+func (s *Server) paused() string {
+	if s.Paused == nil {
+		return ""
+	}
+	return s.Paused()
+}
+
+// DefaultScrapeTimeout is used when Server.ScrapeTimeout is zero
+const DefaultScrapeTimeout = 30 * time.Second
+
+// ScrapeRequest asks the scrape loop for a cycle. The loop sends exactly one
+// ScrapeResult on Done (buffered, so it never blocks) when the cycle ends.
+type ScrapeRequest struct {
+	Ctx  context.Context
+	Done chan<- ScrapeResult
+}
+
+// ScrapeResult is the outcome of a triggered cycle
+type ScrapeResult struct {
+	PostsAdded int
+	Err        error
 }
 
 func boolPtr(b bool) *bool { return &b }
 
-func StartServer(dataFile string, port string) error {
+// ValidTheme reports whether name is one of the go-echarts preset themes
+func ValidTheme(name string) bool { return types.PresetTheme(name) }
+
+// defaultTheme is used when Server.Theme is empty
+const defaultTheme = types.ThemeWesteros
+
+func (s *Server) theme() string {
+	if s.Theme == "" {
+		return defaultTheme
+	}
+	return s.Theme
+}
+
+// CleanBasePath normalises a BASE_PATH value to "/prefix" with no trailing
+// slash; "" and "/" both mean the root
+func CleanBasePath(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+func (s *Server) Start() error {
+	return http.ListenAndServe(":"+s.Port, s.Handler())
+}
+
+// pageTemplate parses the dashboard page. Its funcs read the Server's
+// display settings, so build it after configuring them.
+func (s *Server) pageTemplate() *template.Template {
 	// Clean, high-contrast "Analyst Report" template with Search Bar
-	tpl := template.Must(template.New("dashboard").Parse(`
+	base := CleanBasePath(s.BasePath)
+	funcs := template.FuncMap{
+		// thread links to the Reddit discussion; records stored before
+		// Permalink existed fall back to URL
+		"thread": func(p domain.Post) string {
+			if p.Permalink != "" {
+				return redditLink(p.Permalink, s.LinkHost)
+			}
+			return redditLink(p.URL, s.LinkHost)
+		},
+		// link is an outbound URL, moved to LinkHost when it is on Reddit
+		"link": func(u string) string { return redditLink(u, s.LinkHost) },
+		// sub displays a subreddit name per SubredditPrefix
+		"sub": func(name string) string { return displaySub(name, s.SubredditPrefix) },
+		// title truncates and, with Highlight, marks the post's keyword hits
+		"title": func(p domain.Post) template.HTML {
+			t := filter.TruncateRunes(p.Title, s.TitleLen)
+			if !s.Highlight {
+				return template.HTML(template.HTMLEscapeString(t))
+			}
+			return highlightTitle(t, p.KeywordsHit)
+		},
+		// base is the dashboard's root URL, honoring BasePath
+		"base": func() string { return base + "/" },
+		// assets is where the chart scripts load from
+		"assets": func() string {
+			if s.LocalAssets {
+				return base + "/assets/"
+			}
+			return cdnAssets
+		},
+	}
+	return template.Must(template.New("dashboard").Funcs(funcs).Parse(`
 <!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="utf-8">
     <meta name="viewport" content="width=device-width, initial-scale=1">
     <title>Tool Monitor Report</title>
-    <script src="https://go-echarts.github.io/go-echarts-assets/assets/echarts.min.js"></script>
-    <script src="https://go-echarts.github.io/go-echarts-assets/assets/themes/westeros.js"></script>
+    {{if .InlineScripts}}<script>{{.InlineScripts}}</script>{{else}}
+    <script src="{{assets}}echarts.min.js"></script>
+    <script src="{{assets}}themes/{{.Theme}}.js"></script>{{end}}
     <style>
         :root { --bg: #f3f4f6; --card: #ffffff; --text: #111827; --border: #e5e7eb; --blue: #2563eb; }
         body { background-color: var(--bg); color: var(--text); font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; margin: 0; padding: 30px; }
@@ -53,15 +250,18 @@ func StartServer(dataFile string, port string) error {
         /* Search Form */
         .search-form { display: flex; gap: 10px; }
         .search-input { padding: 8px 12px; border: 1px solid var(--border); border-radius: 6px; font-size: 0.9rem; width: 250px; }
+        .day-input { width: auto; }
         .btn { padding: 8px 16px; border-radius: 6px; border: none; font-weight: 500; cursor: pointer; font-size: 0.9rem; text-decoration: none; display: inline-block; }
         .btn-primary { background: var(--blue); color: white; }
         .btn-secondary { background: #f3f4f6; color: #4b5563; border: 1px solid var(--border); }
         .btn:hover { opacity: 0.9; }
+        .paused-banner { background: #fef3c7; border: 1px solid #f59e0b; color: #92400e; padding: 10px 16px; border-radius: 6px; margin-bottom: 20px; font-weight: 600; }
 
         /* KPI Cards */
-        .stats-grid { display: grid; grid-template-columns: repeat(4, 1fr); gap: 20px; margin-bottom: 25px; }
+        .stats-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(200px, 1fr)); gap: 20px; margin-bottom: 25px; }
         .stat-card { background: var(--card); padding: 20px; border-radius: 8px; border: 1px solid var(--border); }
         .stat-label { font-size: 0.75rem; text-transform: uppercase; font-weight: 600; color: #6b7280; letter-spacing: 0.05em; }
+        .live-state { text-transform: none; font-weight: 500; color: #059669; }
         .stat-value { font-size: 1.75rem; font-weight: 800; color: #111827; margin-top: 8px; }
         .highlight { color: var(--blue); }
 
@@ -78,6 +278,13 @@ func StartServer(dataFile string, port string) error {
         
         /* Tags & Links */
         .tag { background: #eff6ff; color: #1d4ed8; padding: 2px 10px; border-radius: 999px; font-size: 0.75rem; font-weight: 500; border: 1px solid #dbeafe; margin-right: 5px; display: inline-block; }
+        tr.removed { opacity: 0.45; }
+        tr.stale td { color: #b91c1c; font-weight: 600; }
+        a.source { color: #6b7280; text-decoration: none; }
+        .edited { color: #9ca3af; font-size: 0.8rem; cursor: help; }
+        .distinguished { color: #047857; font-size: 0.75rem; font-weight: 600; text-transform: uppercase; }
+        mark { background: #fef08a; color: inherit; padding: 0 1px; border-radius: 2px; }
+        .chart-unavailable { padding: 40px; text-align: center; color: #6b7280; border: 1px dashed var(--border); border-radius: 6px; }
         .score { font-family: monospace; font-weight: 700; color: #059669; background: #d1fae5; padding: 2px 6px; border-radius: 4px; }
         a { color: #2563eb; text-decoration: none; font-weight: 500; }
         a:hover { text-decoration: underline; }
@@ -88,18 +295,26 @@ func StartServer(dataFile string, port string) error {
         <div class="header">
             <div>
                 <h1>Intelligence Monitor</h1>
-                <div class="subtitle">Tracking tool mentions across technical subreddits</div>
+                <div class="subtitle">Tracking tool mentions across technical subreddits{{if .Days}} · {{.Days}}{{end}}{{if .Report}} · generated {{.GeneratedAt}}{{end}}</div>
             </div>
-            
-            <form action="/" method="GET" class="search-form">
+            {{if not .Report}}
+            <form action="{{base}}" method="GET" class="search-form">
                 <input type="text" name="q" class="search-input" placeholder="Filter by keyword (e.g., Splunk)" value="{{.ActiveFilter}}">
+                {{if eq .SortKey "awards"}}<input type="hidden" name="sort" value="awards">{{end}}
+                {{if .Daily}}
+                <input type="date" name="from" class="search-input day-input" title="First day (daily files)" value="{{.From}}">
+                <input type="date" name="to" class="search-input day-input" title="Last day (empty = just the first)" value="{{.To}}">
+                {{end}}
                 <button type="submit" class="btn btn-primary">Filter</button>
-                {{if .ActiveFilter}}
-                <a href="/" class="btn btn-secondary">Clear</a>
+                {{if or .ActiveFilter .From}}
+                <a href="{{base}}" class="btn btn-secondary">Clear</a>
                 {{end}}
             </form>
+            {{end}}
         </div>
 
+        {{if .Paused}}<div class="paused-banner">Scraping paused ({{.Paused}})</div>{{end}}
+
         <div class="stats-grid">
             <div class="stat-card">
                 <div class="stat-label">Total Mentions</div>
@@ -117,32 +332,135 @@ func StartServer(dataFile string, port string) error {
                 <div class="stat-label">Highest Post Upvotes</div>
                 <div class="stat-value">{{.HighestScore}}</div>
             </div>
+            <div class="stat-card">
+                <div class="stat-label">Freshness (Newest / Median)</div>
+                <div class="stat-value">{{.NewestAge}} / {{.MedianAge}}</div>
+            </div>
+        </div>
+
+        {{if and .LiveRefreshMS (not .Report)}}
+        <div class="stats-grid live" id="live" data-url="{{base}}api/live" data-every="{{.LiveRefreshMS}}">
+            <div class="stat-card">
+                <div class="stat-label">Cycle <span id="live-state" class="live-state">idle</span></div>
+                <div class="stat-value" id="live-targets">—</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-label">Posts This Cycle</div>
+                <div class="stat-value" id="live-posts">—</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-label">Errors This Cycle</div>
+                <div class="stat-value" id="live-errors">—</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-label">Rate Limit Remaining</div>
+                <div class="stat-value" id="live-rate">—</div>
+            </div>
         </div>
+        <script>
+        (function () {
+            var box = document.getElementById("live");
+            function set(id, v) { document.getElementById(id).textContent = v; }
+            function poll() {
+                fetch(box.dataset.url).then(function (r) { return r.json(); }).then(function (d) {
+                    set("live-state", d.running ? "running" : "idle");
+                    set("live-targets", d.targets_done + " / " + d.targets_total + " targets");
+                    set("live-posts", d.posts_matched);
+                    set("live-errors", d.errors);
+                    set("live-rate", d.rate_limit_remaining === null ? "—" : Math.floor(d.rate_limit_remaining));
+                }).catch(function () {});
+            }
+            poll();
+            setInterval(poll, Number(box.dataset.every));
+        })();
+        </script>
+        {{end}}
 
         <div class="chart-section">
             <div class="chart-title">Tool Distribution by Subreddit {{if .ActiveFilter}}(Filtered: "{{.ActiveFilter}}"){{end}}</div>
             {{.StackedBarSnippet}}
         </div>
 
+        <div class="chart-section">
+            <div class="chart-title">Post Age Distribution</div>
+            {{.AgeHistSnippet}}
+        </div>
+
+        {{if .Reach}}
+        <div class="table-section" style="margin-bottom: 25px;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Subreddit</th>
+                        <th>Subscribers</th>
+                        <th>Active Users</th>
+                        <th>Mentions</th>
+                        <th>Mentions / 100k Subscribers</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Reach}}
+                    <tr>
+                        <td>{{sub .Subreddit}}</td>
+                        <td>{{.Subscribers}}</td>
+                        <td>{{.ActiveUsers}}</td>
+                        <td>{{.Mentions}}</td>
+                        <td>{{if .Subscribers}}{{printf "%.2f" .Per100k}}{{else}}—{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
+        {{if .Health}}
+        <div class="table-section" style="margin-bottom: 25px;">
+            <table>
+                <thead>
+                    <tr>
+                        <th>Target</th>
+                        <th>Last Successful Scrape</th>
+                        <th>Last Error</th>
+                    </tr>
+                </thead>
+                <tbody>
+                    {{range .Health}}
+                    <tr{{if .Stale}} class="stale" title="No successful scrape recently"{{end}}>
+                        <td>{{.Target}}</td>
+                        <td>{{if .LastSuccess}}{{.LastSuccess}}{{else}}never{{end}}</td>
+                        <td>{{if .LastError}}{{.LastError}}{{else}}—{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+        </div>
+        {{end}}
+
         <div class="table-section">
             <table>
                 <thead>
                     <tr>
-                        <th width="100">Upvotes</th>
+                        <th width="100"><a href="?q={{.ActiveFilter}}{{if .From}}&from={{.From}}&to={{.To}}{{end}}">Upvotes</a></th>
+                        {{if .ShowAwards}}<th width="90"><a href="?q={{.ActiveFilter}}&sort=awards{{if .From}}&from={{.From}}&to={{.To}}{{end}}">Awards</a></th>{{end}}
                         <th width="150">Subreddit</th>
+                        <th width="170">Posted</th>
                         <th>Post Title</th>
                         <th>Tools Mentioned</th>
+                        {{if .ShowTargets}}<th width="170">Matched By</th>{{end}}
                     </tr>
                 </thead>
                 <tbody>
                     {{range .Posts}}
-                    <tr>
+                    <tr{{if .Removed}} class="removed" title="Deleted or removed on Reddit"{{end}}>
                         <td><span class="score">⬆ {{.Score}}</span></td>
-                        <td><a href="https://reddit.com/{{.Subreddit}}" target="_blank">r/{{.Subreddit}}</a></td>
-                        <td><a href="{{.URL}}" target="_blank" style="color: #111827; font-weight: 400;">{{.Title}}</a></td>
+                        {{if $.ShowAwards}}<td>{{if .Awards}}🏅 {{.Awards}}{{else}}—{{end}}</td>{{end}}
+                        <td><a href="{{thread .}}" target="_blank">{{sub .Subreddit}}</a></td>
+                        <td>{{if .CreatedUTC}}{{.CreatedTime.Format "2006-01-02 15:04 UTC"}}{{else}}—{{end}}</td>
+                        <td><a href="{{thread .}}" target="_blank" title="{{.Title}}" style="color: #111827; font-weight: 400;">{{title .}}</a>{{if .Distinguished}} <span class="distinguished" title="Distinguished by a {{.Distinguished}}">{{.Distinguished}}</span>{{end}}{{if .Edited}} <span class="edited" title="Edited on Reddit{{if .EditedUTC}} {{.EditedTime.Format "2006-01-02 15:04 UTC"}}{{end}}; its text may differ from what was captured">✎</span>{{end}}{{if and (not .IsSelf) .Permalink .URL (ne .URL .Permalink)}} <a href="{{link .URL}}" target="_blank" class="source" title="Linked source">↗</a>{{end}}</td>
                         <td>
                             {{range .KeywordsHit}}<span class="tag">{{.}}</span>{{end}}
                         </td>
+                        {{if $.ShowTargets}}<td>{{range $i, $t := .MatchedTargets}}{{if $i}}, {{end}}{{$t}}{{end}}</td>{{end}}
                     </tr>
                     {{end}}
                 </tbody>
@@ -152,176 +470,350 @@ func StartServer(dataFile string, port string) error {
 </body>
 </html>
 `))
+}
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		allPosts := loadData(dataFile)
-		var filteredPosts []domain.Post
-
-		// --- 1. Filtering Logic ---
-		query := r.URL.Query().Get("q")
-		if query != "" {
-			query = strings.ToLower(strings.TrimSpace(query))
-			for _, p := range allPosts {
-				// Check if the query matches any identified tool OR the title
-				match := false
-
-				// Check detected tools
-				for _, k := range p.KeywordsHit {
-					if strings.Contains(strings.ToLower(k), query) {
-						match = true
-						break
-					}
-				}
-
-				// Optional: Also check detected title if you want broader search
-				// if strings.Contains(strings.ToLower(p.Title), query) { match = true }
-
-				if match {
-					filteredPosts = append(filteredPosts, p)
-				}
+// Handler builds the dashboard's routes
+func (s *Server) Handler() http.Handler {
+	base := CleanBasePath(s.BasePath)
+	tpl := s.pageTemplate()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/scrape", s.requireToken(s.handleScrape))
+	mux.HandleFunc("GET /api/keyword/{term}/trend", s.handleKeywordTrend)
+	mux.HandleFunc("GET /download/current.ndjson", s.requireToken(s.handleDownload))
+	if s.Live != nil {
+		mux.HandleFunc("GET /api/live", s.handleLive)
+	}
+	if s.LocalAssets {
+		mux.Handle("GET /assets/", assetHandler())
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var days dayRange
+		if s.DailyDir != "" {
+			var err error
+			if days, err = parseDayRange(r.URL.Query()); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
 			}
-		} else {
-			filteredPosts = allPosts
 		}
+		view := s.buildView(r.URL.Query().Get("q"), r.URL.Query().Get("sort"), days)
+		w.Header().Set("Content-Type", "text/html")
+		tpl.Execute(w, view)
+	})
 
-		// Use filtered posts for the rest of the analysis
-		posts := filteredPosts
+	if base == "" {
+		return mux
+	}
+	// Routes see paths with the prefix stripped; the bare prefix redirects
+	// to its slash form so relative links resolve under it
+	prefixed := http.NewServeMux()
+	prefixed.Handle(base+"/", http.StripPrefix(base, mux))
+	prefixed.Handle(base, http.RedirectHandler(base+"/", http.StatusMovedPermanently))
+	return prefixed
+}
 
-		// --- 2. Aggregation ---
-		subCounts := make(map[string]int)
-		toolCounts := make(map[string]int)
-		matrix := make(map[string]map[string]int)
+// buildView aggregates the stored posts matching q (a keyword substring, ""
+// for all) into the page's KPIs, charts and table, ordered by sortKey
+// ("score" or "awards"). A non-zero days reads those daily files instead
+// of the data file.
+func (s *Server) buildView(q, sortKey string, days dayRange) DashboardView {
+	allPosts := s.posts()
+	if days.From != "" {
+		allPosts = s.dayPosts(days)
+	}
+	var filteredPosts []domain.Post
 
-		uniqueSubs := make(map[string]bool)
-		uniqueTools := make(map[string]bool)
-		highestScore := 0
+	// --- 1. Filtering Logic ---
+	query := q
+	if query != "" {
+		query = strings.ToLower(strings.TrimSpace(query))
+		for _, p := range allPosts {
+			// Check if the query matches any identified tool OR the title
+			match := false
 
-		for _, p := range posts {
-			if p.Score > highestScore {
-				highestScore = p.Score
+			// Check detected tools
+			for _, k := range p.KeywordsHit {
+				if strings.Contains(strings.ToLower(k), query) {
+					match = true
+					break
+				}
 			}
-			sub := p.Subreddit
 
-			subCounts[sub]++
-			uniqueSubs[sub] = true
+			// Optional: Also check detected title if you want broader search
+			// if strings.Contains(strings.ToLower(p.Title), query) { match = true }
 
-			if _, ok := matrix[sub]; !ok {
-				matrix[sub] = make(map[string]int)
-			}
-
-			for _, k := range p.KeywordsHit {
-				toolCounts[k]++
-				uniqueTools[k] = true
-				matrix[sub][k]++
+			if match {
+				filteredPosts = append(filteredPosts, p)
 			}
 		}
+	} else {
+		filteredPosts = allPosts
+	}
 
-		// --- 3. KPI Calculation ---
-		topTool := "N/A"
-		maxT := 0
-		for k, v := range toolCounts {
-			if v > maxT {
-				maxT = v
-				topTool = k
-			}
+	// Use filtered posts for the rest of the analysis
+	posts := filteredPosts
+
+	// loadData orders by score; ?sort=awards re-ranks the table
+	if sortKey == "awards" {
+		sort.SliceStable(posts, func(i, j int) bool { return posts[i].Awards > posts[j].Awards })
+	} else {
+		sortKey = "score"
+	}
+	showAwards := false
+	for _, p := range posts {
+		if p.Awards > 0 {
+			showAwards = true
+			break
 		}
+	}
+	showTargets := false
+	for _, p := range posts {
+		if len(p.MatchedTargets) > 1 || (len(p.MatchedTargets) == 1 && p.MatchedTargets[0] != p.Subreddit) {
+			showTargets = true
+			break
+		}
+	}
 
-		topSub := "N/A"
-		maxS := 0
-		for k, v := range subCounts {
-			if v > maxS {
-				maxS = v
-				topSub = k
-			}
+	// --- 2. Aggregation ---
+	subCounts := make(map[string]int)
+	toolCounts := make(map[string]int)
+	matrix := make(map[string]map[string]int)
+
+	uniqueSubs := make(map[string]bool)
+	uniqueTools := make(map[string]bool)
+	highestScore := 0
+
+	for _, p := range posts {
+		if p.Score > highestScore {
+			highestScore = p.Score
 		}
+		sub := displaySub(p.Subreddit, s.SubredditPrefix)
 
-		// --- 4. Chart Preparation ---
+		subCounts[sub]++
+		uniqueSubs[sub] = true
 
-		// Sort Subreddits (X-Axis) Alphabetically
-		var xSubs []string
-		for s := range uniqueSubs {
-			xSubs = append(xSubs, s)
+		if _, ok := matrix[sub]; !ok {
+			matrix[sub] = make(map[string]int)
 		}
-		sort.Strings(xSubs)
 
-		// Sort Tools (Series) Alphabetically
-		var tools []string
-		for t := range uniqueTools {
-			tools = append(tools, t)
+		for _, k := range p.KeywordsHit {
+			toolCounts[k]++
+			uniqueTools[k] = true
+			matrix[sub][k]++
 		}
-		sort.Strings(tools)
-
-		// Create Stacked Bar Chart
-		bar := charts.NewBar()
-		bar.SetGlobalOptions(
-			charts.WithInitializationOpts(opts.Initialization{
-				Theme:  types.ThemeWesteros,
-				Height: "500px",
-			}),
-			charts.WithTooltipOpts(opts.Tooltip{Show: boolPtr(true), Trigger: "axis", AxisPointer: &opts.AxisPointer{Type: "shadow"}}),
-			charts.WithLegendOpts(opts.Legend{Show: boolPtr(true), Bottom: "0"}),
-			charts.WithXAxisOpts(opts.XAxis{AxisLabel: &opts.AxisLabel{Rotate: 45}}),
-			charts.WithGridOpts(opts.Grid{Bottom: "15%", ContainLabel: boolPtr(true)}),
-		)
+	}
 
-		bar.SetXAxis(xSubs)
+	// --- 3. KPI Calculation ---
+	topTool := "N/A"
+	maxT := 0
+	for k, v := range toolCounts {
+		if v > maxT {
+			maxT = v
+			topTool = k
+		}
+	}
 
-		// Add a series for each Tool found
-		for _, tool := range tools {
-			var data []opts.BarData
-			for _, sub := range xSubs {
-				val := matrix[sub][tool]
-				data = append(data, opts.BarData{Value: val})
-			}
-			bar.AddSeries(tool, data).SetSeriesOptions(
-				charts.WithBarChartOpts(opts.BarChart{Stack: "total"}),
-			)
+	topSub := "N/A"
+	maxS := 0
+	for k, v := range subCounts {
+		if v > maxS {
+			maxS = v
+			topSub = k
 		}
+	}
+
+	// --- 4. Chart Preparation ---
+
+	// Sort Subreddits (X-Axis) Alphabetically
+	var xSubs []string
+	for s := range uniqueSubs {
+		xSubs = append(xSubs, s)
+	}
+	sort.Strings(xSubs)
+
+	// Sort Tools (Series) Alphabetically
+	var tools []string
+	for t := range uniqueTools {
+		tools = append(tools, t)
+	}
+	sort.Strings(tools)
+
+	// Post ages (posts without a timestamp are left out)
+	fresh := computeFreshness(posts, time.Now())
+	newestAge, medianAge := "N/A", "N/A"
+	if fresh.Dated > 0 {
+		newestAge, medianAge = formatAge(fresh.Newest), formatAge(fresh.Median)
+	}
+
+	// --- 5. Render ---
+	return DashboardView{
+		StackedBarSnippet: renderChart("mentions", func() snippetRenderer { return stackedBar(xSubs, tools, matrix, s.theme()) }),
+		AgeHistSnippet:    renderChart("post age", func() snippetRenderer { return ageHistogram(fresh, s.theme()) }),
+		Posts:             posts,
+		TotalMentions:     len(posts),
+		TopTool:           topTool,
+		TopSub:            topSub,
+		HighestScore:      highestScore,
+		NewestAge:         newestAge,
+		MedianAge:         medianAge,
+		ActiveFilter:      q,
+		Theme:             s.theme(),
+		Reach:             subredditReach(s.StatsFile, subCounts),
+		ShowAwards:        showAwards,
+		SortKey:           sortKey,
+
+		ShowTargets: showTargets,
+		Health:      targetHealth(s.HealthFile, s.StaleAfter, time.Now()),
+		Paused:      s.paused(),
+
+		LiveRefreshMS: s.liveRefresh(),
+
+		Daily: s.DailyDir != "",
+		From:  days.From,
+		To:    days.To,
+		Days:  days.label(),
+	}
+}
+
+// handleScrape enqueues an immediate scrape cycle
+func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Trigger == nil {
+		http.Error(w, "on-demand scrape is not available", http.StatusServiceUnavailable)
+		return
+	}
+	if reason := s.paused(); reason != "" {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "paused (" + reason + ")"})
+		return
+	}
+
+	base := s.BaseContext
+	if base == nil {
+		base = context.Background()
+	}
+	timeout := s.ScrapeTimeout
+	if timeout <= 0 {
+		timeout = DefaultScrapeTimeout
+	}
+	// The cycle itself runs under base; only our wait is bounded by timeout
+	// and by the client going away.
+	wait, cancel := context.WithTimeout(base, timeout)
+	defer cancel()
+
+	done := make(chan ScrapeResult, 1)
+	select {
+	case s.Trigger <- ScrapeRequest{Ctx: base, Done: done}:
+	default:
+		writeJSON(w, http.StatusConflict, map[string]string{"status": "scrape cycle already running"})
+		return
+	}
 
-		// --- 5. Render ---
-		view := DashboardView{
-			StackedBarSnippet: renderSnippet(bar),
-			Posts:             posts,
-			TotalMentions:     len(posts),
-			TopTool:           topTool,
-			TopSub:            topSub,
-			HighestScore:      highestScore,
-			ActiveFilter:      r.URL.Query().Get("q"),
+	select {
+	case res := <-done:
+		if res.Err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"status": "scrape cycle failed", "error": res.Err.Error(), "posts_added": res.PostsAdded})
+			return
 		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "scrape cycle complete", "posts_added": res.PostsAdded})
+	case <-r.Context().Done():
+		// Client went away; the cycle carries on regardless
+	case <-wait.Done():
+		if base.Err() != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down, scrape cycle cancelled"})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "scrape cycle still running"})
+	}
+}
 
-		w.Header().Set("Content-Type", "text/html")
-		tpl.Execute(w, view)
-	})
+// requireToken rejects requests that don't carry "Authorization: Bearer <AuthToken>"
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.AuthToken == "" {
+			http.Error(w, "endpoint disabled: DASHBOARD_TOKEN is not set", http.StatusForbidden)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.AuthToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
 
-	return http.ListenAndServe(":"+port, nil)
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
 }
 
 type snippetRenderer interface {
 	RenderSnippet() render.ChartSnippet
 }
 
-func renderSnippet(c snippetRenderer) template.HTML {
-	s := c.RenderSnippet()
+// chartUnavailable stands in for a chart that failed to build or render
+const chartUnavailable = template.HTML(`<div class="chart-unavailable">Chart unavailable</div>`)
+
+// renderChart builds and renders a chart snippet. A panic or an empty
+// snippet (go-echarts edge cases with unusual data) is logged and replaced
+// by a placeholder so the rest of the page still renders.
+func renderChart(name string, build func() snippetRenderer) (html template.HTML) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Chart rendering failed", "chart", name, "panic", r)
+			html = chartUnavailable
+		}
+	}()
+	s := build().RenderSnippet()
+	if s.Element == "" || s.Script == "" {
+		slog.Error("Chart rendering failed", "chart", name, "err", "empty snippet")
+		return chartUnavailable
+	}
 	return template.HTML(s.Element + "\n" + s.Script)
 }
 
-func loadData(path string) []domain.Post {
-	f, err := os.Open(path)
-	if err != nil {
-		return []domain.Post{}
+// stackedBar charts keyword mentions per subreddit, one series per keyword
+func stackedBar(xSubs, tools []string, matrix map[string]map[string]int, theme string) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{
+			Theme:  theme,
+			Height: "500px",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: boolPtr(true), Trigger: "axis", AxisPointer: &opts.AxisPointer{Type: "shadow"}}),
+		charts.WithLegendOpts(opts.Legend{Show: boolPtr(true), Bottom: "0"}),
+		charts.WithXAxisOpts(opts.XAxis{AxisLabel: &opts.AxisLabel{Rotate: 45}}),
+		charts.WithGridOpts(opts.Grid{Bottom: "15%", ContainLabel: boolPtr(true)}),
+	)
+
+	bar.SetXAxis(xSubs)
+
+	// Add a series for each Tool found
+	for _, tool := range tools {
+		var data []opts.BarData
+		for _, sub := range xSubs {
+			val := matrix[sub][tool]
+			data = append(data, opts.BarData{Value: val})
+		}
+		bar.AddSeries(tool, data).SetSeriesOptions(
+			charts.WithBarChartOpts(opts.BarChart{Stack: "total"}),
+		)
 	}
-	defer f.Close()
+	return bar
+}
 
-	var posts []domain.Post
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		if len(scanner.Bytes()) == 0 {
-			continue
-		}
-		var p domain.Post
-		if err := json.Unmarshal(scanner.Bytes(), &p); err == nil {
-			posts = append(posts, p)
-		}
+func loadData(path string) []domain.Post {
+	posts, err := storage.LoadPosts(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Data file read stopped early", "path", path, "err", err)
+	}
+	if posts == nil {
+		return []domain.Post{}
 	}
 	sort.Slice(posts, func(i, j int) bool { return posts[i].Score > posts[j].Score })
 	return posts
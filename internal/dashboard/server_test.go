@@ -0,0 +1,172 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/render"
+)
+
+func TestBasePathRoutes(t *testing.T) {
+	s := &Server{DataFile: writeDataFile(t, mention("a", time.Now(), "splunk")), BasePath: "/reddit/", LocalAssets: true}
+	h := s.Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	page := get("/reddit/")
+	if page.Code != http.StatusOK {
+		t.Fatalf("GET /reddit/ = %d, want 200", page.Code)
+	}
+	body := page.Body.String()
+	for _, want := range []string{`action="/reddit/"`, `src="/reddit/assets/echarts.min.js"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page is missing %s", want)
+		}
+	}
+
+	search := get("/reddit/?q=splunk")
+	if search.Code != http.StatusOK || !strings.Contains(search.Body.String(), `href="/reddit/"`) {
+		t.Errorf("search under the prefix = %d, want 200 with a Clear link to /reddit/", search.Code)
+	}
+	if rec := get("/reddit/api/keyword/splunk/trend"); rec.Code != http.StatusOK {
+		t.Errorf("trend API under the prefix = %d, want 200", rec.Code)
+	}
+	if rec := get("/reddit/assets/README.md"); rec.Code != http.StatusOK {
+		t.Errorf("assets under the prefix = %d, want 200", rec.Code)
+	}
+	// The bare prefix redirects so relative links resolve beneath it
+	if rec := get("/reddit"); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/reddit/" {
+		t.Errorf("GET /reddit = %d to %q, want a redirect to /reddit/", rec.Code, rec.Header().Get("Location"))
+	}
+	// Nothing is served outside the prefix
+	for _, path := range []string{"/", "/api/keyword/splunk/trend"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestCleanBasePath(t *testing.T) {
+	tests := map[string]string{
+		"":           "",
+		"/":          "",
+		"reddit":     "/reddit",
+		"/reddit/":   "/reddit",
+		" /a/b/ ":    "/a/b",
+		"//reddit//": "/reddit",
+	}
+	for in, want := range tests {
+		if got := CleanBasePath(in); got != want {
+			t.Errorf("CleanBasePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDashboardLinksThreadAndSource(t *testing.T) {
+	link := mention("lnk1", time.Now(), "splunk")
+	link.Permalink = "https://reddit.com/r/netsec/comments/lnk1/x/"
+	link.URL = "https://research.example.com/splunk-rules"
+	self := mention("slf1", time.Now(), "splunk")
+	self.IsSelf = true
+	self.Permalink = "https://reddit.com/r/netsec/comments/slf1/y/"
+	self.URL = "https://www.reddit.com/r/netsec/comments/slf1/y/"
+
+	rec := httptest.NewRecorder()
+	(&Server{DataFile: writeDataFile(t, link, self)}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	page := rec.Body.String()
+
+	for _, want := range []string{
+		`href="https://reddit.com/r/netsec/comments/lnk1/x/"`,
+		`href="https://reddit.com/r/netsec/comments/slf1/y/"`,
+		`href="https://research.example.com/splunk-rules" target="_blank" class="source"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page is missing %s", want)
+		}
+	}
+	if strings.Count(page, `class="source"`) != 1 {
+		t.Error("a self post got a separate source link")
+	}
+}
+
+func TestLocalAssets(t *testing.T) {
+	dataFile := writeDataFile(t, mention("a", time.Now(), "splunk"))
+	get := func(s *Server, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// The CDN stays the default and /assets/ falls through to the page
+	cdn := &Server{DataFile: dataFile}
+	if body := get(cdn, "/").Body.String(); !strings.Contains(body, `src="`+cdnAssets+`echarts.min.js"`) {
+		t.Error("default page doesn't load echarts from the CDN")
+	}
+	if rec := get(cdn, "/assets/README.md"); strings.Contains(rec.Body.String(), "Embedded chart assets") {
+		t.Error("GET /assets/ without LocalAssets served an embedded file")
+	}
+
+	local := &Server{DataFile: dataFile, LocalAssets: true}
+	body := get(local, "/").Body.String()
+	for _, want := range []string{`src="/assets/echarts.min.js"`, `src="/assets/themes/` + defaultTheme + `.js"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("LocalAssets page is missing %s", want)
+		}
+	}
+	if strings.Contains(body, cdnAssets) {
+		t.Error("LocalAssets page still loads from the CDN")
+	}
+
+	if !HasLocalAssets("") {
+		t.Skip("chart scripts not vendored; run go generate ./internal/dashboard")
+	}
+	for _, path := range []string{"/assets/echarts.min.js", "/assets/themes/" + defaultTheme + ".js"} {
+		rec := get(local, path)
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s = %d with %d bytes, want non-empty JS", path, rec.Code, rec.Body.Len())
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+			t.Errorf("GET %s Content-Type = %q, want JavaScript", path, ct)
+		}
+	}
+}
+
+// brokenChart renders a snippet missing its script, as go-echarts can with
+// unusual data
+type brokenChart struct{}
+
+func (brokenChart) RenderSnippet() render.ChartSnippet {
+	return render.ChartSnippet{Element: `<div id="broken"></div>`}
+}
+
+func TestRenderChartFallsBack(t *testing.T) {
+	panics := func() snippetRenderer { panic("bad series") }
+	if got := renderChart("mentions", panics); got != chartUnavailable {
+		t.Errorf("panicking chart rendered %q, want the placeholder", got)
+	}
+	if got := renderChart("mentions", func() snippetRenderer { return brokenChart{} }); got != chartUnavailable {
+		t.Errorf("empty script rendered %q, want the placeholder", got)
+	}
+}
+
+func TestPageServesTableWhenChartFails(t *testing.T) {
+	s := &Server{DataFile: writeDataFile(t, mention("CrowdStrike outage thread", time.Now(), "crowdstrike"))}
+	view := s.buildView("", "", dayRange{})
+	view.StackedBarSnippet = renderChart("mentions", func() snippetRenderer { panic("bad series") })
+
+	var page strings.Builder
+	if err := s.pageTemplate().Execute(&page, view); err != nil {
+		t.Fatalf("page failed to render: %v", err)
+	}
+	for _, want := range []string{"Chart unavailable", "<table>", "CrowdStrike outage thread"} {
+		if !strings.Contains(page.String(), want) {
+			t.Errorf("page is missing %q", want)
+		}
+	}
+}
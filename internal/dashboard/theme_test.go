@@ -0,0 +1,50 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/types"
+)
+
+func TestChartsUseSelectedTheme(t *testing.T) {
+	bar := stackedBar([]string{"netsec"}, []string{"splunk"}, map[string]map[string]int{"netsec": {"splunk": 2}}, types.ThemeMacarons)
+	if bar.Initialization.Theme != types.ThemeMacarons {
+		t.Errorf("stacked bar theme = %q, want macarons", bar.Initialization.Theme)
+	}
+	if hist := ageHistogram(freshness{}, types.ThemeShine); hist.Initialization.Theme != types.ThemeShine {
+		t.Errorf("age histogram theme = %q, want shine", hist.Initialization.Theme)
+	}
+}
+
+func TestDashboardRendersTheme(t *testing.T) {
+	path := writeDataFile(t, mention("a", time.Now(), "splunk"))
+	render := func(theme string) string {
+		rec := httptest.NewRecorder()
+		(&Server{DataFile: path, Theme: theme}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Body.String()
+	}
+
+	if page := render("macarons"); !strings.Contains(page, `"macarons"`) || strings.Contains(page, `"westeros"`) {
+		t.Error("DASHBOARD_THEME=macarons isn't applied to the charts")
+	}
+	if page := render(""); !strings.Contains(page, `"westeros"`) {
+		t.Error("the default theme isn't westeros")
+	}
+}
+
+func TestValidTheme(t *testing.T) {
+	for _, name := range []string{"westeros", "macarons", "shine", "vintage"} {
+		if !ValidTheme(name) {
+			t.Errorf("ValidTheme(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"", "Westeros", "solarized"} {
+		if ValidTheme(name) {
+			t.Errorf("ValidTheme(%q) = true", name)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package dashboard
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// defaultTrendDays is the window /api/keyword/{term}/trend covers without ?days=
+const defaultTrendDays = 30
+
+type trendPoint struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type trendResponse struct {
+	Keyword string       `json:"keyword"`
+	Days    int          `json:"days"`
+	Series  []trendPoint `json:"series"`
+}
+
+// handleKeywordTrend serves daily mention counts for one keyword, read from
+// the accumulated data file
+func (s *Server) handleKeywordTrend(w http.ResponseWriter, r *http.Request) {
+	term := r.PathValue("term")
+	days := defaultTrendDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 365 {
+			http.Error(w, "days must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	writeJSON(w, http.StatusOK, trendResponse{
+		Keyword: term,
+		Days:    days,
+		Series:  keywordTrend(s.posts(), term, days, time.Now()),
+	})
+}
+
+// keywordTrend counts distinct posts per UTC day (by creation date) that hit
+// term over the last days days. The series is zero-filled so it charts
+// cleanly, but is empty when the keyword has no mentions in the window.
+func keywordTrend(posts []domain.Post, term string, days int, now time.Time) []trendPoint {
+	term = strings.ToLower(strings.TrimSpace(term))
+	today := now.UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	total := 0
+	for _, p := range posts {
+		if p.CreatedUTC <= 0 || seen[p.ID] || !hitsKeyword(p, term) {
+			continue
+		}
+		created := p.CreatedTime()
+		if created.Before(start) || !created.Before(today.AddDate(0, 0, 1)) {
+			continue
+		}
+		// The data file is append-only, so a post can appear once per cycle
+		seen[p.ID] = true
+		counts[created.Format("2006-01-02")]++
+		total++
+	}
+
+	series := []trendPoint{}
+	if total == 0 {
+		return series
+	}
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		series = append(series, trendPoint{Date: day, Count: counts[day]})
+	}
+	return series
+}
+
+func hitsKeyword(p domain.Post, term string) bool {
+	for _, k := range p.KeywordsHit {
+		if strings.ToLower(k) == term {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// writeDataFile writes posts as an NDJSON data file and returns its path
+func writeDataFile(t *testing.T, posts ...domain.Post) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "current.ndjson")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	for _, p := range posts {
+		if err := enc.Encode(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// mention is a post hitting keywords, created at the given time
+func mention(id string, created time.Time, keywords ...string) domain.Post {
+	return domain.Post{ID: id, Title: id, CreatedUTC: float64(created.Unix()), KeywordsHit: keywords}
+}
+
+func TestKeywordTrendSeries(t *testing.T) {
+	now := time.Date(2025, 6, 10, 15, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	posts := []domain.Post{
+		mention("a", day(0), "splunk"),
+		mention("b", day(0), "Splunk", "misp"),
+		mention("a", day(0), "splunk"), // the same post from a later cycle
+		mention("c", day(2), "splunk"),
+		mention("d", day(3), "splunk"), // outside a 3 day window
+		mention("e", day(1), "misp"),
+		{ID: "f", KeywordsHit: []string{"splunk"}}, // undated
+	}
+
+	got := keywordTrend(posts, " SPLUNK ", 3, now)
+	want := []trendPoint{{"2025-06-08", 1}, {"2025-06-09", 0}, {"2025-06-10", 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("series = %v, want %v", got, want)
+	}
+	if got := keywordTrend(posts, "elastic", 3, now); got == nil || len(got) != 0 {
+		t.Errorf("unknown keyword = %#v, want an empty series", got)
+	}
+}
+
+func TestKeywordTrendEndpoint(t *testing.T) {
+	now := time.Now()
+	srv := &Server{DataFile: writeDataFile(t,
+		mention("a", now, "splunk"),
+		mention("b", now.AddDate(0, 0, -1), "splunk"),
+		mention("c", now.AddDate(0, 0, -40), "splunk"),
+	)}
+	get := func(target string) (*httptest.ResponseRecorder, trendResponse) {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var resp trendResponse
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, resp
+	}
+
+	rec, resp := get("/api/keyword/splunk/trend")
+	if rec.Code != http.StatusOK || resp.Days != defaultTrendDays || len(resp.Series) != defaultTrendDays {
+		t.Fatalf("default window: status %d, %d days, %d points", rec.Code, resp.Days, len(resp.Series))
+	}
+	total := 0
+	for _, p := range resp.Series {
+		total += p.Count
+	}
+	if total != 2 {
+		t.Errorf("default window counted %d mentions, want 2", total)
+	}
+
+	if _, resp := get("/api/keyword/splunk/trend?days=60"); len(resp.Series) != 60 {
+		t.Errorf("days=60 gave %d points", len(resp.Series))
+	}
+	if rec, resp := get("/api/keyword/elastic/trend"); rec.Code != http.StatusOK || resp.Series == nil || len(resp.Series) != 0 {
+		t.Errorf("unknown keyword: status %d, body %s; want 200 and an empty series", rec.Code, rec.Body)
+	}
+	if rec, _ := get("/api/keyword/splunk/trend?days=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("days=0: status %d, want 400", rec.Code)
+	}
+}
@@ -1,14 +1,70 @@
 package domain
 
-import "context"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// TargetKind says which kind of Reddit listing a Target points at
+type TargetKind string
+
+const (
+	KindSubreddit TargetKind = "subreddit"
+	KindMulti     TargetKind = "multi"
+)
+
+// PostKind classifies what a post is: a text discussion, an external link or hosted media
+type PostKind string
+
+const (
+	PostSelf    PostKind = "self"
+	PostLink    PostKind = "link"
+	PostImage   PostKind = "image"
+	PostVideo   PostKind = "video"
+	PostGallery PostKind = "gallery"
+)
+
+// ValidPostKind reports whether k is a known PostKind
+func ValidPostKind(k PostKind) bool {
+	switch k {
+	case PostSelf, PostLink, PostImage, PostVideo, PostGallery:
+		return true
+	}
+	return false
+}
 
 // Target represents a scraping task
 type Target struct {
+	Kind      TargetKind
 	Subreddit string
-	MinScore  int
+	// Owner and Multi identify a multireddit (/user/<Owner>/m/<Multi>)
+	Owner    string
+	Multi    string
+	MinScore int
+	// Sorts overrides the global listing sorts for this target (e.g. new, hot)
+	Sorts []string
+	// Kinds overrides the global post kind allowlist for this target
+	Kinds []PostKind
+	// Interval overrides how often the target is polled in monitor mode
+	// (SCRAPE_INTERVAL); 0 uses the global interval
+	Interval time.Duration
+}
+
+// Name returns a human-readable identifier for logs
+func (t Target) Name() string {
+	if t.Kind == KindMulti {
+		return fmt.Sprintf("user/%s/m/%s", t.Owner, t.Multi)
+	}
+	return t.Subreddit
 }
 
-// Post is the clean data structure for storage
+// Post is the clean data structure for storage. Changing its JSON shape
+// means bumping storage.SchemaVersion.
 type Post struct {
 	ID           string   `json:"id"`
 	Title        string   `json:"title"`
@@ -18,10 +74,185 @@ type Post struct {
 	Score        int      `json:"score"`
 	CommentCount int      `json:"comment_count"`
 	CreatedUTC   float64  `json:"created_utc"`
+	Awards       int      `json:"awards,omitempty"`
+	IsSelf       bool     `json:"is_self"`
+	Kind         PostKind `json:"kind,omitempty"`
 	KeywordsHit  []string `json:"keywords_hit,omitempty"`
+	// Removed marks posts deleted by their author or removed by moderators
+	Removed bool `json:"removed,omitempty"`
+	// MediaURLs lists a gallery post's images (URL points at the gallery itself)
+	MediaURLs []string `json:"media_urls,omitempty"`
+	// CommentKeywordsHit are keywords found in the post's comments (COMMENT_ENRICH)
+	CommentKeywordsHit []string `json:"comment_keywords_hit,omitempty"`
+	// MatchedTargets names the target(s) that surfaced and kept the post, e.g.
+	// "netsec" or "user/someuser/m/security"; near-duplicates merged by
+	// DEDUP_TITLES contribute theirs to the post that is kept
+	MatchedTargets []string `json:"matched_targets,omitempty"`
+	// ScrapedAt is when the post was first captured (UTC, whole seconds so it
+	// serializes as plain RFC3339). Merges keep the earliest value.
+	ScrapedAt time.Time `json:"scraped_at,omitzero"`
+	// UpvoteRatio is the share of votes that are upvotes (0-1); 0 means the
+	// source didn't report one
+	UpvoteRatio float64 `json:"upvote_ratio,omitempty"`
+	// NSFW is Reddit's over_18 flag
+	NSFW bool `json:"nsfw,omitempty"`
+	// Distinguished is "moderator" or "admin" for posts made in an official
+	// capacity (announcements and the like), empty for ordinary posts
+	Distinguished string `json:"distinguished,omitempty"`
+	// Edited marks posts changed by their author after submission, so the
+	// stored title may differ from the live one; EditedUTC is when (epoch
+	// seconds), 0 if Reddit didn't say
+	Edited    bool    `json:"edited,omitempty"`
+	EditedUTC float64 `json:"edited_utc,omitempty"`
+	// ContentHash fingerprints the title, self text and link URL (see the
+	// collector's contentHash), so crossposts share it and edits change it
+	ContentHash string `json:"content_hash,omitempty"`
+	// Lang is the title's detected language (LANG_DETECT), e.g. "en" or "unknown"
+	Lang string `json:"lang,omitempty"`
+	// Permalink is always the Reddit thread, while URL is the link target
+	// (the thread itself only for self posts)
+	Permalink string `json:"permalink,omitempty"`
+	// Raw is the untouched source JSON, only set when RAW_CAPTURE is enabled
+	Raw json.RawMessage `json:"raw,omitempty"`
+}
+
+// Fullname is the post's Reddit "thing" name (t3_<id>), used as a listing cursor
+func (p Post) Fullname() string {
+	return "t3_" + p.ID
+}
+
+// CreatedTime converts the CreatedUTC epoch into a time.Time (UTC). The
+// fractional part is kept as nanoseconds rather than truncated away.
+func (p Post) CreatedTime() time.Time {
+	sec, frac := math.Modf(p.CreatedUTC)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// EditedTime converts EditedUTC like CreatedTime; zero when it's unknown
+func (p Post) EditedTime() time.Time {
+	if p.EditedUTC <= 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(p.EditedUTC)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// MarshalJSON adds a readable RFC3339 "created_at" next to the "created_utc"
+// epoch that existing consumers read
+func (p Post) MarshalJSON() ([]byte, error) {
+	type postAlias Post // drops the method set so this doesn't recurse
+	out := struct {
+		postAlias
+		CreatedAt string `json:"created_at,omitempty"`
+	}{postAlias: postAlias(p)}
+	if p.CreatedUTC > 0 {
+		out.CreatedAt = p.CreatedTime().Format(time.RFC3339)
+	}
+
+	// Encode without HTML escaping; callers that want it (json.Marshal) still
+	// get it applied on top, while the writer can keep Raw byte-identical
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Comment is one flattened comment from a post's comment tree
+type Comment struct {
+	ID       string `json:"id"`
+	PostID   string `json:"post_id"`
+	ParentID string `json:"parent_id"`
+	Author   string `json:"author"`
+	Body     string `json:"body"`
+	Score    int    `json:"score"`
+	// Depth is 0 for top-level comments, 1 for their replies, ...
+	Depth      int     `json:"depth"`
+	CreatedUTC float64 `json:"created_utc"`
+}
+
+// CommentFetcher is implemented by collectors that can read a post's comments.
+// It's kept apart from Collector because comment requests are costly and
+// optional (COMMENT_ENRICH).
+type CommentFetcher interface {
+	// FetchComments returns up to limit comments of the post with the given
+	// ID (no "t3_" prefix), flattened depth-first
+	FetchComments(ctx context.Context, postID string, limit int) ([]Comment, error)
+	// FetchCommentsByURL reads every comment Reddit returns for a thread
+	// permalink. truncated counts replies hidden behind "load more" stubs.
+	FetchCommentsByURL(ctx context.Context, permalink string) (comments []Comment, truncated int, err error)
+}
+
+// SubredditStats is the size of a subreddit, used to put mention counts in context
+type SubredditStats struct {
+	Subreddit   string    `json:"subreddit"`
+	Subscribers int       `json:"subscribers"`
+	ActiveUsers int       `json:"active_users"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// TargetHealth records when a target was last attempted and last fetched
+// successfully. Failed fetches only move LastAttempt.
+type TargetHealth struct {
+	Target      string    `json:"target"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Stale reports whether the target has gone longer than after without a
+// successful fetch as of now. A target that never succeeded is stale once
+// it has been attempted; after <= 0 disables the check.
+func (h TargetHealth) Stale(now time.Time, after time.Duration) bool {
+	if after <= 0 || h.LastAttempt.IsZero() {
+		return false
+	}
+	if h.LastSuccess.IsZero() {
+		return true
+	}
+	return now.Sub(h.LastSuccess) > after
+}
+
+// StatsFetcher is implemented by collectors that can read a subreddit's about page
+type StatsFetcher interface {
+	FetchSubredditStats(ctx context.Context, subreddit string) (SubredditStats, error)
+}
+
+// SubredditKey normalises a subreddit name ("r/NetSec", "netsec") for lookups
+func SubredditKey(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "r/"))
 }
 
 // Collector defines the interface for data fetching
 type Collector interface {
 	FetchNewPosts(ctx context.Context, subreddit string, limit int) ([]Post, error)
+	// FetchPosts fetches a subreddit listing in the given sort (see ValidSort)
+	FetchPosts(ctx context.Context, subreddit, sort string, limit int) ([]Post, error)
+	FetchMultiPosts(ctx context.Context, owner, multi, sort string, limit int) ([]Post, error)
+	// FetchNewSince returns /new posts newer than the before fullname and the
+	// cursor for the next poll. An empty before fetches the full limit.
+	FetchNewSince(ctx context.Context, subreddit, before string, limit int) ([]Post, string, error)
+	// CheckSubreddit reports whether a subreddit exists and can be read with
+	// the current credentials. For an existing but inaccessible subreddit err
+	// carries the reason when Reddit reveals it (private, banned, quarantined).
+	CheckSubreddit(ctx context.Context, subreddit string) (exists, accessible bool, err error)
+}
+
+// CombinedFetcher is implemented by collectors that can read several
+// subreddits' /new listings in one request (/r/a+b+c/new). The limit applies
+// to the merged listing, so busy subreddits can crowd out quiet ones, and
+// each post's Subreddit is its real subreddit, not the combined name.
+type CombinedFetcher interface {
+	FetchCombined(ctx context.Context, subreddits []string, limit int) ([]Post, error)
+}
+
+// ValidSort reports whether s is a listing sort Reddit understands
+func ValidSort(s string) bool {
+	switch s {
+	case "new", "hot", "rising", "top", "controversial":
+		return true
+	}
+	return false
 }
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTargetHealthStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		h     TargetHealth
+		after time.Duration
+		want  bool
+	}{
+		{"never attempted", TargetHealth{}, time.Hour, false},
+		{"never succeeded", TargetHealth{LastAttempt: now}, time.Hour, true},
+		{"recent success", TargetHealth{LastAttempt: now, LastSuccess: now.Add(-30 * time.Minute)}, time.Hour, false},
+		{"old success, failing since", TargetHealth{LastAttempt: now, LastSuccess: now.Add(-2 * time.Hour), LastError: "503"}, time.Hour, true},
+		{"check disabled", TargetHealth{LastAttempt: now}, 0, false},
+	}
+	for _, tt := range tests {
+		if got := tt.h.Stale(now, tt.after); got != tt.want {
+			t.Errorf("%s: Stale = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTargetHealthOmitsZeroLastSuccess(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	data, err := json.Marshal(TargetHealth{Target: "netsec", LastAttempt: now, LastError: "timeout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "last_success") {
+		t.Errorf("never-succeeded target encoded %s, want last_success omitted", data)
+	}
+
+	data, _ = json.Marshal(TargetHealth{Target: "netsec", LastAttempt: now, LastSuccess: now})
+	var back TargetHealth
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.LastSuccess.Equal(now) {
+		t.Errorf("LastSuccess round-tripped as %v, want %v", back.LastSuccess, now)
+	}
+}
+
+func TestPostCreatedTime(t *testing.T) {
+	tests := []struct {
+		epoch float64
+		want  time.Time
+	}{
+		{1700000000, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		// Fractional seconds are kept, not truncated
+		{1700000000.25, time.Date(2023, 11, 14, 22, 13, 20, 250_000_000, time.UTC)},
+		{0, time.Unix(0, 0).UTC()},
+	}
+	for _, tt := range tests {
+		if got := (Post{CreatedUTC: tt.epoch}).CreatedTime(); !got.Equal(tt.want) || got.Location() != time.UTC {
+			t.Errorf("CreatedTime(%v) = %v, want %v", tt.epoch, got, tt.want)
+		}
+	}
+}
+
+func TestPostJSONHasBothTimestamps(t *testing.T) {
+	data, err := json.Marshal(Post{ID: "p1", CreatedUTC: 1700000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["created_utc"] != 1700000000.0 || got["created_at"] != "2023-11-14T22:13:20Z" {
+		t.Errorf("encoded %s, want created_utc and created_at", data)
+	}
+
+	// created_at is output only; reading it back leaves the epoch intact
+	var back Post
+	if err := json.Unmarshal(data, &back); err != nil || back.CreatedUTC != 1700000000 {
+		t.Errorf("decoded CreatedUTC = %v (%v), want 1700000000", back.CreatedUTC, err)
+	}
+
+	data, _ = json.Marshal(Post{ID: "p2"})
+	if strings.Contains(string(data), "created_at") {
+		t.Errorf("encoded %s, want no created_at without a CreatedUTC", data)
+	}
+}
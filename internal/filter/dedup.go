@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// DefaultTitleThreshold is deliberately conservative: titles must share almost
+// all of their words before they're treated as reposts of each other.
+const DefaultTitleThreshold = 0.85
+
+// minDedupTokens keeps short, generic titles ("Help needed") from being
+// collapsed into each other just because they're short.
+const minDedupTokens = 4
+
+// DedupTitles drops near-duplicate posts, keeping the highest-scoring variant.
+// Two titles are near-duplicates when the Jaccard similarity of their
+// normalized word sets is >= threshold. Input order is preserved. A dropped
+// post's MatchedTargets and ScrapedAt (earliest wins) are merged into the
+// variant that replaces it.
+func DedupTitles(posts []domain.Post, threshold float64) []domain.Post {
+	type entry struct {
+		idx    int
+		tokens map[string]bool
+	}
+
+	// Visit best-scoring posts first so they win over their variants
+	order := make([]int, len(posts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return posts[order[a]].Score > posts[order[b]].Score })
+
+	var kept []entry
+	drop := make(map[int]bool)
+	merged := make(map[int][]string)
+	firstSeen := make(map[int]time.Time)
+	for _, i := range order {
+		tokens := titleTokens(posts[i].Title)
+		if len(tokens) >= minDedupTokens {
+			for _, k := range kept {
+				if len(k.tokens) >= minDedupTokens && jaccard(tokens, k.tokens) >= threshold {
+					drop[i] = true
+					merged[k.idx] = appendNew(merged[k.idx], posts[i].MatchedTargets...)
+					firstSeen[k.idx] = EarliestTime(firstSeen[k.idx], posts[i].ScrapedAt)
+					break
+				}
+			}
+		}
+		if !drop[i] {
+			kept = append(kept, entry{idx: i, tokens: tokens})
+		}
+	}
+
+	result := make([]domain.Post, 0, len(kept))
+	for i, p := range posts {
+		if !drop[i] {
+			if extra := merged[i]; len(extra) > 0 {
+				p.MatchedTargets = appendNew(slices.Clone(p.MatchedTargets), extra...)
+			}
+			p.ScrapedAt = EarliestTime(p.ScrapedAt, firstSeen[i])
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// appendNew appends the values not already in list
+func appendNew(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// titleTokens lowercases the title and splits it into a set of words
+func titleTokens(title string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	inter := 0
+	for w := range a {
+		if b[w] {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
@@ -0,0 +1,61 @@
+package filter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func postIDs(posts []domain.Post) []string {
+	var ids []string
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestDedupTitlesCollapsesNearDuplicates(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "a", Score: 5, Title: "New ransomware strain targets VMware ESXi servers in Europe"},
+		{ID: "b", Score: 40, Title: "New ransomware strain targets VMware ESXi servers in Europe!"},
+		{ID: "c", Score: 12, Title: "[News] new ransomware strain targets VMware ESXi servers in Europe", MatchedTargets: []string{"blueteamsec"}},
+	}
+	posts[1].MatchedTargets = []string{"netsec"}
+	got := DedupTitles(posts, DefaultTitleThreshold)
+	if ids := postIDs(got); !slices.Equal(ids, []string{"b"}) {
+		t.Fatalf("kept %v, want only the highest-scoring variant b", ids)
+	}
+	if want := []string{"netsec", "blueteamsec"}; !slices.Equal(got[0].MatchedTargets, want) {
+		t.Errorf("MatchedTargets = %v, want %v", got[0].MatchedTargets, want)
+	}
+}
+
+func TestDedupTitlesKeepsDistinctPosts(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "a", Score: 10, Title: "New ransomware strain targets VMware ESXi servers"},
+		{ID: "b", Score: 20, Title: "New ransomware strain targets Citrix NetScaler appliances"},
+		{ID: "c", Score: 30, Title: "Help needed"},
+		{ID: "d", Score: 40, Title: "Help needed!"},
+		{ID: "e", Score: 50, Title: "Splunk vs Elastic for a small SOC"},
+	}
+	got := DedupTitles(posts, DefaultTitleThreshold)
+	// Short titles are never collapsed, and input order is preserved
+	if ids := postIDs(got); !slices.Equal(ids, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("kept %v, want every post", ids)
+	}
+}
+
+func TestDedupTitlesThreshold(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "a", Score: 2, Title: "Weekly threat intel roundup for analysts and hunters"},
+		{ID: "b", Score: 1, Title: "Weekly threat intel roundup for analysts"},
+	}
+	// 6 of 8 words shared: below the default, above a looser threshold
+	if ids := postIDs(DedupTitles(posts, DefaultTitleThreshold)); len(ids) != 2 {
+		t.Errorf("default threshold kept %v, want both", ids)
+	}
+	if ids := postIDs(DedupTitles(posts, 0.7)); !slices.Equal(ids, []string{"a"}) {
+		t.Errorf("threshold 0.7 kept %v, want a", ids)
+	}
+}
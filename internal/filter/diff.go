@@ -0,0 +1,105 @@
+package filter
+
+import (
+	"sort"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// PostDiff is what changed between two captures of the data file
+type PostDiff struct {
+	Added   []domain.Post `json:"added"`
+	Removed []domain.Post `json:"removed"`
+	Changed []ScoreChange `json:"score_changed"`
+	// KeywordDeltas is new minus old mentions per keyword, nonzero only
+	KeywordDeltas map[string]int `json:"keyword_deltas"`
+}
+
+// ScoreChange is a post present in both captures with a different score
+type ScoreChange struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Subreddit string `json:"subreddit"`
+	Old       int    `json:"old_score"`
+	New       int    `json:"new_score"`
+}
+
+// DiffPosts compares two captures by post ID. A post stored more than once
+// in a capture counts once, with its last (most recent) record. Added and
+// Removed keep file order; Changed is ordered by the size of the change.
+func DiffPosts(old, cur []domain.Post) PostDiff {
+	oldByID := latestByID(old)
+	curByID := latestByID(cur)
+
+	oldPosts, curPosts := uniqueInOrder(old, oldByID), uniqueInOrder(cur, curByID)
+
+	d := PostDiff{KeywordDeltas: make(map[string]int)}
+	for _, p := range curPosts {
+		o, ok := oldByID[p.ID]
+		if !ok {
+			d.Added = append(d.Added, p)
+		} else if o.Score != p.Score {
+			d.Changed = append(d.Changed, ScoreChange{ID: p.ID, Title: p.Title, Subreddit: p.Subreddit, Old: o.Score, New: p.Score})
+		}
+	}
+	for _, p := range oldPosts {
+		if _, ok := curByID[p.ID]; !ok {
+			d.Removed = append(d.Removed, p)
+		}
+	}
+	sort.SliceStable(d.Changed, func(i, j int) bool {
+		return abs(d.Changed[i].New-d.Changed[i].Old) > abs(d.Changed[j].New-d.Changed[j].Old)
+	})
+
+	for k, n := range KeywordCounts(curPosts) {
+		d.KeywordDeltas[k] += n
+	}
+	for k, n := range KeywordCounts(oldPosts) {
+		d.KeywordDeltas[k] -= n
+	}
+	for k, n := range d.KeywordDeltas {
+		if n == 0 {
+			delete(d.KeywordDeltas, k)
+		}
+	}
+	return d
+}
+
+// KeywordCounts counts the posts mentioning each keyword
+func KeywordCounts(posts []domain.Post) map[string]int {
+	counts := make(map[string]int)
+	for _, p := range posts {
+		for _, k := range p.KeywordsHit {
+			counts[k]++
+		}
+	}
+	return counts
+}
+
+func latestByID(posts []domain.Post) map[string]domain.Post {
+	byID := make(map[string]domain.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+	}
+	return byID
+}
+
+// uniqueInOrder lists each ID's record from byID once, at its first position
+func uniqueInOrder(posts []domain.Post, byID map[string]domain.Post) []domain.Post {
+	seen := make(map[string]bool, len(byID))
+	out := make([]domain.Post, 0, len(byID))
+	for _, p := range posts {
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			out = append(out, byID[p.ID])
+		}
+	}
+	return out
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
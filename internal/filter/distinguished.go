@@ -0,0 +1,31 @@
+package filter
+
+// Distinguished filtering policies (FILTER_DISTINGUISHED), for posts made by
+// moderators or admins in an official capacity
+const (
+	DistinguishedInclude = "include" // keep posts regardless of the flag (default)
+	DistinguishedExclude = "exclude" // drop distinguished posts
+	DistinguishedOnly    = "only"    // keep only distinguished posts
+)
+
+// ValidDistinguishedPolicy reports whether policy is a known FILTER_DISTINGUISHED value
+func ValidDistinguishedPolicy(policy string) bool {
+	switch policy {
+	case DistinguishedInclude, DistinguishedExclude, DistinguishedOnly:
+		return true
+	}
+	return false
+}
+
+// KeepDistinguished reports whether a post with the given distinguished
+// value ("moderator", "admin", or empty) passes policy. Unknown policies
+// behave like DistinguishedInclude.
+func KeepDistinguished(policy, distinguished string) bool {
+	switch policy {
+	case DistinguishedExclude:
+		return distinguished == ""
+	case DistinguishedOnly:
+		return distinguished != ""
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package filter
+
+import "testing"
+
+func TestKeepDistinguished(t *testing.T) {
+	tests := []struct {
+		policy              string
+		organic, mod, admin bool
+	}{
+		{DistinguishedInclude, true, true, true},
+		{DistinguishedExclude, true, false, false},
+		{DistinguishedOnly, false, true, true},
+		// Unknown policies fall back to include
+		{"", true, true, true},
+	}
+	for _, tt := range tests {
+		for status, want := range map[string]bool{"": tt.organic, "moderator": tt.mod, "admin": tt.admin} {
+			if got := KeepDistinguished(tt.policy, status); got != want {
+				t.Errorf("KeepDistinguished(%q, %q) = %v, want %v", tt.policy, status, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package filter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// Expr is a compiled keep/drop rule over a post (FILTER_EXPR), e.g.
+//
+//	score > 50 && len(keywords_hit) > 0 && subreddit != "r/memes"
+//
+// Operands are the post's JSON fields (numbers, strings, booleans and string
+// lists), literals and len(x) for strings and lists. Operators are || && !,
+// the comparisons == != < <= > >=, and "x in y" for a string in a list or a
+// substring in a string. Everything is type checked by CompileExpr, so a bad
+// rule fails at startup rather than on the first post.
+type Expr struct {
+	src  string
+	keep func(*domain.Post) bool
+}
+
+// String returns the source expression
+func (e *Expr) String() string { return e.src }
+
+// Keep reports whether p passes the rule
+func (e *Expr) Keep(p domain.Post) bool { return e.keep(&p) }
+
+// CompileExpr parses and type checks src, which must evaluate to a boolean
+func CompileExpr(src string) (*Expr, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	v, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	if v.typ != typeBool {
+		return nil, fmt.Errorf("expression is %s, want bool", v.typ)
+	}
+	return &Expr{src: src, keep: v.b}, nil
+}
+
+type exprType string
+
+const (
+	typeNum  exprType = "number"
+	typeStr  exprType = "string"
+	typeBool exprType = "bool"
+	typeList exprType = "list"
+)
+
+// exprValue is a typed, compiled sub-expression; only the func matching typ is set
+type exprValue struct {
+	typ  exprType
+	num  func(*domain.Post) float64
+	str  func(*domain.Post) string
+	b    func(*domain.Post) bool
+	list func(*domain.Post) []string
+}
+
+// exprFields are the post fields an expression can reference, by JSON name
+var exprFields = map[string]exprValue{
+	"id":                   {typ: typeStr, str: func(p *domain.Post) string { return p.ID }},
+	"title":                {typ: typeStr, str: func(p *domain.Post) string { return p.Title }},
+	"subreddit":            {typ: typeStr, str: func(p *domain.Post) string { return p.Subreddit }},
+	"author":               {typ: typeStr, str: func(p *domain.Post) string { return p.Author }},
+	"url":                  {typ: typeStr, str: func(p *domain.Post) string { return p.URL }},
+	"permalink":            {typ: typeStr, str: func(p *domain.Post) string { return p.Permalink }},
+	"kind":                 {typ: typeStr, str: func(p *domain.Post) string { return string(p.Kind) }},
+	"lang":                 {typ: typeStr, str: func(p *domain.Post) string { return p.Lang }},
+	"content_hash":         {typ: typeStr, str: func(p *domain.Post) string { return p.ContentHash }},
+	"distinguished":        {typ: typeStr, str: func(p *domain.Post) string { return p.Distinguished }},
+	"score":                {typ: typeNum, num: func(p *domain.Post) float64 { return float64(p.Score) }},
+	"comment_count":        {typ: typeNum, num: func(p *domain.Post) float64 { return float64(p.CommentCount) }},
+	"created_utc":          {typ: typeNum, num: func(p *domain.Post) float64 { return p.CreatedUTC }},
+	"awards":               {typ: typeNum, num: func(p *domain.Post) float64 { return float64(p.Awards) }},
+	"upvote_ratio":         {typ: typeNum, num: func(p *domain.Post) float64 { return p.UpvoteRatio }},
+	"edited_utc":           {typ: typeNum, num: func(p *domain.Post) float64 { return p.EditedUTC }},
+	"is_self":              {typ: typeBool, b: func(p *domain.Post) bool { return p.IsSelf }},
+	"removed":              {typ: typeBool, b: func(p *domain.Post) bool { return p.Removed }},
+	"nsfw":                 {typ: typeBool, b: func(p *domain.Post) bool { return p.NSFW }},
+	"edited":               {typ: typeBool, b: func(p *domain.Post) bool { return p.Edited }},
+	"keywords_hit":         {typ: typeList, list: func(p *domain.Post) []string { return p.KeywordsHit }},
+	"comment_keywords_hit": {typ: typeList, list: func(p *domain.Post) []string { return p.CommentKeywordsHit }},
+	"matched_targets":      {typ: typeList, list: func(p *domain.Post) []string { return p.MatchedTargets }},
+	"media_urls":           {typ: typeList, list: func(p *domain.Post) []string { return p.MediaURLs }},
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokNum
+	tokStr
+	tokOp
+)
+
+type exprToken struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lexExpr(src string) ([]exprToken, error) {
+	var toks []exprToken
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			toks = append(toks, exprToken{tokIdent, src[i:j], i})
+			i = j
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, exprToken{tokNum, src[i:j], i})
+			i = j
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			toks = append(toks, exprToken{tokStr, s, i})
+			i = j + 1
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "-"} {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			toks = append(toks, exprToken{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, exprToken{tokEOF, "end of expression", len(src)}), nil
+}
+
+type exprParser struct {
+	toks []exprToken
+	i    int
+}
+
+func (p *exprParser) peek() exprToken { return p.toks[p.i] }
+
+func (p *exprParser) next() exprToken {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator (or keyword) op
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); (t.kind == tokOp || t.kind == tokIdent) && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) or() (exprValue, error) {
+	return p.logical("||", p.and, func(a, b func(*domain.Post) bool) func(*domain.Post) bool {
+		return func(post *domain.Post) bool { return a(post) || b(post) }
+	})
+}
+
+func (p *exprParser) and() (exprValue, error) {
+	return p.logical("&&", p.unary, func(a, b func(*domain.Post) bool) func(*domain.Post) bool {
+		return func(post *domain.Post) bool { return a(post) && b(post) }
+	})
+}
+
+func (p *exprParser) logical(op string, operand func() (exprValue, error), combine func(a, b func(*domain.Post) bool) func(*domain.Post) bool) (exprValue, error) {
+	left, err := operand()
+	if err != nil {
+		return left, err
+	}
+	for {
+		pos := p.peek().pos
+		if !p.accept(op) {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != typeBool || right.typ != typeBool {
+			return exprValue{}, fmt.Errorf("%s needs bool operands, got %s and %s at offset %d", op, left.typ, right.typ, pos)
+		}
+		left = exprValue{typ: typeBool, b: combine(left.b, right.b)}
+	}
+}
+
+func (p *exprParser) unary() (exprValue, error) {
+	pos := p.peek().pos
+	if !p.accept("!") {
+		return p.comparison()
+	}
+	v, err := p.unary()
+	if err != nil {
+		return v, err
+	}
+	if v.typ != typeBool {
+		return exprValue{}, fmt.Errorf("! needs a bool operand, got %s at offset %d", v.typ, pos)
+	}
+	return exprValue{typ: typeBool, b: func(post *domain.Post) bool { return !v.b(post) }}, nil
+}
+
+func (p *exprParser) comparison() (exprValue, error) {
+	left, err := p.primary()
+	if err != nil {
+		return left, err
+	}
+	t := p.peek()
+	var op string
+	for _, o := range []string{"==", "!=", "<", "<=", ">", ">=", "in"} {
+		if p.accept(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return left, nil
+	}
+	right, err := p.primary()
+	if err != nil {
+		return right, err
+	}
+	b, err := compare(op, left, right)
+	if err != nil {
+		return exprValue{}, fmt.Errorf("%w at offset %d", err, t.pos)
+	}
+	return exprValue{typ: typeBool, b: b}, nil
+}
+
+func compare(op string, l, r exprValue) (func(*domain.Post) bool, error) {
+	if op == "in" {
+		switch {
+		case l.typ == typeStr && r.typ == typeList:
+			return func(p *domain.Post) bool { return slices.Contains(r.list(p), l.str(p)) }, nil
+		case l.typ == typeStr && r.typ == typeStr:
+			return func(p *domain.Post) bool { return strings.Contains(r.str(p), l.str(p)) }, nil
+		}
+		return nil, fmt.Errorf("in needs a string and a list or string, got %s and %s", l.typ, r.typ)
+	}
+	if l.typ != r.typ {
+		return nil, fmt.Errorf("cannot compare %s %s %s", l.typ, op, r.typ)
+	}
+	switch l.typ {
+	case typeNum:
+		return ordered(op, l.num, r.num), nil
+	case typeStr:
+		return ordered(op, l.str, r.str), nil
+	case typeBool:
+		switch op {
+		case "==":
+			return func(p *domain.Post) bool { return l.b(p) == r.b(p) }, nil
+		case "!=":
+			return func(p *domain.Post) bool { return l.b(p) != r.b(p) }, nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s is not defined on %s", op, l.typ)
+}
+
+func ordered[T float64 | string](op string, l, r func(*domain.Post) T) func(*domain.Post) bool {
+	cmp := map[string]func(a, b T) bool{
+		"==": func(a, b T) bool { return a == b },
+		"!=": func(a, b T) bool { return a != b },
+		"<":  func(a, b T) bool { return a < b },
+		"<=": func(a, b T) bool { return a <= b },
+		">":  func(a, b T) bool { return a > b },
+		">=": func(a, b T) bool { return a >= b },
+	}[op]
+	return func(p *domain.Post) bool { return cmp(l(p), r(p)) }
+}
+
+func (p *exprParser) primary() (exprValue, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNum:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return exprValue{}, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return exprValue{typ: typeNum, num: func(*domain.Post) float64 { return n }}, nil
+	case tokStr:
+		return exprValue{typ: typeStr, str: func(*domain.Post) string { return t.text }}, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			b := t.text == "true"
+			return exprValue{typ: typeBool, b: func(*domain.Post) bool { return b }}, nil
+		case "len":
+			return p.length(t)
+		}
+		if f, ok := exprFields[t.text]; ok {
+			return f, nil
+		}
+		return exprValue{}, fmt.Errorf("unknown field %q at offset %d", t.text, t.pos)
+	case tokOp:
+		switch t.text {
+		case "(":
+			v, err := p.or()
+			if err != nil {
+				return v, err
+			}
+			if !p.accept(")") {
+				return exprValue{}, fmt.Errorf("missing ) at offset %d", p.peek().pos)
+			}
+			return v, nil
+		case "-":
+			v, err := p.primary()
+			if err != nil {
+				return v, err
+			}
+			if v.typ != typeNum {
+				return exprValue{}, fmt.Errorf("- needs a number, got %s at offset %d", v.typ, t.pos)
+			}
+			return exprValue{typ: typeNum, num: func(post *domain.Post) float64 { return -v.num(post) }}, nil
+		}
+	}
+	return exprValue{}, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+// length compiles len(x) for a string (in runes) or a list
+func (p *exprParser) length(t exprToken) (exprValue, error) {
+	if !p.accept("(") {
+		return exprValue{}, fmt.Errorf("len needs parentheses at offset %d", t.pos)
+	}
+	v, err := p.or()
+	if err != nil {
+		return v, err
+	}
+	if !p.accept(")") {
+		return exprValue{}, fmt.Errorf("missing ) at offset %d", p.peek().pos)
+	}
+	switch v.typ {
+	case typeStr:
+		return exprValue{typ: typeNum, num: func(post *domain.Post) float64 { return float64(len([]rune(v.str(post)))) }}, nil
+	case typeList:
+		return exprValue{typ: typeNum, num: func(post *domain.Post) float64 { return float64(len(v.list(post))) }}, nil
+	}
+	return exprValue{}, fmt.Errorf("len needs a string or list, got %s at offset %d", v.typ, t.pos)
+}
@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestCompileExprPrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		// && binds tighter than ||
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"false && true || true", true},
+		// ! binds tighter than && and ||, and applies to a whole comparison
+		{"!true || true", true},
+		{"!(true || true)", false},
+		{"!false && false", false},
+		{"!!true", true},
+		{"!1 > 2", true},
+		// comparisons bind tighter than the logical operators
+		{"1 < 2 && 3 >= 3", true},
+		{"1 == 2 || \"a\" != \"b\"", true},
+		{"-1 < 0", true},
+	}
+	for _, tt := range tests {
+		e, err := CompileExpr(tt.src)
+		if err != nil {
+			t.Errorf("CompileExpr(%q): %v", tt.src, err)
+			continue
+		}
+		if got := e.Keep(domain.Post{}); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileExprErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{`subreddit == 5`, "cannot compare string == number"},
+		{`score > "50"`, "cannot compare number > string"},
+		{`score == true`, "cannot compare number == bool"},
+		{`title < keywords_hit`, "cannot compare string < list"},
+		{`nsfw < true`, "operator < is not defined on bool"},
+		{`score in keywords_hit`, "in needs a string and a list or string"},
+		{`score && nsfw`, "&& needs bool operands"},
+		{`!score`, "! needs a bool operand"},
+		{`-title`, "- needs a number"},
+		{`len(score) > 1`, "len needs a string or list"},
+		{`score`, "expression is number, want bool"},
+		{`upvotes > 5`, `unknown field "upvotes"`},
+		{`score > 5 && nope`, `unknown field "nope"`},
+		{`(score > 5`, "missing )"},
+		{`score > 5 )`, "unexpected"},
+		{`title == "open`, "unterminated string"},
+		{`score > 5 # note`, "unexpected character"},
+	}
+	for _, tt := range tests {
+		_, err := CompileExpr(tt.src)
+		if err == nil {
+			t.Errorf("CompileExpr(%q) succeeded, want error containing %q", tt.src, tt.wantErr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CompileExpr(%q) = %q, want it to contain %q", tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExprKeep(t *testing.T) {
+	post := domain.Post{
+		Title:       "Splunk detection rules",
+		Subreddit:   "r/netsec",
+		Score:       120,
+		UpvoteRatio: 0.9,
+		KeywordsHit: []string{"splunk"},
+		NSFW:        false,
+		Kind:        domain.PostLink,
+	}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`score > 50 && len(keywords_hit) > 0 && subreddit != "r/memes"`, true},
+		{`score > 500 || subreddit == "r/netsec"`, true},
+		{`score >= 120 && score <= 120`, true},
+		{`upvote_ratio < 0.5`, false},
+		{`"splunk" in keywords_hit`, true},
+		{`"misp" in keywords_hit`, false},
+		{`"detection" in title`, true},
+		{`len(title) == 22`, true},
+		{`!nsfw && kind == "link"`, true},
+		{`nsfw == false`, true},
+		{`len(media_urls) > 0`, false},
+	}
+	for _, tt := range tests {
+		e, err := CompileExpr(tt.src)
+		if err != nil {
+			t.Errorf("CompileExpr(%q): %v", tt.src, err)
+			continue
+		}
+		if got := e.Keep(post); got != tt.want {
+			t.Errorf("Keep(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+		if e.String() != tt.src {
+			t.Errorf("String() = %q, want %q", e.String(), tt.src)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+)
+
+// HighlightSpans returns the [start, end) byte spans of text covered by any
+// of terms (case-insensitive), sorted, with overlapping or touching spans
+// merged into one. Text whose lowercase form has a different byte length
+// (a few non-ASCII letters) can't be mapped back safely and gets no spans.
+func HighlightSpans(text string, terms []string) [][2]int {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		return nil
+	}
+	var spans [][2]int
+	for _, t := range terms {
+		spans = append(spans, occurrences(lower, strings.ToLower(t))...)
+	}
+	slices.SortFunc(spans, func(a, b [2]int) int { return a[0] - b[0] })
+
+	var merged [][2]int
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], s[1])
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
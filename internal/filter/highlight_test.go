@@ -0,0 +1,36 @@
+package filter
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHighlightSpans(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  [][2]int
+	}{
+		{"single hit", "Splunk tips", []string{"splunk"}, [][2]int{{0, 6}}},
+		{"case-insensitive, every occurrence", "misp and MISP", []string{"Misp"}, [][2]int{{0, 4}, {9, 13}}},
+		{"sorted across terms", "yara then sigma", []string{"sigma", "yara"}, [][2]int{{0, 4}, {10, 15}}},
+		{"overlapping hits merge", "crowdstrike", []string{"crowd", "owdstr"}, [][2]int{{0, 8}}},
+		{"nested hit merges", "recorded future", []string{"recorded future", "future"}, [][2]int{{0, 15}}},
+		{"touching hits merge", "foobar", []string{"foo", "bar"}, [][2]int{{0, 6}}},
+		{"separate hits stay apart", "foo bar", []string{"foo", "bar"}, [][2]int{{0, 3}, {4, 7}}},
+		{"repeated overlapping term", "aaa", []string{"aa"}, [][2]int{{0, 3}}},
+		{"no hits", "nothing here", []string{"splunk"}, nil},
+		{"empty term ignored", "text", []string{""}, nil},
+		{"same-length non-ASCII is fine", "Ωmega tool", []string{"ωmega"}, [][2]int{{0, 6}}},
+		// İ lowercases to a shorter byte sequence, so offsets can't be mapped back
+		{"length mismatch gives no spans", "İstanbul splunk", []string{"splunk"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HighlightSpans(tt.text, tt.terms); !slices.Equal(got, tt.want) {
+				t.Errorf("HighlightSpans(%q, %q) = %v, want %v", tt.text, tt.terms, got, tt.want)
+			}
+		})
+	}
+}
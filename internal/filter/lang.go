@@ -0,0 +1,142 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LangUnknown is recorded when a title is too short or too ambiguous to call
+const LangUnknown = "unknown"
+
+// minLangLetters is the fewest letters a title needs before detection guesses
+const minLangLetters = 12
+
+// scriptLangs maps non-Latin scripts to the language they most likely mean.
+// It is a best guess: Cyrillic is reported as Russian, Han without kana as
+// Chinese, and so on.
+var scriptLangs = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// langTrigrams are frequent letter trigrams per Latin-script language, with
+// "_" marking a word boundary
+var langTrigrams = map[string][]string{
+	"en": {"_th", "the", "he_", "ing", "ng_", "_an", "and", "nd_", "_to", "to_", "_of", "of_", "ion", "ed_", "_in", "in_", "is_", "_is", "er_", "tio", "for", "_fo", "or_", "hat", "tha", "at_", "you", "_yo", "ou_", "thi", "his", "wit", "_wi", "ith", "th_", "_be", "ll_", "are", "ly_", "ve_", "_wh", "wha", "how", "_ho", "ow_", "_a_", "_it", "it_", "_on", "on_"},
+	"es": {"_de", "de_", "os_", "_la", "la_", "el_", "_el", "que", "_qu", "ue_", "_en", "en_", "_co", "ón_", "ión", "ado", "con", "par", "ara", "_pa", "nte", "_lo", "los", "_se", "por", "_po", "una", "_un", "est", "del", "al_", "ien", "cia", "ía_", "_es", "_y_", "mos", "ero", "ar_", "ndo"},
+	"fr": {"_de", "de_", "les", "_le", "le_", "_la", "la_", "et_", "_et", "ent", "nt_", "que", "_qu", "ue_", "des", "_pa", "our", "pou", "_po", "est", "_un", "une", "ne_", "eur", "ons", "_en", "en_", "du_", "_du", "_ce", "ait", "ais", "_au", "aux", "ux_", "_à_", "_es", "_pl", "plu", "ur_"},
+	"de": {"en_", "er_", "_de", "der", "ich", "die", "_di", "ie_", "ein", "und", "_un", "nd_", "sch", "che", "ch_", "den", "ine", "_ei", "cht", "ten", "_da", "das", "gen", "_zu", "zu_", "ung", "ist", "_is", "mit", "_mi", "auf", "_au", "uf_", "nic", "ht_", "ber", "_be", "_wi", "wie", "für"},
+	"pt": {"_de", "de_", "os_", "_qu", "que", "ue_", "_co", "ão_", "ção", "do_", "_do", "da_", "_da", "_pa", "par", "ara", "_se", "nte", "com", "um_", "_um", "uma", "em_", "_em", "não", "_nã", "ões", "ado", "est", "por", "_po", "_o_", "_e_", "_na", "na_", "no_", "_no", "_é_", "mos", "ar_"},
+	"it": {"_di", "di_", "_de", "del", "ell", "lla", "che", "_ch", "he_", "_co", "con", "zio", "ion", "one", "ne_", "per", "_pe", "il_", "_il", "non", "_no", "ato", "ta_", "_in", "gli", "_gl", "_un", "una", "_la", "la_", "_le", "le_", "_è_", "_si", "son", "ono", "no_", "_e_", "ere", "are"},
+	"nl": {"_de", "de_", "en_", "_en", "et_", "van", "_va", "an_", "_he", "het", "een", "_ee", "_ve", "ver", "ij_", "ijk", "oor", "voo", "_vo", "nie", "iet", "_ni", "aar", "_te", "te_", "ond", "cht", "_zi", "zij", "dat", "_da", "wor", "ord", "_ik", "ik_", "_is", "_op", "op_", "_me", "met"},
+}
+
+// langProfiles are langTrigrams as sets, built once
+var langProfiles = func() map[string]map[string]bool {
+	profiles := make(map[string]map[string]bool, len(langTrigrams))
+	for lang, grams := range langTrigrams {
+		set := make(map[string]bool, len(grams))
+		for _, g := range grams {
+			set[g] = true
+		}
+		profiles[lang] = set
+	}
+	return profiles
+}()
+
+// DetectLang makes a best-effort guess at a title's language, as an ISO 639-1
+// code. Non-Latin scripts are recognised by their letters; Latin-script text
+// is scored against the frequent trigrams of a handful of languages (en, es,
+// fr, de, pt, it, nl). Titles under a dozen letters, or whose best score is
+// weak or close to the runner-up, are LangUnknown rather than a wild guess.
+func DetectLang(title string) string {
+	var letters, latin int
+	scripts := make(map[string]int)
+	for _, r := range title {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range scriptLangs {
+			if unicode.Is(s.table, r) {
+				scripts[s.lang]++
+				break
+			}
+		}
+	}
+	// CJK packs a word into a letter or two, so it gets a lower bar
+	if letters < minLangLetters && (letters < 4 || latin > 0) {
+		return LangUnknown
+	}
+	if latin*2 < letters {
+		best, n := LangUnknown, 0
+		for lang, c := range scripts {
+			if c > n {
+				best, n = lang, c
+			}
+		}
+		// Kana alongside Han means Japanese, however few
+		if best == "zh" && scripts["ja"] > 0 {
+			return "ja"
+		}
+		return best
+	}
+	return detectLatin(title)
+}
+
+// detectLatin scores title's trigrams against langProfiles
+func detectLatin(title string) string {
+	grams := trigrams(title)
+	if len(grams) == 0 {
+		return LangUnknown
+	}
+	best, second := 0, 0
+	lang := LangUnknown
+	for l, profile := range langProfiles {
+		score := 0
+		for _, g := range grams {
+			if profile[g] {
+				score++
+			}
+		}
+		switch {
+		case score > best:
+			best, second, lang = score, best, l
+		case score > second:
+			second = score
+		}
+	}
+	// Need a fair share of hits and a clear lead over the next language
+	if best < 3 || best*8 < len(grams) || best < second+2 {
+		return LangUnknown
+	}
+	return lang
+}
+
+// trigrams lists the letter trigrams of each word in s, lowercased and padded
+// with "_" at word boundaries. Digits and punctuation separate words.
+func trigrams(s string) []string {
+	var grams []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		rs := []rune("_" + w + "_")
+		for i := 0; i+3 <= len(rs); i++ {
+			grams = append(grams, string(rs[i:i+3]))
+		}
+	}
+	return grams
+}
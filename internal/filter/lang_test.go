@@ -0,0 +1,30 @@
+package filter
+
+import "testing"
+
+func TestDetectLang(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		// Clearly English
+		{"How is everyone handling the CrowdStrike outage this morning?", "en"},
+		{"What are the best tools for threat hunting with Splunk", "en"},
+		// Clearly not English
+		{"¿Cuál es la mejor herramienta para el análisis de los registros?", "es"},
+		{"Quelle est la meilleure solution pour les entreprises et les équipes", "fr"},
+		{"Wie schützt ihr euch gegen die neue Phishing Welle und den Betrug", "de"},
+		{"Как защитить сервер от атак", "ru"},
+		{"新しいマルウェアの分析", "ja"},
+		{"勒索软件攻击事件分析", "zh"},
+		// Too short to call
+		{"Splunk?", LangUnknown},
+		{"CVE-2024-3094", LangUnknown},
+		{"help pls", LangUnknown},
+		{"", LangUnknown},
+	}
+	for _, tt := range tests {
+		if got := DetectLang(tt.title); got != tt.want {
+			t.Errorf("DetectLang(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
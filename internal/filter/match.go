@@ -0,0 +1,252 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Matcher finds which keywords occur in a piece of text. Hits are reported
+// once each, in keyword order.
+type Matcher interface {
+	Match(text string) (hits []string)
+}
+
+// Keyword matching modes (MATCH_MODE)
+const (
+	MatchSubstring = "substring" // case-insensitive substring (default)
+	MatchWord      = "word"      // case-insensitive, whole words only
+	MatchRegex     = "regex"     // every keyword is a case-insensitive regular expression
+	MatchFuzzy     = "fuzzy"     // whole words, tolerating small typos
+)
+
+// ValidMatchMode reports whether mode names a Matcher NewMatcher can build
+func ValidMatchMode(mode string) bool {
+	switch mode {
+	case MatchSubstring, MatchWord, MatchRegex, MatchFuzzy:
+		return true
+	}
+	return false
+}
+
+// NewMatcher builds the Matcher for mode over keywords. Only regex mode can
+// fail, on a pattern that doesn't compile.
+func NewMatcher(mode string, keywords []string) (Matcher, error) {
+	switch mode {
+	case "", MatchSubstring:
+		return SubstringMatcher(lowerAll(keywords)), nil
+	case MatchWord:
+		return WordMatcher(lowerAll(keywords)), nil
+	case MatchFuzzy:
+		return NewFuzzyMatcher(keywords), nil
+	case MatchRegex:
+		return NewRegexMatcher(keywords)
+	}
+	return nil, fmt.Errorf("unknown match mode %q", mode)
+}
+
+func lowerAll(keywords []string) []string {
+	lower := make([]string, len(keywords))
+	for i, k := range keywords {
+		lower[i] = strings.ToLower(k)
+	}
+	return lower
+}
+
+// SubstringMatcher hits a (lowercase) keyword anywhere in the text, so "misp"
+// also matches "misplaced". This is the original matching behavior.
+type SubstringMatcher []string
+
+func (m SubstringMatcher) Match(text string) []string {
+	text = strings.ToLower(text)
+	var hits []string
+	for _, k := range m {
+		if k != "" && strings.Contains(text, k) {
+			hits = append(hits, k)
+		}
+	}
+	return hits
+}
+
+// WordMatcher hits a (lowercase) keyword only where it isn't part of a longer
+// word. Boundaries are checked by hand rather than with \b so keywords that
+// start or end in punctuation ("c++", ".net") still work.
+type WordMatcher []string
+
+func (m WordMatcher) Match(text string) []string {
+	text = strings.ToLower(text)
+	var hits []string
+	for _, k := range m {
+		if k != "" && containsWord(text, k) {
+			hits = append(hits, k)
+		}
+	}
+	return hits
+}
+
+func containsWord(text, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		// RuneError stands in for "no rune" at either end of the text
+		if (i == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// RegexMatcher treats each keyword as a case-insensitive regular expression,
+// compiled once. Hits are reported by pattern, or by the (lowercased) text
+// each pattern matched when built with NewCaptureRegexMatcher.
+type RegexMatcher struct {
+	labels   []string
+	patterns []*regexp.Regexp
+	capture  bool
+}
+
+func NewRegexMatcher(patterns []string) (*RegexMatcher, error) {
+	return newRegexMatcher(patterns, false)
+}
+
+// NewCaptureRegexMatcher reports what each pattern matched, so a pattern like
+// "CVE-\d{4}-\d+" yields the individual CVE IDs as hits
+func NewCaptureRegexMatcher(patterns []string) (*RegexMatcher, error) {
+	return newRegexMatcher(patterns, true)
+}
+
+func newRegexMatcher(patterns []string, capture bool) (*RegexMatcher, error) {
+	m := &RegexMatcher{capture: capture}
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keyword pattern %q: %w", p, err)
+		}
+		m.labels = append(m.labels, p)
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+func (m *RegexMatcher) Match(text string) []string {
+	var hits []string
+	for i, re := range m.patterns {
+		if !m.capture {
+			if re.MatchString(text) {
+				hits = append(hits, m.labels[i])
+			}
+			continue
+		}
+		for _, found := range re.FindAllString(text, -1) {
+			if found = strings.ToLower(found); !slices.Contains(hits, found) {
+				hits = append(hits, found)
+			}
+		}
+	}
+	return hits
+}
+
+// MultiMatcher combines matchers, e.g. literal keywords and regex entries
+// from the same file. A hit found by several matchers is reported once.
+type MultiMatcher []Matcher
+
+func (mm MultiMatcher) Match(text string) []string {
+	var hits []string
+	for _, m := range mm {
+		for _, h := range m.Match(text) {
+			if !slices.Contains(hits, h) {
+				hits = append(hits, h)
+			}
+		}
+	}
+	return hits
+}
+
+// FuzzyMatcher compares keywords against runs of whole words in the text and
+// allows a small edit distance, catching typos like "crowdstirke". Short
+// keywords must match exactly since one edit changes them too much.
+type FuzzyMatcher struct {
+	keywords [][]string // each keyword split into words
+	labels   []string
+}
+
+func NewFuzzyMatcher(keywords []string) *FuzzyMatcher {
+	m := &FuzzyMatcher{}
+	for _, k := range keywords {
+		words := words(strings.ToLower(k))
+		if len(words) == 0 {
+			continue
+		}
+		m.keywords = append(m.keywords, words)
+		m.labels = append(m.labels, strings.ToLower(k))
+	}
+	return m
+}
+
+func (m *FuzzyMatcher) Match(text string) []string {
+	tokens := words(strings.ToLower(text))
+	var hits []string
+	for i, kw := range m.keywords {
+		target := strings.Join(kw, " ")
+		allowed := fuzzyTolerance(len([]rune(target)))
+		for start := 0; start+len(kw) <= len(tokens); start++ {
+			window := strings.Join(tokens[start:start+len(kw)], " ")
+			if levenshtein(window, target) <= allowed {
+				hits = append(hits, m.labels[i])
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// fuzzyTolerance is the edit distance allowed for a keyword of n runes
+func fuzzyTolerance(n int) int {
+	switch {
+	case n < 5:
+		return 0
+	case n <= 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return !isWordRune(r) })
+}
+
+// levenshtein is the edit distance between a and b, counted in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,70 @@
+package filter
+
+import (
+	"slices"
+	"testing"
+)
+
+// matchCorpus is shared by every Matcher implementation's test
+var matchCorpus = []string{
+	"MISP feeds for Splunk",
+	"Misplaced logs again",
+	"CrowdStirke outage postmortem",
+	"Learning C++ for malware analysis",
+	"Nothing relevant here",
+}
+
+var matchKeywords = []string{"misp", "crowdstrike", "c++", "splunk"}
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		mode     string
+		keywords []string
+		want     [][]string // hits per corpus entry
+	}{
+		// Substrings hit inside longer words ("Misplaced") but miss typos
+		{MatchSubstring, matchKeywords, [][]string{{"misp", "splunk"}, {"misp"}, nil, {"c++"}, nil}},
+		// Whole words only, with punctuation-ending keywords still working
+		{MatchWord, matchKeywords, [][]string{{"misp", "splunk"}, nil, nil, {"c++"}, nil}},
+		// Whole words, tolerating a transposition in a long keyword
+		{MatchFuzzy, matchKeywords, [][]string{{"misp", "splunk"}, nil, {"crowdstrike"}, {"c++"}, nil}},
+		// Hits are reported by pattern
+		{MatchRegex, []string{"misp", "crowd[- ]?strike", `c\+\+`, "splunk"},
+			[][]string{{"misp", "splunk"}, {"misp"}, nil, {`c\+\+`}, nil}},
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.mode, tt.keywords)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.mode, err)
+		}
+		for i, text := range matchCorpus {
+			if got := m.Match(text); !slices.Equal(got, tt.want[i]) {
+				t.Errorf("%s: Match(%q) = %q, want %q", tt.mode, text, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNewMatcherDefaultsToSubstring(t *testing.T) {
+	m, err := NewMatcher("", matchKeywords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(SubstringMatcher); !ok {
+		t.Errorf("NewMatcher(\"\") = %T, want SubstringMatcher", m)
+	}
+	if _, err := NewMatcher("soundex", matchKeywords); err == nil || ValidMatchMode("soundex") {
+		t.Error("unknown mode accepted")
+	}
+}
+
+func TestMultiMatcherReportsEachHitOnce(t *testing.T) {
+	re, err := NewRegexMatcher([]string{"splunk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mm := MultiMatcher{SubstringMatcher{"splunk", "misp"}, re}
+	if got, want := mm.Match(matchCorpus[0]), []string{"splunk", "misp"}; !slices.Equal(got, want) {
+		t.Errorf("Match = %q, want %q", got, want)
+	}
+}
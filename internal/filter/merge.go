@@ -0,0 +1,42 @@
+package filter
+
+import (
+	"slices"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// MergeByID combines several listings of the same target (e.g. /new and /hot),
+// or one cycle's posts from several targets, into one. It keeps the first
+// occurrence of each post ID with the highest score seen for it, the earliest
+// ScrapedAt and every target in MatchedTargets.
+func MergeByID(sets ...[]domain.Post) []domain.Post {
+	var merged []domain.Post
+	index := make(map[string]int)
+	for _, set := range sets {
+		for _, p := range set {
+			if i, ok := index[p.ID]; ok {
+				if p.Score > merged[i].Score {
+					merged[i].Score = p.Score
+				}
+				merged[i].ScrapedAt = EarliestTime(merged[i].ScrapedAt, p.ScrapedAt)
+				if len(p.MatchedTargets) > 0 {
+					merged[i].MatchedTargets = appendNew(slices.Clone(merged[i].MatchedTargets), p.MatchedTargets...)
+				}
+				continue
+			}
+			index[p.ID] = len(merged)
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// EarliestTime returns the earlier of a and b, ignoring zero values
+func EarliestTime(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,44 @@
+package filter
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestMergeByIDOverlappingSorts(t *testing.T) {
+	early := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	late := early.Add(time.Minute)
+	newest := []domain.Post{
+		{ID: "a", Score: 3, ScrapedAt: late},
+		{ID: "b", Score: 10, ScrapedAt: late},
+		{ID: "c", Score: 1, ScrapedAt: late},
+	}
+	hot := []domain.Post{
+		{ID: "b", Score: 25, ScrapedAt: early},
+		{ID: "d", Score: 300},
+		{ID: "a", Score: 2},
+	}
+	got := MergeByID(newest, hot)
+
+	if ids := postIDs(got); !slices.Equal(ids, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("merged %v, want each post once in first-seen order", ids)
+	}
+	scores := map[string]int{}
+	for _, p := range got {
+		scores[p.ID] = p.Score
+	}
+	if want := map[string]int{"a": 3, "b": 25, "c": 1, "d": 300}; !maps.Equal(scores, want) {
+		t.Errorf("scores = %v, want the max seen %v", scores, want)
+	}
+	if !got[1].ScrapedAt.Equal(early) || !got[0].ScrapedAt.Equal(late) {
+		t.Errorf("ScrapedAt = %v, %v; want the earliest non-zero", got[0].ScrapedAt, got[1].ScrapedAt)
+	}
+	// The inputs are left alone
+	if newest[1].Score != 10 {
+		t.Errorf("input modified: %+v", newest[1])
+	}
+}
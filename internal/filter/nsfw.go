@@ -0,0 +1,29 @@
+package filter
+
+// NSFW filtering policies (FILTER_NSFW)
+const (
+	NSFWExclude = "exclude" // drop over_18 posts (default)
+	NSFWInclude = "include" // keep posts regardless of the flag
+	NSFWOnly    = "only"    // keep only over_18 posts
+)
+
+// ValidNSFWPolicy reports whether policy is a known FILTER_NSFW value
+func ValidNSFWPolicy(policy string) bool {
+	switch policy {
+	case NSFWExclude, NSFWInclude, NSFWOnly:
+		return true
+	}
+	return false
+}
+
+// KeepNSFW reports whether a post flagged nsfw passes policy. Unknown
+// policies behave like NSFWExclude.
+func KeepNSFW(policy string, nsfw bool) bool {
+	switch policy {
+	case NSFWInclude:
+		return true
+	case NSFWOnly:
+		return nsfw
+	}
+	return !nsfw
+}
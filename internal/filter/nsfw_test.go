@@ -0,0 +1,25 @@
+package filter
+
+import "testing"
+
+func TestKeepNSFW(t *testing.T) {
+	tests := []struct {
+		policy    string
+		sfw, nsfw bool
+	}{
+		{NSFWExclude, true, false},
+		{NSFWInclude, true, true},
+		{NSFWOnly, false, true},
+		// Unknown policies fall back to exclude
+		{"", true, false},
+		{"bogus", true, false},
+	}
+	for _, tt := range tests {
+		if got := KeepNSFW(tt.policy, false); got != tt.sfw {
+			t.Errorf("KeepNSFW(%q, false) = %v, want %v", tt.policy, got, tt.sfw)
+		}
+		if got := KeepNSFW(tt.policy, true); got != tt.nsfw {
+			t.Errorf("KeepNSFW(%q, true) = %v, want %v", tt.policy, got, tt.nsfw)
+		}
+	}
+}
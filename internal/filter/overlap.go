@@ -0,0 +1,83 @@
+package filter
+
+import "strings"
+
+// CollapsingMatcher drops hits that only ever occur inside a longer hit,
+// e.g. "crowd" when the text's only "crowd" is part of "CrowdStrike". A hit
+// that also appears on its own elsewhere in the text is kept. Hits that
+// aren't literal text (regex pattern labels, fuzzy matches) are left alone.
+type CollapsingMatcher struct {
+	Matcher
+}
+
+func (m CollapsingMatcher) Match(text string) []string {
+	return CollapseOverlaps(text, m.Matcher.Match(text))
+}
+
+// CollapseOverlaps removes from hits every hit whose occurrences in text
+// (case-insensitive) all lie within occurrences of another, longer hit
+func CollapseOverlaps(text string, hits []string) []string {
+	if len(hits) < 2 {
+		return hits
+	}
+	lower := strings.ToLower(text)
+	spans := make([][][2]int, len(hits))
+	for i, h := range hits {
+		spans[i] = occurrences(lower, strings.ToLower(h))
+	}
+
+	var kept []string
+	for i, h := range hits {
+		if !covered(spans[i], hits, spans, i) {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// covered reports whether every span of hit i sits inside a span of a
+// longer hit. A hit with no literal occurrence is never covered.
+func covered(own [][2]int, hits []string, spans [][][2]int, i int) bool {
+	if len(own) == 0 {
+		return false
+	}
+	for _, s := range own {
+		inside := false
+		for j, other := range spans {
+			if j == i || len(hits[j]) <= len(hits[i]) {
+				continue
+			}
+			for _, o := range other {
+				if o[0] <= s[0] && s[1] <= o[1] {
+					inside = true
+					break
+				}
+			}
+			if inside {
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return true
+}
+
+// occurrences returns the [start, end) byte spans of every (possibly
+// overlapping) occurrence of sub in s
+func occurrences(s, sub string) [][2]int {
+	if sub == "" {
+		return nil
+	}
+	var spans [][2]int
+	for from := 0; ; {
+		i := strings.Index(s[from:], sub)
+		if i < 0 {
+			return spans
+		}
+		start := from + i
+		spans = append(spans, [2]int{start, start + len(sub)})
+		from = start + 1
+	}
+}
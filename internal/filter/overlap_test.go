@@ -0,0 +1,38 @@
+package filter
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollapseOverlaps(t *testing.T) {
+	tests := []struct {
+		text string
+		hits []string
+		want []string
+	}{
+		// "crowd" only occurs inside "crowdstrike"
+		{"CrowdStrike outage", []string{"crowd", "crowdstrike"}, []string{"crowdstrike"}},
+		// ...but here it also stands alone, so both are kept
+		{"CrowdStrike drew a crowd", []string{"crowd", "crowdstrike"}, []string{"crowd", "crowdstrike"}},
+		// Nested three deep keeps only the longest
+		{"Microsoft Defender for Endpoint", []string{"defender", "microsoft defender", "microsoft defender for endpoint"}, []string{"microsoft defender for endpoint"}},
+		// Partly overlapping hits aren't nested, so neither goes
+		{"splunkbase", []string{"splunk", "kbase"}, []string{"splunk", "kbase"}},
+		// A hit with no literal occurrence (e.g. a regex label) is left alone
+		{"CVE-2024-1234 in CrowdStrike", []string{`cve-\d+`, "crowdstrike"}, []string{`cve-\d+`, "crowdstrike"}},
+		{"Splunk", []string{"splunk"}, []string{"splunk"}},
+	}
+	for _, tt := range tests {
+		if got := CollapseOverlaps(tt.text, tt.hits); !slices.Equal(got, tt.want) {
+			t.Errorf("CollapseOverlaps(%q, %q) = %q, want %q", tt.text, tt.hits, got, tt.want)
+		}
+	}
+}
+
+func TestCollapsingMatcher(t *testing.T) {
+	m := CollapsingMatcher{SubstringMatcher{"crowd", "crowdstrike", "falcon"}}
+	if got, want := m.Match("CrowdStrike Falcon sensor update"), []string{"crowdstrike", "falcon"}; !slices.Equal(got, want) {
+		t.Errorf("Match = %q, want %q", got, want)
+	}
+}
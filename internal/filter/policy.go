@@ -0,0 +1,38 @@
+package filter
+
+// Match policies (MATCH_POLICY): how a post's score and keyword hits combine
+// into the keep decision
+const (
+	MatchScoreOrKeyword  = "score_or_keyword" // meet min_score or hit keywords (default)
+	MatchKeywordRequired = "keyword_required" // meet min_score and hit keywords
+	MatchScoreOnly       = "score_only"       // meet min_score; hits are still recorded
+)
+
+// ValidMatchPolicy reports whether policy is a known MATCH_POLICY value
+func ValidMatchPolicy(policy string) bool {
+	switch policy {
+	case MatchScoreOrKeyword, MatchKeywordRequired, MatchScoreOnly:
+		return true
+	}
+	return false
+}
+
+// KeepMatch reports whether a post passes policy, given whether it meets its
+// target's min score and whether it hit enough keywords. Unknown policies
+// behave like MatchScoreOrKeyword.
+func KeepMatch(policy string, scoreOK, keywordOK bool) bool {
+	switch policy {
+	case MatchKeywordRequired:
+		return scoreOK && keywordOK
+	case MatchScoreOnly:
+		return scoreOK
+	}
+	return scoreOK || keywordOK
+}
+
+// No-keywords modes (NO_KEYWORDS): how the gate behaves when no keywords are
+// configured, whatever MATCH_POLICY says
+const (
+	NoKeywordsScore = "score" // keep posts meeting min_score (default)
+	NoKeywordsAll   = "all"   // keep every post; min_score is ignored
+)
@@ -0,0 +1,34 @@
+package filter
+
+import "testing"
+
+func TestKeepMatch(t *testing.T) {
+	// want is indexed by [scoreOK][keywordOK]
+	tests := []struct {
+		policy string
+		want   [2][2]bool
+	}{
+		{MatchScoreOrKeyword, [2][2]bool{{false, true}, {true, true}}},
+		{MatchKeywordRequired, [2][2]bool{{false, false}, {false, true}}},
+		{MatchScoreOnly, [2][2]bool{{false, false}, {true, true}}},
+		// Unknown policies behave like the default
+		{"bogus", [2][2]bool{{false, true}, {true, true}}},
+	}
+	for _, tt := range tests {
+		for _, scoreOK := range []bool{false, true} {
+			for _, keywordOK := range []bool{false, true} {
+				want := tt.want[b2i(scoreOK)][b2i(keywordOK)]
+				if got := KeepMatch(tt.policy, scoreOK, keywordOK); got != want {
+					t.Errorf("KeepMatch(%q, score %v, keyword %v) = %v, want %v", tt.policy, scoreOK, keywordOK, got, want)
+				}
+			}
+		}
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
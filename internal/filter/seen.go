@@ -0,0 +1,84 @@
+package filter
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+)
+
+// DefaultSeenCapacity bounds SeenSet when no capacity is configured
+const DefaultSeenCapacity = 50000
+
+// Dedup scopes: how long post IDs are remembered (DEDUP_SCOPE)
+const (
+	DedupNone   = "none"   // write every observation, e.g. for score trends
+	DedupCycle  = "cycle"  // one copy per cycle; the set resets each cycle
+	DedupGlobal = "global" // one copy ever, bounded by the LRU capacity
+)
+
+// ValidDedupScope reports whether scope is a known DEDUP_SCOPE value
+func ValidDedupScope(scope string) bool {
+	switch scope {
+	case DedupNone, DedupCycle, DedupGlobal:
+		return true
+	}
+	return false
+}
+
+// SeenSet remembers recently written post IDs across cycles so re-fetched
+// posts aren't appended again. It's an LRU: once full, the least recently
+// seen ID is forgotten, which may let a very old post be written twice but
+// keeps memory bounded in long runs. Safe for concurrent use.
+type SeenSet struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently seen
+	index    map[string]*list.Element
+	evicting bool
+}
+
+func NewSeenSet(capacity int) *SeenSet {
+	if capacity < 1 {
+		capacity = DefaultSeenCapacity
+	}
+	return &SeenSet{capacity: capacity, order: list.New(), index: make(map[string]*list.Element)}
+}
+
+// Add records id and reports whether it was new. Seeing an ID again refreshes
+// it, so posts that keep showing up are never evicted.
+func (ss *SeenSet) Add(id string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if el, ok := ss.index[id]; ok {
+		ss.order.MoveToFront(el)
+		return false
+	}
+	ss.index[id] = ss.order.PushFront(id)
+	if ss.order.Len() > ss.capacity {
+		if !ss.evicting {
+			ss.evicting = true
+			slog.Info("Seen-ID set is full, evicting the oldest IDs", "capacity", ss.capacity)
+		}
+		oldest := ss.order.Back()
+		ss.order.Remove(oldest)
+		delete(ss.index, oldest.Value.(string))
+	}
+	return true
+}
+
+// Reset forgets every ID
+func (ss *SeenSet) Reset() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.order.Init()
+	clear(ss.index)
+	ss.evicting = false
+}
+
+// Len is the number of IDs currently remembered
+func (ss *SeenSet) Len() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.order.Len()
+}
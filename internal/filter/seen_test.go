@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSeenSetBoundedByCapacity(t *testing.T) {
+	const capacity = 100
+	ss := NewSeenSet(capacity)
+	for i := range 1000 {
+		if !ss.Add(fmt.Sprintf("id%d", i)) {
+			t.Fatalf("id%d reported as seen on first add", i)
+		}
+		if ss.Len() > capacity {
+			t.Fatalf("Len = %d after %d adds, want at most %d", ss.Len(), i+1, capacity)
+		}
+	}
+	// The most recent IDs are still suppressed; the oldest were evicted and
+	// may be written again
+	for i := 900; i < 1000; i++ {
+		if ss.Add(fmt.Sprintf("id%d", i)) {
+			t.Errorf("recent id%d wasn't suppressed", i)
+		}
+	}
+	if !ss.Add("id0") {
+		t.Error("id0 should have been evicted")
+	}
+}
+
+func TestSeenSetRefreshKeepsHotIDs(t *testing.T) {
+	ss := NewSeenSet(3)
+	ss.Add("hot")
+	for i := range 10 {
+		ss.Add(fmt.Sprintf("cold%d", i))
+		// Seeing hot again moves it to the front of the LRU
+		if ss.Add("hot") {
+			t.Fatalf("hot was evicted after cold%d", i)
+		}
+	}
+}
+
+func TestSeenSetReset(t *testing.T) {
+	ss := NewSeenSet(0)
+	ss.Add("a")
+	ss.Reset()
+	if ss.Len() != 0 || !ss.Add("a") {
+		t.Error("Reset didn't forget a")
+	}
+}
@@ -0,0 +1,28 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+)
+
+// SynonymMatcher reports each hit under its canonical name, so "RF" and
+// "recordedfuture" both count as "recorded future". Canonical maps lowercased
+// aliases to canonical names; hits come out lowercased like the other
+// matchers' and are reported once.
+type SynonymMatcher struct {
+	Matcher
+	Canonical map[string]string
+}
+
+func (m SynonymMatcher) Match(text string) []string {
+	var hits []string
+	for _, h := range m.Matcher.Match(text) {
+		if c, ok := m.Canonical[strings.ToLower(h)]; ok {
+			h = strings.ToLower(c)
+		}
+		if !slices.Contains(hits, h) {
+			hits = append(hits, h)
+		}
+	}
+	return hits
+}
@@ -0,0 +1,35 @@
+package filter
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestSynonymMatcherCollapsesAliasesInCounts(t *testing.T) {
+	canonical := map[string]string{"rf": "Recorded Future", "recordedfuture": "Recorded Future"}
+	m := SynonymMatcher{
+		Matcher:   WordMatcher{"rf", "recordedfuture", "recorded future", "misp"},
+		Canonical: canonical,
+	}
+	titles := []string{
+		"RF threat intel pricing",
+		"RecordedFuture vs MISP",
+		"Recorded Future acquisition",
+		// Both an alias and the canonical name count once
+		"Recorded Future (RF) review",
+		"Perf tuning", // "rf" only inside a word
+	}
+	counts := map[string]int{}
+	for _, title := range titles {
+		for _, h := range m.Match(title) {
+			counts[h]++
+		}
+	}
+	if want := map[string]int{"recorded future": 4, "misp": 1}; !maps.Equal(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	if got := m.Match("Recorded Future (RF) review"); !slices.Equal(got, []string{"recorded future"}) {
+		t.Errorf("Match = %q, want a single canonical hit", got)
+	}
+}
@@ -0,0 +1,23 @@
+package filter
+
+import "unicode/utf8"
+
+// Ellipsis marks a truncated title
+const Ellipsis = "…"
+
+// TruncateRunes caps s at max runes, replacing the tail with an ellipsis.
+// It counts and cuts on rune boundaries so multi-byte characters (emoji,
+// CJK) are never split. max <= 0 disables truncation.
+func TruncateRunes(s string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	n := 0
+	for i := range s {
+		if n == max-1 {
+			return s[:i] + Ellipsis
+		}
+		n++
+	}
+	return s
+}
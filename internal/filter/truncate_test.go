@@ -0,0 +1,37 @@
+package filter
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short title", 20, "short title"},
+		{"exactly ten", 11, "exactly ten"},
+		{"a longer title", 8, "a longe…"},
+		{"unlimited title", 0, "unlimited title"},
+		{"negative", -1, "negative"},
+		// Multi-byte runes are counted and cut whole
+		{"日本語のタイトルです", 5, "日本語の…"},
+		{"🔥🔥🔥 hot take", 3, "🔥🔥…"},
+		{"é", 1, "é"},
+		{"éé", 1, "…"},
+	}
+	for _, tt := range tests {
+		got := TruncateRunes(tt.in, tt.max)
+		if got != tt.want {
+			t.Errorf("TruncateRunes(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("TruncateRunes(%q, %d) = %q is not valid UTF-8", tt.in, tt.max, got)
+		}
+		if tt.max > 0 && utf8.RuneCountInString(got) > tt.max {
+			t.Errorf("TruncateRunes(%q, %d) = %q is longer than the cap", tt.in, tt.max, got)
+		}
+	}
+}
@@ -3,11 +3,17 @@ package ingest
 import (
 	"bufio"
 	"encoding/csv"
+	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/qepting91/reddit-scraper/internal/domain"
 )
@@ -15,15 +21,57 @@ import (
 // Regex for valid subreddit names
 var subNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]{3,21}$`)
 
+// Regexes for the components of a multireddit path (/user/<owner>/m/<name>)
+var (
+	userNameRegex  = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+	multiNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]{2,50}$`)
+)
+
+// LoadTargets reads a targets CSV whose first row is a header. A path of
+// "-" reads stdin.
 func LoadTargets(path string) ([]domain.Target, error) {
+	return LoadTargetsHeader(path, true)
+}
+
+// LoadTargetsHeader is LoadTargets with the header row optional, e.g. for
+// target lists piped in by another tool
+func LoadTargetsHeader(path string, header bool) ([]domain.Target, error) {
+	return LoadTargetsWith(path, TargetOptions{Header: header})
+}
+
+// TargetOptions control how a targets CSV is read
+type TargetOptions struct {
+	// Header skips the first row
+	Header bool
+	// AutoCorrect fixes common subreddit name slips ("r/netsec", "net-sec")
+	// instead of skipping the row; see CorrectSubreddit
+	AutoCorrect bool
+}
+
+// LoadTargetsWith is LoadTargets with explicit options
+func LoadTargetsWith(path string, opts TargetOptions) ([]domain.Target, error) {
+	if path == "-" {
+		return ReadTargetsWith(os.Stdin, opts)
+	}
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return ReadTargetsWith(f, opts)
+}
+
+// ReadTargets parses targets CSV from r, skipping the first row when header is set
+func ReadTargets(in io.Reader, header bool) ([]domain.Target, error) {
+	return ReadTargetsWith(in, TargetOptions{Header: header})
+}
 
+// ReadTargetsWith parses targets CSV from r with explicit options
+func ReadTargetsWith(in io.Reader, opts TargetOptions) ([]domain.Target, error) {
 	// Wrap in BOM stripper
-	r := csv.NewReader(stripBOM(f))
+	r := csv.NewReader(stripBOM(in))
+	// Piped lists often give just "subreddit" or "subreddit,score"
+	r.FieldsPerRecord = -1
 	
 	var targets []domain.Target
 	line := 0
@@ -36,42 +84,270 @@ func LoadTargets(path string) ([]domain.Target, error) {
 			continue
 		}
 		line++
-		if line == 1 { continue } // Skip header
+		if line == 1 && opts.Header { continue } // Skip header
 
 		// Validation (Fail-Soft)
 		sub := strings.TrimSpace(record[0])
-		if !subNameRegex.MatchString(sub) {
-			continue 
+		// min_score defaults to 0 when the column is missing
+		var score int
+		if len(record) > 1 {
+			score, _ = strconv.Atoi(strings.TrimSpace(record[1]))
+		}
+
+		// Optional third column: per-target sorts, e.g. "new|hot"
+		var sorts []string
+		if len(record) > 2 {
+			sorts = parseSorts(record[2])
+		}
+		// Optional fourth column: per-target post kinds, e.g. "self|link"
+		var kinds []domain.PostKind
+		if len(record) > 3 {
+			kinds = ParseKinds(strings.Split(record[3], "|"))
+		}
+		// Optional fifth column: monitor-mode polling interval, e.g. "5m"
+		var interval time.Duration
+		if len(record) > 4 && strings.TrimSpace(record[4]) != "" {
+			val := strings.TrimSpace(record[4])
+			if d, err := time.ParseDuration(val); err == nil && d > 0 {
+				interval = d
+			} else {
+				slog.Warn("Invalid target interval (e.g. 15m), using SCRAPE_INTERVAL", "line", line, "val", val)
+			}
 		}
 
-		score, _ := strconv.Atoi(strings.TrimSpace(record[1]))
+		if owner, multi, ok := parseMultiPath(sub); ok {
+			targets = append(targets, domain.Target{
+				Kind:     domain.KindMulti,
+				Owner:    owner,
+				Multi:    multi,
+				MinScore: score,
+				Sorts:    sorts,
+				Kinds:    kinds,
+				Interval: interval,
+			})
+			continue
+		}
+
+		if !subNameRegex.MatchString(sub) {
+			fixed, err := CorrectSubreddit(sub)
+			switch {
+			case err != nil:
+				slog.Warn("Skipping invalid target (want a subreddit name, an /r/ link or a /user/<owner>/m/<name> path)", "line", line, "name", sub, "err", err)
+				continue
+			case !subNameRegex.MatchString(fixed):
+				slog.Warn("Skipping invalid subreddit name (want 3-21 letters, digits or underscores)", "line", line, "name", sub)
+				continue
+			case !opts.AutoCorrect:
+				slog.Warn("Skipping invalid subreddit name, enable -fix-targets to accept the suggestion", "line", line, "name", sub, "suggestion", fixed)
+				continue
+			}
+			slog.Info("Corrected subreddit name", "line", line, "name", sub, "corrected", fixed)
+			sub = fixed
+		}
 
 		targets = append(targets, domain.Target{
+			Kind:      domain.KindSubreddit,
 			Subreddit: sub,
 			MinScore:  score,
+			Sorts:     sorts,
+			Kinds:     kinds,
+			Interval:  interval,
 		})
 	}
-	return targets, nil
+	return mergeDuplicateTargets(targets), nil
+}
+
+// CorrectSubreddit applies light fixes to a malformed subreddit name: it
+// trims spaces, drops an "r/" prefix (or a full .../r/<name>/ URL) and any
+// trailing path, and removes characters Reddit doesn't allow in names. The
+// result still has to be checked; "a" stays too short. Any other path or
+// URL (e.g. a user profile) is an error rather than a name to squash.
+func CorrectSubreddit(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	lower := strings.ToLower(name)
+	if i := strings.Index(lower, "/r/"); i >= 0 {
+		name = name[i+3:]
+	} else if strings.HasPrefix(lower, "r/") {
+		name = name[2:]
+	} else if strings.ContainsAny(name, "/.") {
+		return "", fmt.Errorf("%q is a path or URL without an /r/<name> segment", name)
+	}
+	name, _, _ = strings.Cut(name, "/")
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			return r
+		}
+		return -1
+	}, name), nil
+}
+
+// mergeDuplicateTargets collapses rows naming the same target (names compare
+// case-insensitively, as on Reddit) into the first one, so it is fetched once.
+// The merge is the most inclusive: the lower MinScore, the union of sorts
+// and kinds, where an empty list (use the global setting) wins outright, and
+// the shorter explicit interval.
+func mergeDuplicateTargets(targets []domain.Target) []domain.Target {
+	index := make(map[string]int, len(targets))
+	merged := targets[:0:0]
+	for _, t := range targets {
+		key := strings.ToLower(t.Name())
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, t)
+			continue
+		}
+		m := &merged[i]
+		slog.Warn("Duplicate target in CSV, merging rows", "target", t.Name(), "min_score", min(m.MinScore, t.MinScore))
+		m.MinScore = min(m.MinScore, t.MinScore)
+		m.Sorts = unionOrAll(m.Sorts, t.Sorts)
+		m.Kinds = unionOrAll(m.Kinds, t.Kinds)
+		if t.Interval > 0 && (m.Interval == 0 || t.Interval < m.Interval) {
+			m.Interval = t.Interval
+		}
+	}
+	return merged
+}
+
+// unionOrAll unions two override lists; an empty list means "all" and wins
+func unionOrAll[T comparable](a, b []T) []T {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	out := slices.Clone(a)
+	for _, v := range b {
+		if !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseMultiPath recognises "/user/<owner>/m/<name>" (also "user/...", "u/...")
+// and returns its validated components
+func parseMultiPath(s string) (owner, multi string, ok bool) {
+	parts := strings.Split(strings.Trim(s, "/"), "/")
+	if len(parts) != 4 || (parts[0] != "user" && parts[0] != "u") || parts[2] != "m" {
+		return "", "", false
+	}
+	if !userNameRegex.MatchString(parts[1]) || !multiNameRegex.MatchString(parts[3]) {
+		return "", "", false
+	}
+	return parts[1], parts[3], true
+}
+
+// parseSorts splits a "|" separated sort list, dropping names Reddit doesn't support
+func parseSorts(field string) []string {
+	var sorts []string
+	for _, s := range strings.Split(field, "|") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if domain.ValidSort(s) {
+			sorts = append(sorts, s)
+		}
+	}
+	return sorts
+}
+
+// ParseKinds normalises post kind names, dropping unknown ones
+func ParseKinds(names []string) []domain.PostKind {
+	var kinds []domain.PostKind
+	for _, name := range names {
+		k := domain.PostKind(strings.ToLower(strings.TrimSpace(name)))
+		if domain.ValidPostKind(k) {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+// ExcludeTargets drops targets whose name matches any entry in exclude
+// (case-insensitive, "r/" prefix optional). Multireddits match on their
+// "user/<owner>/m/<name>" path.
+func ExcludeTargets(targets []domain.Target, exclude []string) (kept, excluded []domain.Target) {
+	if len(exclude) == 0 {
+		return targets, nil
+	}
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.TrimPrefix(strings.Trim(name, "/"), "r/")
+		if name != "" {
+			skip[name] = true
+		}
+	}
+	for _, t := range targets {
+		if skip[strings.ToLower(t.Name())] {
+			excluded = append(excluded, t)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept, excluded
+}
+
+// Keyword is one entry of keywords.csv
+type Keyword struct {
+	Term string
+	// Regex marks a pattern: category "regex" or a /.../ term
+	Regex bool
+	// Line is the entry's line in the file, for error messages
+	Line int
 }
 
-func LoadKeywords(path string) ([]string, error) {
+// LoadKeywords reads keywords.csv. Regex entries are compiled here so a bad
+// pattern fails the load with its line number instead of surfacing mid-cycle.
+func LoadKeywords(path string) ([]Keyword, error) {
 	f, err := os.Open(path)
 	if err != nil { return nil, err }
 	defer f.Close()
 	r := csv.NewReader(stripBOM(f))
-	var kws []string
+	r.FieldsPerRecord = -1
+	var kws []Keyword
 	line := 0
 	for {
 		rec, err := r.Read()
 		if err == io.EOF { break }
 		if line > 0 && len(rec) > 0 {
-			kws = append(kws, strings.ToLower(strings.TrimSpace(rec[0])))
+			lineNo, _ := r.FieldPos(0)
+			kw, err := parseKeyword(rec, lineNo)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if kw.Term != "" {
+				kws = append(kws, kw)
+			}
 		}
 		line++
 	}
 	return kws, nil
 }
 
+func parseKeyword(rec []string, lineNo int) (Keyword, error) {
+	// Case is left alone; matchers normalise it (regex patterns need it intact)
+	kw := Keyword{Term: strings.TrimSpace(rec[0]), Line: lineNo}
+	if len(rec) > 1 && strings.EqualFold(strings.TrimSpace(rec[1]), "regex") {
+		kw.Regex = true
+	} else if len(kw.Term) > 2 && strings.HasPrefix(kw.Term, "/") && strings.HasSuffix(kw.Term, "/") {
+		kw.Term = kw.Term[1 : len(kw.Term)-1]
+		kw.Regex = true
+	}
+	if kw.Regex {
+		if _, err := regexp.Compile(kw.Term); err != nil {
+			return kw, fmt.Errorf("line %d: invalid regex %q: %w", lineNo, kw.Term, err)
+		}
+	}
+	return kw, nil
+}
+
+// KeywordTerms returns the terms of kws, e.g. for logging
+func KeywordTerms(kws []Keyword) []string {
+	terms := make([]string, len(kws))
+	for i, kw := range kws {
+		terms[i] = kw.Term
+	}
+	return terms
+}
+
 func stripBOM(r io.Reader) io.Reader {
 	br := bufio.NewReader(r)
 	rdr, _, err := br.ReadRune()
@@ -79,3 +355,53 @@ func stripBOM(r io.Reader) io.Reader {
 	if rdr != '\uFEFF' { br.UnreadRune() }
 	return br
 }
+
+// LoadSynonyms reads synonyms.csv ("alias,canonical" rows after a header)
+// into a map from lowercased alias to canonical name. An alias listed for two
+// canonicals keeps the first; that, and aliases nested in one another
+// ("rf" inside "rfc"), are logged since they can tag the wrong tool.
+func LoadSynonyms(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(stripBOM(f))
+	r.FieldsPerRecord = -1
+
+	synonyms := make(map[string]string)
+	var aliases []string
+	line := 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil || line == 1 || len(rec) < 2 {
+			continue
+		}
+		alias := strings.ToLower(strings.TrimSpace(rec[0]))
+		canonical := strings.TrimSpace(rec[1])
+		if alias == "" || canonical == "" || strings.EqualFold(alias, canonical) {
+			continue
+		}
+		if prev, ok := synonyms[alias]; ok {
+			if !strings.EqualFold(prev, canonical) {
+				slog.Warn("Ambiguous synonym, keeping the first mapping", "alias", alias, "kept", prev, "ignored", canonical)
+			}
+			continue
+		}
+		synonyms[alias] = canonical
+		aliases = append(aliases, alias)
+	}
+
+	for _, a := range aliases {
+		for _, b := range aliases {
+			if a != b && strings.Contains(b, a) && !strings.EqualFold(synonyms[a], synonyms[b]) {
+				slog.Warn("Overlapping synonyms map to different keywords", "alias", a, "within", b, "maps_to", synonyms[a], "other_maps_to", synonyms[b])
+			}
+		}
+	}
+	return synonyms, nil
+}
@@ -0,0 +1,282 @@
+package ingest
+
+import (
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestCorrectSubreddit(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"r/netsec", "netsec"},
+		{" netsec ", "netsec"},
+		{"R/NetSec", "NetSec"},
+		{"net-sec", "netsec"},
+		{"https://www.reddit.com/r/netsec/comments/abc/title/", "netsec"},
+		{"old.reddit.com/r/blueteamsec", "blueteamsec"},
+		// Fixed but still invalid; the loader rejects it
+		{"a", "a"},
+		{"r/!!", ""},
+	}
+	for _, tt := range tests {
+		got, err := CorrectSubreddit(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("CorrectSubreddit(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestCorrectSubredditRejectsOtherPaths(t *testing.T) {
+	for _, in := range []string{
+		"www.reddit.com/user/foo",
+		"https://reddit.com/user/x/m/y",
+		"reddit.com",
+		"netsec/top",
+	} {
+		if got, err := CorrectSubreddit(in); err == nil {
+			t.Errorf("CorrectSubreddit(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestReadTargetsAutoCorrect(t *testing.T) {
+	csv := "subreddit,min_score\n" +
+		"r/netsec,5\n" +
+		" malware ,5\n" +
+		"www.reddit.com/user/foo,5\n" +
+		"https://reddit.com/user/x/m/y,5\n" +
+		"a,5\n" +
+		"/user/someone/m/security,5\n"
+
+	names := func(opts TargetOptions) []string {
+		targets, err := ReadTargetsWith(strings.NewReader(csv), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, tgt := range targets {
+			out = append(out, tgt.Name())
+		}
+		return out
+	}
+
+	if got, want := names(TargetOptions{Header: true, AutoCorrect: true}), []string{"netsec", "malware", "user/someone/m/security"}; !slices.Equal(got, want) {
+		t.Errorf("with auto-correct: %v, want %v", got, want)
+	}
+	// Without it the slips are skipped, not silently fixed (spaces are always trimmed)
+	if got, want := names(TargetOptions{Header: true}), []string{"malware", "user/someone/m/security"}; !slices.Equal(got, want) {
+		t.Errorf("without auto-correct: %v, want %v", got, want)
+	}
+}
+
+func TestReadTargetsMultireddit(t *testing.T) {
+	csv := "subreddit,min_score\n" +
+		"/user/someone/m/security,5\n" +
+		"u/other_user/m/blue_team,0\n" +
+		"netsec,10\n" +
+		"/user/someone/m/x,5\n" + // multi name too short
+		"/user/someone/security,5\n"
+	targets, err := ReadTargets(strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.Target{
+		{Kind: domain.KindMulti, Owner: "someone", Multi: "security", MinScore: 5},
+		{Kind: domain.KindMulti, Owner: "other_user", Multi: "blue_team"},
+		{Kind: domain.KindSubreddit, Subreddit: "netsec", MinScore: 10},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("got %d targets %+v, want %d", len(targets), targets, len(want))
+	}
+	for i, got := range targets {
+		if got.Kind != want[i].Kind || got.Owner != want[i].Owner || got.Multi != want[i].Multi ||
+			got.Subreddit != want[i].Subreddit || got.MinScore != want[i].MinScore {
+			t.Errorf("target %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if name := targets[0].Name(); name != "user/someone/m/security" {
+		t.Errorf("Name() = %q, want user/someone/m/security", name)
+	}
+}
+
+func TestExcludeTargets(t *testing.T) {
+	targets := []domain.Target{
+		{Subreddit: "netsec"},
+		{Subreddit: "AskNetsec"},
+		{Subreddit: "Malware"},
+		{Kind: domain.KindMulti, Owner: "someone", Multi: "security"},
+		{Subreddit: "blueteamsec"},
+	}
+	kept, excluded := ExcludeTargets(targets, []string{"r/malware", " ASKNETSEC ", "/user/someone/m/security/", ""})
+
+	names := func(ts []domain.Target) []string {
+		var out []string
+		for _, tgt := range ts {
+			out = append(out, tgt.Name())
+		}
+		return out
+	}
+	if got, want := names(kept), []string{"netsec", "blueteamsec"}; !slices.Equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+	if got, want := names(excluded), []string{"AskNetsec", "Malware", "user/someone/m/security"}; !slices.Equal(got, want) {
+		t.Errorf("excluded %v, want %v", got, want)
+	}
+
+	if kept, excluded := ExcludeTargets(targets, nil); len(kept) != len(targets) || excluded != nil {
+		t.Errorf("no exclusions: kept %d, excluded %v", len(kept), excluded)
+	}
+}
+
+// writeKeywords writes a keywords.csv with content and returns its path
+func writeKeywords(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keywords.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadKeywordsRegex(t *testing.T) {
+	path := writeKeywords(t, "keyword,category\n"+
+		"Splunk,siem\n"+
+		`CVE-\d{4}-\d+,regex`+"\n"+
+		"/crowd[- ]?strike/,edr\n"+
+		"/,edr\n")
+	kws, err := LoadKeywords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Keyword{
+		{Term: "Splunk", Line: 2},
+		{Term: `CVE-\d{4}-\d+`, Regex: true, Line: 3},
+		{Term: "crowd[- ]?strike", Regex: true, Line: 4},
+		// Too short to be a /.../ pattern
+		{Term: "/", Line: 5},
+	}
+	if !slices.Equal(kws, want) {
+		t.Errorf("LoadKeywords = %+v, want %+v", kws, want)
+	}
+}
+
+func TestLoadKeywordsInvalidRegex(t *testing.T) {
+	for _, content := range []string{
+		"keyword,category\nSplunk,siem\nCVE-(\\d+,regex\n",
+		"keyword,category\nSplunk,siem\n/CVE-(\\d+/,edr\n",
+	} {
+		_, err := LoadKeywords(writeKeywords(t, content))
+		if err == nil || !strings.Contains(err.Error(), "line 3") || !strings.Contains(err.Error(), "invalid regex") {
+			t.Errorf("err = %v, want an invalid regex error on line 3", err)
+		}
+	}
+}
+
+func TestReadTargetsMergesDuplicates(t *testing.T) {
+	csv := "subreddit,min_score,sorts,kinds,interval\n" +
+		"netsec,50,new,self,30m\n" +
+		"malware,10,,,\n" +
+		"NetSec,5,hot,link|self,10m\n" +
+		"netsec,20,new,,1h\n" +
+		"/user/someone/m/security,3,,,\n" +
+		"user/Someone/m/Security,1,,,\n"
+	targets, err := ReadTargets(strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("got %d targets, want 3: %+v", len(targets), targets)
+	}
+
+	// Merged into the first row: the lowest score, the shortest interval,
+	// and an empty kinds list (all kinds) wins over the explicit ones
+	netsec := targets[0]
+	if netsec.Subreddit != "netsec" || netsec.MinScore != 5 || netsec.Interval != 10*time.Minute {
+		t.Errorf("netsec = %+v, want min_score 5 and interval 10m", netsec)
+	}
+	if !slices.Equal(netsec.Sorts, []string{"new", "hot"}) || netsec.Kinds != nil {
+		t.Errorf("netsec sorts %v kinds %v, want new hot and every kind", netsec.Sorts, netsec.Kinds)
+	}
+	if targets[1].Subreddit != "malware" {
+		t.Errorf("second target = %+v, want malware in file order", targets[1])
+	}
+	if multi := targets[2]; multi.Kind != domain.KindMulti || multi.MinScore != 1 {
+		t.Errorf("multi = %+v, want the two rows merged with min_score 1", multi)
+	}
+}
+
+func TestReadTargetsFromReader(t *testing.T) {
+	names := func(targets []domain.Target) []string {
+		var out []string
+		for _, tgt := range targets {
+			out = append(out, tgt.Name())
+		}
+		return out
+	}
+
+	// Piped lists often have no header and only some columns
+	targets, err := ReadTargets(strings.NewReader("netsec,100\nmalware\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(targets); !slices.Equal(got, []string{"netsec", "malware"}) || targets[0].MinScore != 100 || targets[1].MinScore != 0 {
+		t.Errorf("headerless: %+v", targets)
+	}
+	// With a header (and a BOM from a spreadsheet export) the first row is skipped
+	targets, err = ReadTargets(strings.NewReader("\ufeffsubreddit,min_score\nnetsec,100\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(targets); !slices.Equal(got, []string{"netsec"}) {
+		t.Errorf("with header: %v, want netsec", got)
+	}
+}
+
+func TestLoadTargetsFromStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin })
+	go func() {
+		io.WriteString(w, "netsec,100\n")
+		w.Close()
+	}()
+
+	targets, err := LoadTargetsHeader("-", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].Subreddit != "netsec" || targets[0].MinScore != 100 {
+		t.Errorf("targets = %+v, want netsec with min_score 100", targets)
+	}
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	path := writeKeywords(t, "alias,canonical\n"+
+		"RF,Recorded Future\n"+
+		" recordedfuture , Recorded Future\n"+
+		"rf,CrowdStrike\n"+
+		"crowdstrike,CrowdStrike\n"+
+		"falcon\n")
+	synonyms, err := LoadSynonyms(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The conflicting second "rf" row, the self-mapping and the short row are dropped
+	want := map[string]string{"rf": "Recorded Future", "recordedfuture": "Recorded Future"}
+	if !maps.Equal(synonyms, want) {
+		t.Errorf("LoadSynonyms = %v, want %v", synonyms, want)
+	}
+}
@@ -0,0 +1,147 @@
+// Package schedule decides when scraping should pause
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuietHours is a set of weekly time windows during which no cycles run
+// (QUIET_HOURS), evaluated in its own time zone (QUIET_HOURS_TZ)
+type QuietHours struct {
+	windows []window
+	loc     *time.Location
+}
+
+// window covers [start, end) minutes past midnight on the listed start days.
+// A window with end <= start runs past midnight into the next day.
+type window struct {
+	days       [7]bool // indexed by time.Weekday
+	start, end int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseQuietHours reads windows separated by ";", each "[days ]HH:MM-HH:MM",
+// e.g. "22:00-06:00; Sat-Sun 00:00-24:00" or "Mon,Wed 12:00-13:00". Days
+// default to every day; a window crossing midnight belongs to the day it
+// starts on. A nil loc means time.Local. An empty spec yields nil (never quiet).
+func ParseQuietHours(spec string, loc *time.Location) (*QuietHours, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	q := &QuietHours{loc: loc}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseWindow(part)
+		if err != nil {
+			return nil, fmt.Errorf("quiet hours %q: %w", part, err)
+		}
+		q.windows = append(q.windows, w)
+	}
+	if len(q.windows) == 0 {
+		return nil, nil
+	}
+	return q, nil
+}
+
+func parseWindow(s string) (window, error) {
+	var w window
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		for d := range w.days {
+			w.days[d] = true
+		}
+	case 2:
+		if err := parseDays(fields[0], &w.days); err != nil {
+			return w, err
+		}
+	default:
+		return w, fmt.Errorf("want \"[days ]HH:MM-HH:MM\"")
+	}
+
+	from, to, ok := strings.Cut(fields[len(fields)-1], "-")
+	if !ok {
+		return w, fmt.Errorf("want a HH:MM-HH:MM range")
+	}
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return w, err
+	}
+	if w.start == w.end || w.start == 24*60 {
+		return w, fmt.Errorf("empty time range")
+	}
+	return w, nil
+}
+
+// parseDays reads "Mon-Fri" or "Sat,Sun" (ranges may wrap, e.g. "Fri-Mon")
+func parseDays(s string, days *[7]bool) error {
+	for _, item := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock reads "HH:MM" as minutes past midnight; "24:00" is allowed as an end
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hh < 0 || mm < 0 || mm > 59 || hh > 24 || (hh == 24 && mm != 0) {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return hh*60 + mm, nil
+}
+
+// Active reports whether t falls inside a quiet window. A nil QuietHours is
+// never active.
+func (q *QuietHours) Active(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	t = t.In(q.loc)
+	now := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	for _, w := range q.windows {
+		if w.start < w.end {
+			if w.days[today] && now >= w.start && now < w.end {
+				return true
+			}
+			continue
+		}
+		// Crosses midnight: the evening part today, the morning part from yesterday's start
+		if (w.days[today] && now >= w.start) || (w.days[yesterday] && now < w.end) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at is a time on the week of Sunday 2025-06-01, in UTC
+func at(day time.Weekday, hour, minute int) time.Time {
+	return time.Date(2025, 6, 1+int(day), hour, minute, 0, 0, time.UTC)
+}
+
+func TestQuietHoursActive(t *testing.T) {
+	q, err := ParseQuietHours("22:00-06:00; Sat-Sun 00:00-24:00; Mon,Wed 12:00-13:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		t     time.Time
+		quiet bool
+	}{
+		{at(time.Tuesday, 21, 59), false},
+		{at(time.Tuesday, 22, 0), true},
+		// After midnight, still in Tuesday's overnight window
+		{at(time.Wednesday, 5, 59), true},
+		{at(time.Wednesday, 6, 0), false},
+		{at(time.Wednesday, 12, 30), true},
+		{at(time.Thursday, 12, 30), false},
+		{at(time.Monday, 13, 0), false},
+		{at(time.Saturday, 15, 0), true},
+		{at(time.Sunday, 23, 59), true},
+	}
+	for _, tt := range tests {
+		if got := q.Active(tt.t); got != tt.quiet {
+			t.Errorf("Active(%s) = %v, want %v", tt.t.Format("Mon 15:04"), got, tt.quiet)
+		}
+	}
+}
+
+func TestQuietHoursUsesItsTimeZone(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	q, err := ParseQuietHours("09:00-17:00", tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 01:00 UTC is 10:00 in Tokyo
+	if !q.Active(at(time.Monday, 1, 0)) || q.Active(at(time.Monday, 10, 0)) {
+		t.Error("window not evaluated in QUIET_HOURS_TZ")
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	for _, spec := range []string{"", " ; "} {
+		q, err := ParseQuietHours(spec, nil)
+		if err != nil || q != nil {
+			t.Errorf("ParseQuietHours(%q) = %v, %v; want nil, nil", spec, q, err)
+		}
+		if q.Active(time.Now()) {
+			t.Errorf("empty spec %q is quiet", spec)
+		}
+	}
+	for _, spec := range []string{
+		"22:00",
+		"25:00-06:00",
+		"10:60-11:00",
+		"10:00-10:00",
+		"24:00-06:00",
+		"Funday 10:00-11:00",
+		"Mon Tue 10:00-11:00",
+	} {
+		if _, err := ParseQuietHours(spec, nil); err == nil {
+			t.Errorf("ParseQuietHours(%q) accepted", spec)
+		}
+	}
+	// Day ranges may wrap around the weekend
+	q, err := ParseQuietHours("Fri-Mon 10:00-11:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.Active(at(time.Sunday, 10, 30)) || q.Active(at(time.Wednesday, 10, 30)) {
+		t.Error("Fri-Mon didn't wrap through the weekend")
+	}
+}
@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// Spacing keeps requests for the same target at least Min apart, across
+// cycles as well as within one (SUBREDDIT_MIN_INTERVAL), so a misconfigured
+// interval or a burst of on-demand scrapes can't hammer one subreddit. It is
+// safe for concurrent use by the workers.
+type Spacing struct {
+	Min time.Duration
+
+	mu sync.Mutex
+	// last is when each target (by lowercased name) was last given a slot
+	last map[string]time.Time
+}
+
+// NewSpacing returns a Spacing where every target may be requested at once
+func NewSpacing(min time.Duration) *Spacing {
+	return &Spacing{Min: min, last: make(map[string]time.Time)}
+}
+
+// Reserve claims t's next slot at or after now and returns how long to wait
+// for it (0 when t is free). Concurrent callers for one target get
+// successive slots.
+func (sp *Spacing) Reserve(t domain.Target, now time.Time) time.Duration {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	slot := now
+	if last, ok := sp.last[key(t)]; ok && now.Before(last.Add(sp.Min)) {
+		slot = last.Add(sp.Min)
+	}
+	sp.last[key(t)] = slot
+	return slot.Sub(now)
+}
@@ -0,0 +1,33 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestSpacingReserve(t *testing.T) {
+	sp := NewSpacing(time.Minute)
+	netsec := domain.Target{Subreddit: "netsec"}
+	now := at(time.Monday, 9, 0)
+
+	if wait := sp.Reserve(netsec, now); wait != 0 {
+		t.Errorf("first request waits %v, want 0", wait)
+	}
+	// Back-to-back callers, in this cycle or the next, queue up a minute apart
+	if wait := sp.Reserve(netsec, now.Add(10*time.Second)); wait != 50*time.Second {
+		t.Errorf("second request waits %v, want 50s", wait)
+	}
+	if wait := sp.Reserve(domain.Target{Subreddit: "NetSec"}, now.Add(10*time.Second)); wait != 110*time.Second {
+		t.Errorf("third request waits %v, want 110s", wait)
+	}
+	// Other targets aren't held up
+	if wait := sp.Reserve(domain.Target{Subreddit: "malware"}, now.Add(10*time.Second)); wait != 0 {
+		t.Errorf("another target waits %v, want 0", wait)
+	}
+	// Once the gap has passed the target is free again
+	if wait := sp.Reserve(netsec, now.Add(5*time.Minute)); wait != 0 {
+		t.Errorf("request after the gap waits %v, want 0", wait)
+	}
+}
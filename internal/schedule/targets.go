@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"strings"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// Intervals schedules each target on its own polling interval in monitor
+// mode (SCRAPE_INTERVAL). Targets without an Interval use Default. It is not
+// safe for concurrent use; the scrape loop owns it.
+type Intervals struct {
+	Default time.Duration
+	// next is when each target (by lowercased name) is due again
+	next map[string]time.Time
+}
+
+// NewIntervals returns a schedule where every target is due immediately
+func NewIntervals(def time.Duration) *Intervals {
+	return &Intervals{Default: def, next: make(map[string]time.Time)}
+}
+
+// Interval is how often t is polled
+func (iv *Intervals) Interval(t domain.Target) time.Duration {
+	if t.Interval > 0 {
+		return t.Interval
+	}
+	return iv.Default
+}
+
+// Due returns the targets due at now and schedules their next run, as if
+// they were scraped at now. Targets not seen before are due immediately.
+func (iv *Intervals) Due(targets []domain.Target, now time.Time) []domain.Target {
+	var due []domain.Target
+	for _, t := range targets {
+		if next, ok := iv.next[key(t)]; ok && now.Before(next) {
+			continue
+		}
+		due = append(due, t)
+	}
+	iv.Mark(due, now)
+	return due
+}
+
+// Mark records targets as scraped at now, e.g. by an on-demand cycle
+func (iv *Intervals) Mark(targets []domain.Target, now time.Time) {
+	for _, t := range targets {
+		iv.next[key(t)] = now.Add(iv.Interval(t))
+	}
+}
+
+// Next returns when the earliest of targets is due; a target not seen
+// before makes that now
+func (iv *Intervals) Next(targets []domain.Target, now time.Time) time.Time {
+	var earliest time.Time
+	for _, t := range targets {
+		next, ok := iv.next[key(t)]
+		if !ok {
+			return now
+		}
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+// key identifies a target across reloads; names compare case-insensitively
+func key(t domain.Target) string {
+	return strings.ToLower(t.Name())
+}
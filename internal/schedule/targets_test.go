@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestIntervalsPollFastTargetsMoreOften(t *testing.T) {
+	fast := domain.Target{Subreddit: "cybersecurity", Interval: 5 * time.Minute}
+	slow := domain.Target{Subreddit: "netsec"} // the default hour
+	iv := NewIntervals(time.Hour)
+
+	counts := map[string]int{}
+	start := at(time.Monday, 9, 0)
+	// Tick every minute for two hours, as the monitor loop would
+	for now := start; now.Before(start.Add(2 * time.Hour)); now = now.Add(time.Minute) {
+		for _, t := range iv.Due([]domain.Target{fast, slow}, now) {
+			counts[t.Name()]++
+		}
+	}
+	if counts[fast.Name()] != 24 || counts[slow.Name()] != 2 {
+		t.Errorf("enqueued fast %d and slow %d times, want 24 and 2", counts[fast.Name()], counts[slow.Name()])
+	}
+}
+
+func TestIntervalsNext(t *testing.T) {
+	fast := domain.Target{Subreddit: "cybersecurity", Interval: 5 * time.Minute}
+	slow := domain.Target{Subreddit: "netsec"}
+	iv := NewIntervals(time.Hour)
+	now := at(time.Monday, 9, 0)
+
+	if got := iv.Next([]domain.Target{fast, slow}, now); !got.Equal(now) {
+		t.Errorf("Next before any run = %v, want now", got)
+	}
+	iv.Due([]domain.Target{fast, slow}, now)
+	if got := iv.Next([]domain.Target{fast, slow}, now); !got.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("Next = %v, want the fast target's next run", got)
+	}
+	// A target added by a reload is due straight away
+	added := domain.Target{Subreddit: "malware"}
+	if got := iv.Next([]domain.Target{fast, slow, added}, now); !got.Equal(now) {
+		t.Errorf("Next with a new target = %v, want now", got)
+	}
+}
+
+func TestIntervalsMarkDefersDueTargets(t *testing.T) {
+	target := domain.Target{Subreddit: "netsec"}
+	iv := NewIntervals(time.Hour)
+	now := at(time.Monday, 9, 0)
+
+	// An on-demand cycle at 9:30 pushes the next run to 10:30
+	iv.Mark([]domain.Target{target}, now.Add(30*time.Minute))
+	if due := iv.Due([]domain.Target{target}, now.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("due at 10:00 after a 9:30 run: %v", due)
+	}
+	// Names compare case-insensitively across reloads
+	if due := iv.Due([]domain.Target{{Subreddit: "NetSec"}}, now.Add(90*time.Minute)); len(due) != 1 {
+		t.Errorf("due at 10:30 = %v, want the target", due)
+	}
+}
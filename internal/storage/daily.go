@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DailyFile is the file under a daily output directory holding the posts
+// written on day, e.g. 2024-01-15.ndjson
+func DailyFile(dir string, day time.Time) string {
+	return filepath.Join(dir, day.Format(time.DateOnly)+".ndjson")
+}
+
+// dailyWriter appends records to one NDJSON file per calendar day in loc
+// (DAILY_OUTPUT). The day is taken at write time, so a cycle running past
+// midnight closes the old file and carries on in the new one.
+type dailyWriter struct {
+	dir      string
+	loc      *time.Location
+	encoding string
+
+	day string
+	f   *os.File
+	enc *json.Encoder
+}
+
+func newDailyWriter(dir string, loc *time.Location, encoding string) *dailyWriter {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &dailyWriter{dir: dir, loc: loc, encoding: encoding}
+}
+
+// Write appends record to the file for now's day, switching files when the
+// day has changed since the last write
+func (dw *dailyWriter) Write(record any, now time.Time) error {
+	now = now.In(dw.loc)
+	if day := now.Format(time.DateOnly); dw.f == nil || day != dw.day {
+		dw.Close()
+		f, err := os.OpenFile(DailyFile(dw.dir, now), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		enc := newEncoder(encodingWriter(f, dw.encoding))
+		if err := writeHeaderIfEmpty(f, enc); err != nil {
+			f.Close()
+			return err
+		}
+		dw.day, dw.f, dw.enc = day, f, enc
+	}
+	return dw.enc.Encode(record)
+}
+
+func (dw *dailyWriter) Close() {
+	if dw.f != nil {
+		dw.f.Close()
+		dw.f, dw.enc = nil, nil
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestDailyWriterSwitchesFileAtMidnight(t *testing.T) {
+	dir := t.TempDir()
+	// UTC-5, so the local day ends five hours after UTC's
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	dw := newDailyWriter(dir, loc, "")
+	defer dw.Close()
+
+	beforeMidnight := time.Date(2024, 1, 15, 23, 59, 59, 0, loc)
+	for i, now := range []time.Time{
+		beforeMidnight.Add(-time.Hour),
+		beforeMidnight,
+		// The cycle runs on past midnight
+		beforeMidnight.Add(2 * time.Second),
+	} {
+		if err := dw.Write(domain.Post{ID: string(rune('a' + i))}, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dw.Close()
+
+	old, next := DailyFile(dir, beforeMidnight), DailyFile(dir, beforeMidnight.Add(time.Minute))
+	if !strings.HasSuffix(old, "2024-01-15.ndjson") || !strings.HasSuffix(next, "2024-01-16.ndjson") {
+		t.Fatalf("daily files %s and %s", old, next)
+	}
+	if n := countRecords(t, old); n != 2 {
+		t.Errorf("%s holds %d records, want 2", old, n)
+	}
+	if n := countRecords(t, next); n != 1 {
+		t.Errorf("%s holds %d records, want the one written after midnight", next, n)
+	}
+
+	// Reopening a day appends to it under its one schema header
+	dw = newDailyWriter(dir, loc, "")
+	if err := dw.Write(domain.Post{ID: "d"}, beforeMidnight.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	dw.Close()
+	data, err := os.ReadFile(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers := countHeaders(data); headers != 1 || countRecords(t, next) != 2 {
+		t.Errorf("reopened day has %d headers and %d records, want 1 and 2", headers, countRecords(t, next))
+	}
+}
+
+func TestDailyWriterDefaultsToUTC(t *testing.T) {
+	dir := t.TempDir()
+	dw := newDailyWriter(dir, nil, "")
+	// 20:00 in UTC-5 is already the next day in UTC
+	now := time.Date(2024, 1, 15, 20, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+	if err := dw.Write(domain.Post{ID: "a"}, now); err != nil {
+		t.Fatal(err)
+	}
+	dw.Close()
+	if _, err := os.Stat(DailyFile(dir, now.UTC())); err != nil || !strings.HasSuffix(DailyFile(dir, now.UTC()), "2024-01-16.ndjson") {
+		t.Errorf("no UTC day file: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Output encodings for OUTPUT_ENCODING
+const (
+	EncodingUTF8 = "utf8"
+	// EncodingASCII writes non-ASCII characters as JSON \u escapes, so the
+	// file is pure ASCII but parsers still recover the original text
+	EncodingASCII = "ascii"
+	// EncodingASCIIStrip drops non-ASCII characters from the output entirely
+	EncodingASCIIStrip = "ascii-strip"
+)
+
+// ValidEncoding reports whether enc is a supported output encoding
+func ValidEncoding(enc string) bool {
+	switch enc {
+	case "", EncodingUTF8, EncodingASCII, EncodingASCIIStrip:
+		return true
+	}
+	return false
+}
+
+// encodingWriter wraps w for the given output encoding. It works on the
+// encoder's finished JSON: non-ASCII bytes only ever occur inside string
+// literals there, so escaping or dropping them can't break the syntax.
+func encodingWriter(w io.Writer, enc string) io.Writer {
+	switch enc {
+	case EncodingASCII:
+		return &asciiWriter{w: w}
+	case EncodingASCIIStrip:
+		return &asciiWriter{w: w, strip: true}
+	}
+	return w
+}
+
+type asciiWriter struct {
+	w     io.Writer
+	strip bool
+	buf   []byte
+}
+
+// Write converts one encoded record. json.Encoder hands over each record in
+// a single call, so runes are never split across writes.
+func (aw *asciiWriter) Write(p []byte) (int, error) {
+	aw.buf = aw.buf[:0]
+	for i := 0; i < len(p); {
+		if p[i] < utf8.RuneSelf {
+			aw.buf = append(aw.buf, p[i])
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(p[i:])
+		i += size
+		if aw.strip {
+			continue
+		}
+		if r > 0xFFFF {
+			// Outside the BMP JSON needs a UTF-16 surrogate pair
+			r -= 0x10000
+			aw.buf = fmt.Appendf(aw.buf, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		} else {
+			aw.buf = fmt.Appendf(aw.buf, `\u%04x`, r)
+		}
+	}
+	if _, err := aw.w.Write(aw.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+var emojiPost = domain.Post{
+	ID:          "e1",
+	Title:       "Splunk 🔥 café",
+	Subreddit:   "netsec",
+	KeywordsHit: []string{"splunk"},
+}
+
+func TestOutputEncodingUTF8(t *testing.T) {
+	for _, enc := range []string{"", EncodingUTF8} {
+		records := writeRecords(t, &WriterService{Encoding: enc}, emojiPost)
+		if !strings.Contains(records[0], `"title":"Splunk 🔥 café"`) {
+			t.Errorf("encoding %q changed the title: %s", enc, records[0])
+		}
+	}
+}
+
+func TestOutputEncodingASCIIEscapes(t *testing.T) {
+	w := &WriterService{Encoding: EncodingASCII}
+	records := writeRecords(t, w, emojiPost)
+	if !isASCII(records[0]) {
+		t.Fatalf("ascii output has non-ASCII bytes: %s", records[0])
+	}
+	// The emoji is outside the BMP, so it becomes a surrogate pair
+	if !strings.Contains(records[0], `"title":"Splunk \ud83d\udd25 caf\u00e9"`) {
+		t.Errorf("title not escaped as expected: %s", records[0])
+	}
+
+	// JSON parsers recover the original text
+	posts, err := LoadPosts(w.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts[0].Title != emojiPost.Title {
+		t.Errorf("loaded title %q, want %q", posts[0].Title, emojiPost.Title)
+	}
+}
+
+func TestOutputEncodingASCIIStrip(t *testing.T) {
+	records := writeRecords(t, &WriterService{Encoding: EncodingASCIIStrip}, emojiPost)
+	if !isASCII(records[0]) || !strings.Contains(records[0], `"title":"Splunk  caf"`) {
+		t.Errorf("non-ASCII not stripped: %s", records[0])
+	}
+	if !strings.Contains(records[0], `"keywords_hit":["splunk"]`) {
+		t.Errorf("keyword hits lost: %s", records[0])
+	}
+}
+
+func TestValidEncoding(t *testing.T) {
+	for _, enc := range []string{"", EncodingUTF8, EncodingASCII, EncodingASCIIStrip} {
+		if !ValidEncoding(enc) {
+			t.Errorf("ValidEncoding(%q) = false", enc)
+		}
+	}
+	if ValidEncoding("latin1") {
+		t.Error("ValidEncoding(latin1) = true")
+	}
+}
+
+func isASCII(s string) bool {
+	for i := range len(s) {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
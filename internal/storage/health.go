@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// SaveHealth writes per-target health (keyed by Target.Name) as JSON,
+// replaced atomically like the stats file
+func SaveHealth(path string, health map[string]domain.TargetHealth) error {
+	return writeJSONAtomic(path, health)
+}
+
+// LoadHealth reads a file written by SaveHealth. A missing file yields an empty map.
+func LoadHealth(path string) (map[string]domain.TargetHealth, error) {
+	health := make(map[string]domain.TargetHealth)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return health, nil
+	}
+	if err != nil {
+		return health, err
+	}
+	if err := json.Unmarshal(data, &health); err != nil {
+		return health, err
+	}
+	return health, nil
+}
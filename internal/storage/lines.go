@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"bufio"
+	"io"
+)
+
+// MaxLineBytes bounds a single NDJSON record when reading data files back.
+// bufio.Scanner's 64KB default is far too small for posts with long bodies.
+const MaxLineBytes = 16 << 20
+
+// ReadLines calls fn for every non-empty line in r. A line longer than maxLen
+// is skipped (reported through onSkip with its 1-based line number) instead of
+// aborting the read, so one oversized record can't hide the rest of the file.
+func ReadLines(r io.Reader, maxLen int, fn func(line []byte), onSkip func(lineNo int)) error {
+	br := bufio.NewReader(r)
+	var buf []byte
+	tooLong := false
+	lineNo := 0
+
+	for {
+		chunk, isPrefix, err := br.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !tooLong {
+			if len(buf)+len(chunk) > maxLen {
+				tooLong = true
+				buf = buf[:0]
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if isPrefix {
+			continue
+		}
+
+		lineNo++
+		if tooLong {
+			if onSkip != nil {
+				onSkip(lineNo)
+			}
+		} else if len(buf) > 0 {
+			fn(buf)
+		}
+		buf = buf[:0]
+		tooLong = false
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadLinesSkipsOversizedLine(t *testing.T) {
+	in := "first\n" + strings.Repeat("x", 100) + "\n\nthird\n" + strings.Repeat("y", 51) + "\nlast"
+	var lines []string
+	var skipped []int
+	err := ReadLines(strings.NewReader(in), 50, func(line []byte) {
+		lines = append(lines, string(line))
+	}, func(lineNo int) {
+		skipped = append(skipped, lineNo)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "third", "last"}; !slices.Equal(lines, want) {
+		t.Errorf("read %v, want %v", lines, want)
+	}
+	if want := []int{2, 5}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped lines %v, want %v", skipped, want)
+	}
+}
+
+func TestLoadPostsPastScannerLimit(t *testing.T) {
+	// Well past bufio.Scanner's 64KB default but within MaxLineBytes
+	long := strings.Repeat("selftext ", 20_000)
+	path := filepath.Join(t.TempDir(), "current.ndjson")
+	data := fmt.Sprintf("{\"id\":\"a\",\"title\":%q}\n{\"id\":\"b\",\"title\":\"after\"}\n", long)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	posts, err := LoadPosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].Title != long || posts[1].ID != "b" {
+		t.Errorf("loaded %d posts, want the long record and the one after it", len(posts))
+	}
+}
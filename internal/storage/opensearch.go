@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// OpenSearch defaults
+const (
+	DefaultOpenSearchIndex = "reddit-posts"
+	DefaultBulkSize        = 500
+	defaultBulkRetries     = 3
+	defaultBulkRetryDelay  = time.Second
+	defaultBulkFlushEvery  = 5 * time.Second
+)
+
+// openSearchMapping makes the time fields dates so the index can be
+// filtered and charted by them; everything else is mapped dynamically
+const openSearchMapping = `{"mappings":{"properties":{` +
+	`"created_utc":{"type":"date","format":"epoch_second"},` +
+	`"created_at":{"type":"date"},` +
+	`"scraped_at":{"type":"date"}}}}`
+
+// OpenSearchWriter bulk-indexes posts into an OpenSearch (or Elasticsearch)
+// index (STORAGE_BACKEND=opensearch). Each post's ID is its document _id, so
+// re-indexing a post updates it instead of duplicating it. Posts are sent in
+// batches of BatchSize, or every few seconds on a slow stream; documents the
+// cluster rejects with 429 or a 5xx are retried with backoff, other
+// rejections (e.g. mapping conflicts) are logged and dropped.
+type OpenSearchWriter struct {
+	// URL is the cluster base URL, e.g. https://search.example:9200
+	URL   string
+	Index string
+	// Username/Password use basic auth; APIKey sends "Authorization: ApiKey"
+	Username string
+	Password string
+	APIKey   string
+	// BatchSize is the number of posts per _bulk request (DefaultBulkSize when 0)
+	BatchSize int
+	// Fields optionally limits each document to these JSON keys, as in WriterService
+	Fields []string
+	// HTTPClient defaults to one with a 30s timeout
+	HTTPClient *http.Client
+
+	// MaxRetries and RetryDelay bound retries of failed documents; zero uses
+	// the defaults (3 retries, 1s doubling)
+	MaxRetries int
+	RetryDelay time.Duration
+
+	// Written counts the posts indexed; Failed those given up on. Read them
+	// only after Start returns.
+	Written int
+	Failed  int
+}
+
+// Consumed reports how many posts were indexed
+func (w *OpenSearchWriter) Consumed() int { return w.Written }
+
+func (w *OpenSearchWriter) Start(wg *sync.WaitGroup, input <-chan domain.Post) {
+	defer wg.Done()
+	w.applyDefaults()
+	if err := w.ensureIndex(); err != nil {
+		// Indexing still works through dynamic mapping, just without date types
+		slog.Warn("Could not create OpenSearch index mapping", "index", w.Index, "err", err)
+	}
+
+	ticker := time.NewTicker(defaultBulkFlushEvery)
+	defer ticker.Stop()
+	var batch []domain.Post
+	for {
+		select {
+		case post, ok := <-input:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, post)
+			if len(batch) >= w.BatchSize {
+				w.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = nil
+			}
+		}
+	}
+}
+
+func (w *OpenSearchWriter) applyDefaults() {
+	w.URL = strings.TrimRight(w.URL, "/")
+	if w.Index == "" {
+		w.Index = DefaultOpenSearchIndex
+	}
+	if w.BatchSize <= 0 {
+		w.BatchSize = DefaultBulkSize
+	}
+	if w.HTTPClient == nil {
+		w.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if w.MaxRetries <= 0 {
+		w.MaxRetries = defaultBulkRetries
+	}
+	if w.RetryDelay <= 0 {
+		w.RetryDelay = defaultBulkRetryDelay
+	}
+}
+
+// ensureIndex creates the index with the date mapping unless it exists
+func (w *OpenSearchWriter) ensureIndex() error {
+	resp, err := w.do(http.MethodHead, "/"+w.Index, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	resp, err = w.do(http.MethodPut, "/"+w.Index, []byte(openSearchMapping))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// A concurrent creator wins the race with a 400 resource_already_exists
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("creating index: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// flush indexes batch, retrying the documents that failed transiently
+func (w *OpenSearchWriter) flush(batch []domain.Post) {
+	delay := w.RetryDelay
+	for attempt := 0; len(batch) > 0; attempt++ {
+		retry, err := w.bulk(batch)
+		if err != nil {
+			slog.Warn("OpenSearch bulk request failed", "index", w.Index, "docs", len(batch), "attempt", attempt+1, "err", err)
+		}
+		if len(retry) == 0 {
+			return
+		}
+		if attempt == w.MaxRetries {
+			w.Failed += len(retry)
+			slog.Error("Giving up on posts OpenSearch keeps rejecting", "index", w.Index, "docs", len(retry))
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+		batch = retry
+	}
+}
+
+// bulkResponse is the part of a _bulk reply needed to find failed items
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends one _bulk request and returns the posts worth retrying. A
+// failed request (network, 429, 5xx) retries the whole batch.
+func (w *OpenSearchWriter) bulk(batch []domain.Post) ([]domain.Post, error) {
+	var body bytes.Buffer
+	enc := newEncoder(&body)
+	for _, post := range batch {
+		enc.Encode(map[string]map[string]string{"index": {"_index": w.Index, "_id": post.ID}})
+		var doc any = post
+		if len(w.Fields) > 0 {
+			doc = project(post, w.Fields)
+		}
+		enc.Encode(doc)
+	}
+
+	resp, err := w.do(http.MethodPost, "/_bulk", body.Bytes())
+	if err != nil {
+		return batch, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		io.Copy(io.Discard, resp.Body)
+		return batch, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		w.Failed += len(batch)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return batch, fmt.Errorf("decoding bulk response: %w", err)
+	}
+	if !result.Errors {
+		w.Written += len(batch)
+		return nil, nil
+	}
+
+	var retry []domain.Post
+	for i, item := range result.Items {
+		if i >= len(batch) {
+			break
+		}
+		for _, r := range item { // one entry, keyed by the action ("index")
+			switch {
+			case r.Status < 300:
+				w.Written++
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				retry = append(retry, batch[i])
+			default:
+				w.Failed++
+				slog.Warn("OpenSearch rejected post", "id", batch[i].ID, "status", r.Status, "err", string(r.Error))
+			}
+		}
+	}
+	return retry, nil
+}
+
+func (w *OpenSearchWriter) do(method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		contentType := "application/json"
+		if path == "/_bulk" {
+			contentType = "application/x-ndjson"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case w.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+w.APIKey)
+	case w.Username != "":
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	return w.HTTPClient.Do(req)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// bulkStub is an OpenSearch stand-in: the index doesn't exist yet, and each
+// _bulk item is answered with the status in reject[id] the first time the
+// document is sent (201 otherwise)
+type bulkStub struct {
+	mu       sync.Mutex
+	reject   map[string]int
+	sent     map[string]int // _id -> times sent
+	docs     map[string]domain.Post
+	mapping  string
+	requests int
+	auth     []string
+}
+
+func (st *bulkStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	user, pass, _ := r.BasicAuth()
+	st.auth = append(st.auth, user+":"+pass)
+
+	switch {
+	case r.Method == http.MethodHead && r.URL.Path == "/posts":
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPut && r.URL.Path == "/posts":
+		body, _ := io.ReadAll(r.Body)
+		st.mapping = string(body)
+	case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+		st.requests++
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			http.Error(w, "bad content type "+ct, http.StatusBadRequest)
+			return
+		}
+		var items []string
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var action map[string]struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id"`
+			}
+			json.Unmarshal(sc.Bytes(), &action)
+			sc.Scan()
+			var post domain.Post
+			json.Unmarshal(sc.Bytes(), &post)
+
+			id := action["index"].ID
+			st.sent[id]++
+			status := http.StatusCreated
+			if code, ok := st.reject[id]; ok && st.sent[id] == 1 {
+				status = code
+			}
+			if status < 300 {
+				st.docs[id] = post
+			}
+			items = append(items, fmt.Sprintf(`{"index":{"_index":%q,"_id":%q,"status":%d}}`, action["index"].Index, id, status))
+		}
+		fmt.Fprintf(w, `{"errors":true,"items":[%s]}`, strings.Join(items, ","))
+	default:
+		http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+	}
+}
+
+func TestOpenSearchWriterBulkIndexes(t *testing.T) {
+	stub := &bulkStub{
+		reject: map[string]int{"busy": http.StatusTooManyRequests, "bad": http.StatusBadRequest},
+		sent:   map[string]int{},
+		docs:   map[string]domain.Post{},
+	}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	w := &OpenSearchWriter{
+		URL:        srv.URL + "/",
+		Index:      "posts",
+		Username:   "soc",
+		Password:   "secret",
+		BatchSize:  2,
+		HTTPClient: srv.Client(),
+		RetryDelay: time.Millisecond,
+	}
+	input := make(chan domain.Post, 5)
+	for _, id := range []string{"a", "busy", "bad", "b", "c"} {
+		input <- domain.Post{ID: id, Title: "Splunk " + id, CreatedUTC: 1700000000}
+	}
+	close(input)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	w.Start(&wg, input)
+
+	// 429s are retried; other rejections are dropped
+	if w.Written != 4 || w.Failed != 1 || w.Consumed() != 4 {
+		t.Errorf("Written %d Failed %d, want 4 and 1", w.Written, w.Failed)
+	}
+	if stub.sent["busy"] != 2 || stub.sent["bad"] != 1 {
+		t.Errorf("sent busy %d times and bad %d times, want 2 and 1", stub.sent["busy"], stub.sent["bad"])
+	}
+	// Three batches of at most two, plus the retry
+	if stub.requests != 4 {
+		t.Errorf("made %d bulk requests, want 4", stub.requests)
+	}
+	for _, id := range []string{"a", "busy", "b", "c"} {
+		if doc, ok := stub.docs[id]; !ok || doc.ID != id || doc.Title != "Splunk "+id {
+			t.Errorf("document %s = %+v, want the post indexed under its ID", id, doc)
+		}
+	}
+	if !strings.Contains(stub.mapping, `"created_utc":{"type":"date","format":"epoch_second"}`) {
+		t.Errorf("index mapping = %s, want created_utc as a date", stub.mapping)
+	}
+	for _, a := range stub.auth {
+		if a != "soc:secret" {
+			t.Errorf("request sent with basic auth %q", a)
+		}
+	}
+}
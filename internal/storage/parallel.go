@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// ParallelSink runs Writers sinks from New on the same input, each taking
+// whichever post is next. Only use it for sinks that are safe to run side by
+// side and idempotent per post (e.g. OpenSearchWriter, which indexes by ID);
+// the file writer would interleave lines.
+type ParallelSink struct {
+	Writers int
+	New     func() Sink
+
+	sinks []Sink
+}
+
+// Consumed sums what the individual sinks consumed
+func (p *ParallelSink) Consumed() int {
+	n := 0
+	for _, s := range p.sinks {
+		n += s.Consumed()
+	}
+	return n
+}
+
+func (p *ParallelSink) Start(wg *sync.WaitGroup, input <-chan domain.Post) {
+	defer wg.Done()
+	var inner sync.WaitGroup
+	p.sinks = make([]Sink, max(p.Writers, 1))
+	for i := range p.sinks {
+		p.sinks[i] = p.New()
+		inner.Add(1)
+		go p.sinks[i].Start(&inner, input)
+	}
+	inner.Wait()
+}
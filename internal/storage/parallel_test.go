@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// memIndex is an idempotent sink target: posts are stored by ID, and every
+// write is counted so duplicates show up
+type memIndex struct {
+	mu     sync.Mutex
+	writes map[string]int
+}
+
+// memSink is one writer over a shared memIndex
+type memSink struct {
+	index    *memIndex
+	consumed int
+}
+
+func (s *memSink) Consumed() int { return s.consumed }
+
+func (s *memSink) Start(wg *sync.WaitGroup, input <-chan domain.Post) {
+	defer wg.Done()
+	for p := range input {
+		time.Sleep(10 * time.Microsecond) // a slow backend, so the writers overlap
+		s.index.mu.Lock()
+		s.index.writes[p.ID]++
+		s.index.mu.Unlock()
+		s.consumed++
+	}
+}
+
+func TestParallelSinkPersistsEachPostOnce(t *testing.T) {
+	const posts = 500
+	index := &memIndex{writes: map[string]int{}}
+	var sinks []*memSink
+	p := &ParallelSink{Writers: 4, New: func() Sink {
+		s := &memSink{index: index}
+		sinks = append(sinks, s)
+		return s
+	}}
+
+	input := make(chan domain.Post)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go p.Start(&wg, input)
+	for i := range posts {
+		input <- domain.Post{ID: fmt.Sprintf("p%d", i)}
+	}
+	close(input)
+	wg.Wait()
+
+	if len(sinks) != 4 {
+		t.Fatalf("started %d writers, want 4", len(sinks))
+	}
+	if len(index.writes) != posts || p.Consumed() != posts {
+		t.Errorf("stored %d posts, consumed %d; want %d", len(index.writes), p.Consumed(), posts)
+	}
+	for id, n := range index.writes {
+		if n != 1 {
+			t.Errorf("post %s written %d times", id, n)
+		}
+	}
+	busy := 0
+	for _, s := range sinks {
+		if s.consumed > 0 {
+			busy++
+		}
+	}
+	if busy < 2 {
+		t.Errorf("only %d of 4 writers took posts", busy)
+	}
+}
+
+func TestParallelSinkRunsAtLeastOneWriter(t *testing.T) {
+	index := &memIndex{writes: map[string]int{}}
+	p := &ParallelSink{New: func() Sink { return &memSink{index: index} }}
+	input := make(chan domain.Post, 1)
+	input <- domain.Post{ID: "a"}
+	close(input)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	p.Start(&wg, input)
+	if p.Consumed() != 1 || index.writes["a"] != 1 {
+		t.Errorf("Writers 0 consumed %d, want the post written by one writer", p.Consumed())
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// LoadPosts reads every post in the NDJSON file at path, in file order. The
+// schema header, lines that aren't posts and oversized records are skipped
+// (the latter with a warning). A read error part way returns the posts read
+// so far along with it.
+func LoadPosts(path string) ([]domain.Post, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var posts []domain.Post
+	err = ReadLines(f, MaxLineBytes, func(line []byte) {
+		// Files written before the schema header existed simply lack it
+		if IsHeader(line) {
+			return
+		}
+		var p domain.Post
+		if err := json.Unmarshal(line, &p); err == nil {
+			posts = append(posts, p)
+		}
+	}, func(lineNo int) {
+		slog.Warn("Skipping oversized record in data file", "path", path, "line", lineNo, "max_bytes", MaxLineBytes)
+	})
+	return posts, err
+}
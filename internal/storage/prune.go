@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// PruneResult summarises a Prune pass over one NDJSON file
+type PruneResult struct {
+	Kept    int
+	Removed int
+	Skipped int // oversized lines that couldn't be read (dropped with the rewrite)
+}
+
+// Prune rewrites the NDJSON file at path without the posts created before
+// cutoff. Records without created_utc (or that don't parse) are kept when
+// keepUndated is set, otherwise dropped. The new file is written next to the
+// old one and renamed over it, so readers never see a half-written file.
+// A missing file is not an error.
+func Prune(path string, cutoff time.Time, keepUndated bool) (PruneResult, error) {
+	var res PruneResult
+
+	in, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".prune-*")
+	if err != nil {
+		return res, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	tmp.Chmod(0644)             // match the writer's mode, CreateTemp uses 0600
+
+	var writeErr error
+	readErr := ReadLines(in, MaxLineBytes, func(line []byte) {
+		if writeErr != nil {
+			return
+		}
+		// The schema header always survives; it has no created_utc
+		if !IsHeader(line) && !keepRecord(line, cutoff, keepUndated) {
+			res.Removed++
+			return
+		}
+		if !IsHeader(line) {
+			res.Kept++
+		}
+		if _, err := tmp.Write(line); err != nil {
+			writeErr = err
+			return
+		}
+		_, writeErr = tmp.Write([]byte{'\n'})
+	}, func(int) { res.Skipped++ })
+
+	if err := errors.Join(readErr, writeErr); err != nil {
+		tmp.Close()
+		return res, fmt.Errorf("pruning %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return res, err
+	}
+	if res.Removed == 0 && res.Skipped == 0 {
+		return res, nil // nothing changed, leave the original alone
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return res, fmt.Errorf("pruning %s: %w", path, err)
+	}
+	return res, nil
+}
+
+func keepRecord(line []byte, cutoff time.Time, keepUndated bool) bool {
+	var p struct {
+		CreatedUTC float64 `json:"created_utc"`
+	}
+	if err := json.Unmarshal(line, &p); err != nil || p.CreatedUTC <= 0 {
+		return keepUndated
+	}
+	return !domain.Post{CreatedUTC: p.CreatedUTC}.CreatedTime().Before(cutoff)
+}
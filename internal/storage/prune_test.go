@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestPruneRemovesOldPosts(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	at := func(id string, age time.Duration) domain.Post {
+		return domain.Post{ID: id, Title: id, CreatedUTC: float64(now.Add(-age).Unix())}
+	}
+	posts := []domain.Post{
+		at("old", 200*time.Hour),
+		at("recent", time.Hour),
+		{ID: "undated", Title: "undated"},
+		at("edge", 168*time.Hour), // exactly at the cutoff is kept
+	}
+	cutoff := now.Add(-168 * time.Hour)
+
+	for _, tt := range []struct {
+		keepUndated bool
+		want        []string
+	}{
+		{true, []string{"recent", "undated", "edge"}},
+		{false, []string{"recent", "edge"}},
+	} {
+		w := &WriterService{}
+		writeRecords(t, w, posts...)
+		res, err := Prune(w.FilePath, cutoff, tt.keepUndated)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := splitIDs(t, w.FilePath); !slices.Equal(got, tt.want) {
+			t.Errorf("keepUndated=%v: kept %v, want %v", tt.keepUndated, got, tt.want)
+		}
+		if res.Kept != len(tt.want) || res.Removed != len(posts)-len(tt.want) {
+			t.Errorf("keepUndated=%v: result %+v", tt.keepUndated, res)
+		}
+		data, _ := os.ReadFile(w.FilePath)
+		if countHeaders(data) != 1 {
+			t.Errorf("keepUndated=%v: schema header lost", tt.keepUndated)
+		}
+		info, _ := os.Stat(w.FilePath)
+		if info.Mode().Perm() != 0o644 {
+			t.Errorf("pruned file mode %v, want 0644", info.Mode().Perm())
+		}
+		if leftovers, _ := filepath.Glob(w.FilePath + ".prune-*"); len(leftovers) > 0 {
+			t.Errorf("temp files left behind: %v", leftovers)
+		}
+	}
+}
+
+func TestPruneLeavesUnchangedAndMissingFiles(t *testing.T) {
+	w := &WriterService{}
+	writeRecords(t, w, domain.Post{ID: "a", CreatedUTC: float64(time.Now().Unix())})
+	before, _ := os.Stat(w.FilePath)
+
+	res, err := Prune(w.FilePath, time.Now().Add(-time.Hour), true)
+	if err != nil || res.Removed != 0 || res.Kept != 1 {
+		t.Fatalf("Prune = %+v, %v", res, err)
+	}
+	after, _ := os.Stat(w.FilePath)
+	if !os.SameFile(before, after) {
+		t.Error("a file with nothing to prune was rewritten")
+	}
+
+	if _, err := Prune(filepath.Join(t.TempDir(), "missing.ndjson"), time.Now(), true); err != nil {
+		t.Errorf("missing file: %v", err)
+	}
+}
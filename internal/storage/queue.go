@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+// QueueState is a scrape cycle's progress, saved as it goes so a restart can
+// resume the cycle instead of starting it over (RESUME_CYCLES)
+type QueueState struct {
+	// CycleID identifies the cycle the rest of the state belongs to
+	CycleID string    `json:"cycle_id"`
+	Started time.Time `json:"started"`
+	// Targets is the cycle's full queue and Done the targets finished so
+	// far, both by Target.Name
+	Targets []string `json:"targets"`
+	Done    []string `json:"done"`
+}
+
+// SaveQueueState writes the state as JSON, replaced atomically like the stats file
+func SaveQueueState(path string, state QueueState) error {
+	return writeJSONAtomic(path, state)
+}
+
+// LoadQueueState reads a file written by SaveQueueState. A missing file
+// yields a zero state (no CycleID), meaning there is nothing to resume.
+func LoadQueueState(path string) (QueueState, error) {
+	var state QueueState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return QueueState{}, err
+	}
+	return state, nil
+}
+
+// ClearQueueState removes the state once its cycle has finished
+func ClearQueueState(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// RetagResult summarises a Retag pass over one NDJSON file
+type RetagResult struct {
+	Records int
+	Changed int
+	Skipped int // oversized lines that couldn't be read (dropped with the rewrite, as in Prune)
+	// Untitled counts records without a stored title (e.g. an OUTPUT_FIELDS
+	// projection that left it out); there is nothing to match, so their
+	// keywords_hit is kept as is
+	Untitled int
+}
+
+// Retag recomputes keywords_hit for every record in the NDJSON file at path
+// by calling match on its title, and rewrites the file when anything changed.
+// Only keywords_hit is touched: other fields (including ones a projected
+// OUTPUT_FIELDS file lacks) are carried over as stored, though a rewritten
+// record lists its fields alphabetically. Lines that aren't JSON objects are
+// copied unchanged. Like Prune, the new file is renamed over the old one.
+func Retag(path string, match func(title string) []string) (RetagResult, error) {
+	var res RetagResult
+
+	in, err := os.Open(path)
+	if err != nil {
+		return res, err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".retag-*")
+	if err != nil {
+		return res, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	tmp.Chmod(0644)
+
+	var writeErr error
+	readErr := ReadLines(in, MaxLineBytes, func(line []byte) {
+		if writeErr != nil {
+			return
+		}
+		out := line
+		if !IsHeader(line) {
+			res.Records++
+			switch retagged, status := retagRecord(line, match); status {
+			case retagChanged:
+				res.Changed++
+				out = retagged
+			case retagUntitled:
+				res.Untitled++
+			}
+		}
+		if _, err := tmp.Write(out); err != nil {
+			writeErr = err
+			return
+		}
+		_, writeErr = tmp.Write([]byte{'\n'})
+	}, func(int) { res.Skipped++ })
+
+	if err := errors.Join(readErr, writeErr); err != nil {
+		tmp.Close()
+		return res, fmt.Errorf("retagging %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return res, err
+	}
+	if res.Changed == 0 && res.Skipped == 0 {
+		return res, nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return res, fmt.Errorf("retagging %s: %w", path, err)
+	}
+	return res, nil
+}
+
+// retagStatus is what retagRecord did with a line
+type retagStatus int
+
+const (
+	retagSame     retagStatus = iota // not a record, or the hits didn't change
+	retagChanged                     // keywords_hit was rewritten
+	retagUntitled                    // no title to match, left untouched
+)
+
+// retagRecord returns line with keywords_hit replaced by match(title).
+// Records without a title are returned unchanged rather than cleared.
+func retagRecord(line []byte, match func(string) []string) ([]byte, retagStatus) {
+	var rec map[string]json.RawMessage
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return line, retagSame
+	}
+	var title string
+	if err := json.Unmarshal(rec["title"], &title); err != nil {
+		return line, retagUntitled
+	}
+	var old []string
+	json.Unmarshal(rec["keywords_hit"], &old)
+
+	hits := match(title)
+	if slices.Equal(hits, old) {
+		return line, retagSame
+	}
+	if len(hits) == 0 {
+		delete(rec, "keywords_hit") // omitempty, as the writer does
+	} else {
+		raw, _ := json.Marshal(hits)
+		rec["keywords_hit"] = raw
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(rec); err != nil {
+		return line, retagSame
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), retagChanged
+}
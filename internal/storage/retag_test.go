@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRetagLeavesUntitledRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.ndjson")
+	data := `{"_schema":1}
+{"id":"a","title":"Splunk tips","keywords_hit":["old"]}
+{"id":"b","keywords_hit":["kept"]}
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	match := func(title string) []string {
+		if strings.Contains(title, "Splunk") {
+			return []string{"splunk"}
+		}
+		return nil
+	}
+
+	res, err := Retag(path, match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Records != 2 || res.Changed != 1 || res.Untitled != 1 {
+		t.Fatalf("result = %+v, want 2 records, 1 changed, 1 untitled", res)
+	}
+	got, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(got)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), got)
+	}
+	if !strings.Contains(lines[1], `"keywords_hit":["splunk"]`) {
+		t.Errorf("titled record not retagged: %s", lines[1])
+	}
+	if lines[2] != `{"id":"b","keywords_hit":["kept"]}` {
+		t.Errorf("untitled record changed: %s", lines[2])
+	}
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// RotationState is where the next capped cycle starts in the target list
+// (MAX_TARGETS_PER_CYCLE), saved so a restart carries on the rotation
+type RotationState struct {
+	Offset int `json:"offset"`
+}
+
+// SaveRotationState writes the state as JSON, replaced atomically like the stats file
+func SaveRotationState(path string, state RotationState) error {
+	return writeJSONAtomic(path, state)
+}
+
+// LoadRotationState reads a file written by SaveRotationState. A missing
+// file yields a zero state, starting at the top of the list.
+func LoadRotationState(path string) (RotationState, error) {
+	var state RotationState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return RotationState{}, err
+	}
+	return state, nil
+}
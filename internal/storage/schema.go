@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// SchemaVersion identifies the record format written by WriterService. Bump
+// it whenever domain.Post's JSON shape changes in a way parsers would notice
+// (renamed or retyped fields; new omitempty fields don't need a bump).
+const SchemaVersion = 1
+
+// SchemaHeader is the first line of every NDJSON file the writer creates
+type SchemaHeader struct {
+	Schema      int    `json:"_schema"`
+	GeneratedAt string `json:"generated_at"`
+}
+
+var headerPrefix = []byte(`{"_schema"`)
+
+// IsHeader reports whether an NDJSON line is a SchemaHeader rather than a post
+func IsHeader(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(line), headerPrefix)
+}
+
+// writeHeaderIfEmpty starts a new (empty) file with a SchemaHeader; appending
+// to an existing file leaves its first line alone
+func writeHeaderIfEmpty(f *os.File, enc *json.Encoder) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() > 0 {
+		return err
+	}
+	return enc.Encode(SchemaHeader{Schema: SchemaVersion, GeneratedAt: time.Now().UTC().Format(time.RFC3339)})
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestSchemaHeaderRoundTrip(t *testing.T) {
+	posts := []domain.Post{
+		{ID: "a", Title: "Splunk tips", Subreddit: "netsec", Score: 3, CreatedUTC: 1700000000, KeywordsHit: []string{"splunk"}},
+		{ID: "b", Title: "MISP feeds", Subreddit: "Malware", Score: 9},
+	}
+	w := &WriterService{}
+	writeRecords(t, w, posts...)
+	// A second run appends to the same file without a second header
+	writeRecords(t, w, domain.Post{ID: "c", Title: "later"})
+
+	data, err := os.ReadFile(w.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	var header SchemaHeader
+	if err := json.Unmarshal([]byte(first), &header); err != nil || header.Schema != SchemaVersion {
+		t.Fatalf("first line %s, want a schema %d header", first, SchemaVersion)
+	}
+	if _, err := time.Parse(time.RFC3339, header.GeneratedAt); err != nil {
+		t.Errorf("generated_at %q: %v", header.GeneratedAt, err)
+	}
+	if n := countHeaders(data); n != 1 {
+		t.Errorf("%d headers after appending, want 1", n)
+	}
+
+	loaded, err := LoadPosts(w.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(posts, domain.Post{ID: "c", Title: "later"})
+	if !reflect.DeepEqual(loaded, want) {
+		t.Errorf("loaded %+v, want %+v", loaded, want)
+	}
+}
+
+func TestLoadPostsWithoutHeader(t *testing.T) {
+	// Files from before the header existed
+	path := filepath.Join(t.TempDir(), "old.ndjson")
+	os.WriteFile(path, []byte(`{"id":"a","title":"old"}`+"\n"+`{"id":"b","title":"older"}`+"\n"), 0o644)
+	posts, err := LoadPosts(path)
+	if err != nil || len(posts) != 2 || posts[0].ID != "a" {
+		t.Errorf("loaded %+v (%v), want both records", posts, err)
+	}
+}
+
+func TestIsHeader(t *testing.T) {
+	for line, want := range map[string]bool{
+		`{"_schema":1,"generated_at":"2025-01-01T00:00:00Z"}`: true,
+		`  {"_schema":2}`:              true,
+		`{"id":"_schema","title":"x"}`: false,
+		`{"title":"{\"_schema\":1}"}`:  false,
+		``:                             false,
+	} {
+		if got := IsHeader([]byte(line)); got != want {
+			t.Errorf("IsHeader(%s) = %v, want %v", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxOpenSplitFiles bounds the per-subreddit file handles kept open at once.
+// The least recently written file is closed first; it's simply reopened in
+// append mode if that subreddit shows up again.
+const maxOpenSplitFiles = 32
+
+type splitFile struct {
+	f        *os.File
+	enc      *json.Encoder
+	lastUsed time.Time
+}
+
+// splitWriter appends records to one NDJSON file per subreddit
+type splitWriter struct {
+	dir      string
+	maxOpen  int
+	encoding string
+	files    map[string]*splitFile
+}
+
+func newSplitWriter(dir string, maxOpen int, encoding string) *splitWriter {
+	return &splitWriter{dir: dir, maxOpen: maxOpen, encoding: encoding, files: make(map[string]*splitFile)}
+}
+
+func (sw *splitWriter) Write(subreddit string, record any) error {
+	name := SanitizeSubreddit(subreddit)
+	sf, ok := sw.files[name]
+	if !ok {
+		if len(sw.files) >= sw.maxOpen {
+			sw.closeIdlest()
+		}
+		f, err := os.OpenFile(filepath.Join(sw.dir, name+".ndjson"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		sf = &splitFile{f: f, enc: newEncoder(encodingWriter(f, sw.encoding))}
+		if err := writeHeaderIfEmpty(f, sf.enc); err != nil {
+			f.Close()
+			return err
+		}
+		sw.files[name] = sf
+	}
+	sf.lastUsed = time.Now()
+	return sf.enc.Encode(record)
+}
+
+func (sw *splitWriter) closeIdlest() {
+	var idlest string
+	for name, sf := range sw.files {
+		if idlest == "" || sf.lastUsed.Before(sw.files[idlest].lastUsed) {
+			idlest = name
+		}
+	}
+	if idlest != "" {
+		sw.files[idlest].f.Close()
+		delete(sw.files, idlest)
+	}
+}
+
+func (sw *splitWriter) Close() {
+	for name, sf := range sw.files {
+		sf.f.Close()
+		delete(sw.files, name)
+	}
+}
+
+// SanitizeSubreddit turns a subreddit name ("r/NetSec") into a safe file stem
+// ("netsec"). Anything outside [a-z0-9_-] becomes "_", so names can never
+// traverse directories.
+func SanitizeSubreddit(name string) string {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "r/"))
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(name, "_") == "" {
+		return "_unknown"
+	}
+	return name
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// splitIDs reads a per-subreddit file's post IDs
+func splitIDs(t *testing.T, path string) []string {
+	t.Helper()
+	posts, err := LoadPosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestSplitBySubreddit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "by-sub")
+	ch := make(chan domain.Post, 4)
+	ch <- domain.Post{ID: "a", Subreddit: "r/netsec"}
+	ch <- domain.Post{ID: "b", Subreddit: "r/Malware"}
+	ch <- domain.Post{ID: "c", Subreddit: "netsec"}
+	ch <- domain.Post{ID: "d", Subreddit: "../../etc"}
+	close(ch)
+
+	// Split only: no combined file
+	var wg sync.WaitGroup
+	wg.Add(1)
+	(&WriterService{SplitDir: dir}).Start(&wg, ch)
+
+	want := map[string][]string{
+		"netsec.ndjson":    {"a", "c"},
+		"malware.ndjson":   {"b"},
+		"______etc.ndjson": {"d"},
+	}
+	for name, ids := range want {
+		if got := splitIDs(t, filepath.Join(dir, name)); !slices.Equal(got, ids) {
+			t.Errorf("%s holds %v, want %v", name, got, ids)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(want) {
+		t.Errorf("%d files in the split dir, want %d", len(entries), len(want))
+	}
+}
+
+func TestSplitWriterReopensClosedFiles(t *testing.T) {
+	dir := t.TempDir()
+	sw := newSplitWriter(dir, 2, "")
+	for _, p := range []domain.Post{
+		{ID: "1", Subreddit: "netsec"},
+		{ID: "2", Subreddit: "malware"},
+		{ID: "3", Subreddit: "blueteamsec"}, // closes netsec, the idlest
+		{ID: "4", Subreddit: "netsec"},
+	} {
+		if err := sw.Write(p.Subreddit, p); err != nil {
+			t.Fatal(err)
+		}
+		if len(sw.files) > 2 {
+			t.Fatalf("%d files open, want at most 2", len(sw.files))
+		}
+	}
+	sw.Close()
+
+	// Reopening appends, and the schema header is written only once
+	if got := splitIDs(t, filepath.Join(dir, "netsec.ndjson")); !slices.Equal(got, []string{"1", "4"}) {
+		t.Errorf("netsec holds %v, want 1 4", got)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "netsec.ndjson"))
+	if n := countHeaders(data); n != 1 {
+		t.Errorf("netsec has %d schema headers, want 1", n)
+	}
+}
+
+func TestSanitizeSubreddit(t *testing.T) {
+	for in, want := range map[string]string{
+		"r/NetSec":    "netsec",
+		" blue_team ": "blue_team",
+		"a/b":         "a_b",
+		"..":          "_unknown",
+		"":            "_unknown",
+		"r/!!!":       "_unknown",
+	} {
+		if got := SanitizeSubreddit(in); got != want {
+			t.Errorf("SanitizeSubreddit(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
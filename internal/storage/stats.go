@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// SaveStats writes subreddit stats (keyed by domain.SubredditKey) as JSON,
+// replacing the file atomically so the dashboard never reads half a file
+func SaveStats(path string, stats map[string]domain.SubredditStats) error {
+	return writeJSONAtomic(path, stats)
+}
+
+// writeJSONAtomic writes v as indented JSON through a temp file and a rename
+func writeJSONAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	tmp.Chmod(0644)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadStats reads a file written by SaveStats. A missing file yields an empty map.
+func LoadStats(path string) (map[string]domain.SubredditStats, error) {
+	stats := make(map[string]domain.SubredditStats)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
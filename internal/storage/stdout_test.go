@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+func TestWriterStreamsNDJSONToStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	input := make(chan domain.Post)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		(&WriterService{FilePath: StdoutPath, BufferSize: DefaultBufferSize}).Start(&wg, input)
+		w.Close()
+	}()
+
+	lines := bufio.NewScanner(r)
+	read := func() domain.Post {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("no line on stdout: %v", lines.Err())
+		}
+		var p domain.Post
+		if err := json.Unmarshal(lines.Bytes(), &p); err != nil {
+			t.Fatalf("stdout line %q isn't a JSON post: %v", lines.Text(), err)
+		}
+		return p
+	}
+
+	// Each post is readable as soon as it's written, before input closes,
+	// and there is no schema header in the stream
+	for _, id := range []string{"abc", "def"} {
+		input <- domain.Post{ID: id, Title: "Splunk " + id, Subreddit: "netsec"}
+		if p := read(); p.ID != id {
+			t.Errorf("read post %q, want %q", p.ID, id)
+		}
+	}
+	close(input)
+	wg.Wait()
+	if lines.Scan() {
+		t.Errorf("unexpected trailing output %q", lines.Text())
+	}
+}
@@ -1,31 +1,219 @@
 package storage
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/qepting91/reddit-scraper/internal/domain"
 )
 
+// Sink is the last pipeline stage: it consumes posts until input is closed,
+// then calls wg.Done. Consumed is read only after that.
+type Sink interface {
+	Start(wg *sync.WaitGroup, input <-chan domain.Post)
+	Consumed() int
+}
+
+// Output buffering defaults (OUTPUT_BUFFER_SIZE, OUTPUT_FLUSH_INTERVAL)
+const (
+	DefaultBufferSize    = 64 << 10
+	DefaultFlushInterval = time.Second
+)
+
+// StdoutPath as a FilePath streams the combined NDJSON to standard output
+// (OUTPUT_PATH=-)
+const StdoutPath = "-"
+
 // WriterService implements the Monitor Pattern for thread safety
 type WriterService struct {
+	// FilePath is the combined NDJSON file. Empty skips it (e.g. split-only
+	// output); StdoutPath writes each post to stdout as soon as it arrives,
+	// without the schema header or buffering.
 	FilePath string
+	// Fields optionally limits each record to these JSON keys (see ValidateFields).
+	// Empty means every field is written.
+	Fields []string
+	// SplitDir, when set, also routes each post to <SplitDir>/<subreddit>.ndjson
+	SplitDir string
+	// DailyDir, when set, also writes each post to <DailyDir>/<date>.ndjson
+	// for the day it's written on in DailyLocation (nil = UTC), see DailyFile
+	DailyDir      string
+	DailyLocation *time.Location
+	// Encoding is the OUTPUT_ENCODING (see ValidEncoding); empty means utf8
+	Encoding string
+
+	// BufferSize, when > 0, buffers FilePath writes in memory (OUTPUT_BUFFER_SIZE).
+	// The buffer is flushed when full, every FlushInterval (if > 0) so readers
+	// like the dashboard stay current, and when input is closed.
+	BufferSize    int
+	FlushInterval time.Duration
+
+	// Written counts the posts consumed; read it only after Start returns
+	Written int
 }
 
+// Consumed reports how many posts were written
+func (w *WriterService) Consumed() int { return w.Written }
+
 func (w *WriterService) Start(wg *sync.WaitGroup, input <-chan domain.Post) {
 	defer wg.Done()
 
-	f, err := os.OpenFile(w.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
+	var enc *json.Encoder
+	var buf *bufio.Writer
+	if w.FilePath == StdoutPath {
+		// One Write per post, so a reader sees whole lines straight away
+		enc = newEncoder(encodingWriter(os.Stdout, w.Encoding))
+	} else if w.FilePath != "" {
+		f, err := os.OpenFile(w.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		var out io.Writer = f
+		if w.BufferSize > 0 {
+			buf = bufio.NewWriterSize(f, w.BufferSize)
+			// Deferred after Close, so it runs first
+			defer w.flush(buf)
+			out = buf
+		}
+		enc = newEncoder(encodingWriter(out, w.Encoding))
+		if err := writeHeaderIfEmpty(f, enc); err != nil {
+			slog.Warn("Could not write schema header", "path", w.FilePath, "err", err)
+		}
+	}
+
+	var split *splitWriter
+	if w.SplitDir != "" {
+		if err := os.MkdirAll(w.SplitDir, 0755); err != nil {
+			slog.Error("Cannot create split output directory", "dir", w.SplitDir, "err", err)
+		} else {
+			split = newSplitWriter(w.SplitDir, maxOpenSplitFiles, w.Encoding)
+			defer split.Close()
+		}
+	}
+
+	var daily *dailyWriter
+	if w.DailyDir != "" {
+		if err := os.MkdirAll(w.DailyDir, 0755); err != nil {
+			slog.Error("Cannot create daily output directory", "dir", w.DailyDir, "err", err)
+		} else {
+			daily = newDailyWriter(w.DailyDir, w.DailyLocation, w.Encoding)
+			defer daily.Close()
+		}
+	}
+
+	// A nil channel never fires, so unbuffered or interval-less output just
+	// consumes input
+	var tick <-chan time.Time
+	if buf != nil && w.FlushInterval > 0 {
+		ticker := time.NewTicker(w.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case post, ok := <-input:
+			if !ok {
+				return
+			}
+			w.write(post, enc, split, daily)
+		case <-tick:
+			w.flush(buf)
+		}
+	}
+}
+
+func (w *WriterService) write(post domain.Post, enc *json.Encoder, split *splitWriter, daily *dailyWriter) {
+	w.Written++
+	// Write as NDJSON
+	var record any = post
+	if len(w.Fields) > 0 {
+		record = project(post, w.Fields)
+	}
+	if enc != nil {
+		enc.Encode(record)
+	}
+	if split != nil {
+		if err := split.Write(post.Subreddit, record); err != nil {
+			slog.Warn("Per-subreddit write failed", "sub", post.Subreddit, "err", err)
+		}
+	}
+	if daily != nil {
+		if err := daily.Write(record, time.Now()); err != nil {
+			slog.Warn("Daily write failed", "dir", w.DailyDir, "err", err)
+		}
+	}
+}
+
+func (w *WriterService) flush(buf *bufio.Writer) {
+	if buf.Buffered() == 0 {
 		return
 	}
-	defer f.Close()
+	if err := buf.Flush(); err != nil {
+		slog.Error("Flushing buffered output failed", "path", w.FilePath, "err", err)
+	}
+}
+
+func newEncoder(out io.Writer) *json.Encoder {
+	enc := json.NewEncoder(out)
+	// Keep "&", "<" and ">" literal so RAW_CAPTURE payloads stay byte-identical to the source
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+// ValidateFields checks every name against domain.Post's JSON tags
+func ValidateFields(fields []string) error {
+	known := postJSONFields()
+	var unknown []string
+	for _, f := range fields {
+		if !known[f] {
+			unknown = append(unknown, f)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown output field(s): %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// project re-encodes a post keeping only the selected keys. Going through the
+// normal marshaller keeps omitempty and any custom encoding intact, and the
+// same unescaped encoder as unprojected records keeps the values byte-identical.
+func project(p domain.Post, fields []string) map[string]json.RawMessage {
+	var raw bytes.Buffer
+	newEncoder(&raw).Encode(p)
+	var all map[string]json.RawMessage
+	json.Unmarshal(raw.Bytes(), &all)
 
-	enc := json.NewEncoder(f)
+	out := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f]; ok {
+			out[f] = v
+		}
+	}
+	return out
+}
 
-	for post := range input {
-		// Write as NDJSON
-		enc.Encode(post)
+func postJSONFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(domain.Post{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
 	}
+	// Derived in Post.MarshalJSON rather than declared as a struct field
+	fields["created_at"] = true
+	return fields
 }
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qepting91/reddit-scraper/internal/domain"
+)
+
+// writeRecords runs w over posts and returns the data file's records,
+// without the schema header
+func writeRecords(t *testing.T, w *WriterService, posts ...domain.Post) []string {
+	t.Helper()
+	if w.FilePath == "" {
+		w.FilePath = filepath.Join(t.TempDir(), "current.ndjson")
+	}
+	ch := make(chan domain.Post, len(posts))
+	for _, p := range posts {
+		ch <- p
+	}
+	close(ch)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	w.Start(&wg, ch)
+
+	data, err := os.ReadFile(w.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !IsHeader([]byte(line)) {
+			records = append(records, line)
+		}
+	}
+	return records
+}
+
+var htmlPost = domain.Post{
+	ID:          "abc",
+	Title:       "Detect <script> & friends > 5",
+	Subreddit:   "netsec",
+	Score:       42,
+	KeywordsHit: []string{"detect"},
+	Raw:         json.RawMessage(`{"id":"abc","title":"Detect <script> & friends > 5"}`),
+}
+
+func TestProjectionOmitsUnselectedFields(t *testing.T) {
+	fields := []string{"id", "subreddit", "title", "score", "keywords_hit"}
+	records := writeRecords(t, &WriterService{Fields: fields}, htmlPost)
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(records[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for k := range got {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if want := slices.Sorted(slices.Values(fields)); !slices.Equal(keys, want) {
+		t.Errorf("projected keys = %v, want %v", keys, want)
+	}
+}
+
+func TestProjectionKeepsValuesByteIdentical(t *testing.T) {
+	full := writeRecords(t, &WriterService{}, htmlPost)
+	projected := writeRecords(t, &WriterService{Fields: []string{"id", "title", "raw"}}, htmlPost)
+
+	var fullFields, projFields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(full[0]), &fullFields); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(projected[0]), &projFields); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"id", "title", "raw"} {
+		if string(projFields[f]) != string(fullFields[f]) {
+			t.Errorf("%s: projected %s, unprojected %s", f, projFields[f], fullFields[f])
+		}
+	}
+	if !strings.Contains(projected[0], `"title":"Detect <script> & friends > 5"`) {
+		t.Errorf("projected title was escaped: %s", projected[0])
+	}
+	if !strings.Contains(projected[0], string(htmlPost.Raw)) {
+		t.Errorf("projected raw isn't the source bytes: %s", projected[0])
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	if err := ValidateFields([]string{"id", "created_at", "keywords_hit"}); err != nil {
+		t.Errorf("known fields: %v", err)
+	}
+	err := ValidateFields([]string{"id", "upvotes", "Title"})
+	if err == nil || !strings.Contains(err.Error(), "upvotes, Title") {
+		t.Errorf("err = %v, want both unknown fields named", err)
+	}
+}
+
+func TestRawRoundTripsThroughWriterAndLoader(t *testing.T) {
+	w := &WriterService{}
+	writeRecords(t, w, htmlPost, domain.Post{ID: "plain", Title: "no raw"})
+
+	posts, err := LoadPosts(w.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("loaded %d posts, want 2", len(posts))
+	}
+	if string(posts[0].Raw) != string(htmlPost.Raw) {
+		t.Errorf("Raw = %s, want the source bytes %s", posts[0].Raw, htmlPost.Raw)
+	}
+	if posts[1].Raw != nil {
+		t.Errorf("post without raw loaded Raw = %s, want nil", posts[1].Raw)
+	}
+}
+
+// countHeaders counts the schema header lines in an NDJSON file's content
+func countHeaders(data []byte) int {
+	n := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if IsHeader([]byte(line)) {
+			n++
+		}
+	}
+	return n
+}
+
+// countRecords counts the posts in the NDJSON file at path so far
+func countRecords(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n") - countHeaders(data)
+}
+
+func TestBufferedWriterFlushesOnShutdown(t *testing.T) {
+	const posts = 200
+	path := filepath.Join(t.TempDir(), "current.ndjson")
+	w := &WriterService{FilePath: path, BufferSize: 1 << 20}
+	input := make(chan domain.Post)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go w.Start(&wg, input)
+
+	for i := range posts {
+		input <- domain.Post{ID: fmt.Sprintf("p%d", i), Title: "Splunk"}
+	}
+	// Everything still fits in the buffer, so nothing is on disk yet
+	if n := countRecords(t, path); n != 0 {
+		t.Errorf("%d records on disk before shutdown, want them buffered", n)
+	}
+	close(input)
+	wg.Wait()
+
+	if n := countRecords(t, path); n != posts {
+		t.Errorf("%d records after shutdown, want all %d", n, posts)
+	}
+}
+
+func TestBufferedWriterFlushesEveryInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "current.ndjson")
+	w := &WriterService{FilePath: path, BufferSize: 1 << 20, FlushInterval: 10 * time.Millisecond}
+	input := make(chan domain.Post)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go w.Start(&wg, input)
+	defer func() {
+		close(input)
+		wg.Wait()
+	}()
+
+	input <- domain.Post{ID: "a", Title: "Splunk"}
+	deadline := time.Now().Add(5 * time.Second)
+	for countRecords(t, path) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("buffered post never flushed while the writer was idle")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}